@@ -0,0 +1,298 @@
+package main
+
+import (
+	"flag"
+	"time"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/config"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/controller"
+)
+
+// controllerFlags are the command line flags of the controller.
+type controllerFlags struct {
+	configFilePath   string
+	criSocketPath    string
+	multusSocketPath string
+	netnsAnnotation  string
+
+	workerCount              int
+	delegateTimeout          time.Duration
+	maxConcurrentDelegates   int
+	maxAttachmentsPerPod     int
+	cacheSyncTimeout         time.Duration
+	drainTimeout             time.Duration
+	shutdownTimeout          time.Duration
+	resyncPeriod             time.Duration
+	maxRetries               int
+	retryBaseDelay           time.Duration
+	retryMaxDelay            time.Duration
+	eventDeduplicationWindow time.Duration
+
+	metricsAddress string
+	healthAddress  string
+	debugAddress   string
+	adminAddress   string
+	otlpEndpoint   string
+	auditLogPath   string
+
+	leaderElect    bool
+	leaseNamespace string
+	leaseName      string
+
+	namespace                    string
+	podSelector                  string
+	reattachOnNetAttachDefUpdate bool
+	verifyNetworkStatus          bool
+	dryRun                       bool
+	podFinalizer                 bool
+	interfaceStates              bool
+	networksReadyCondition       bool
+	annotationPrefix             string
+	restrictCrossNamespaceRefs   bool
+	podNetworkAttachments        bool
+	networksUpdateWebhook        string
+	cniArgsPodLabels             string
+	cniArgsPodAnnotations        string
+}
+
+// parseFlags registers the command line flags - along with klog's -, then
+// parses them.
+func parseFlags() *controllerFlags {
+	klog.InitFlags(nil)
+	flags := &controllerFlags{}
+	flags.registerRuntimeFlags()
+	flags.registerProcessingFlags()
+	flags.registerObservabilityFlags()
+	flags.registerLeaderElectionFlags()
+	flags.registerPodFlags()
+	flag.Parse()
+	return flags
+}
+
+// registerRuntimeFlags registers the flags locating the container runtime,
+// the multus server, and the pods' network namespaces.
+func (flags *controllerFlags) registerRuntimeFlags() {
+	flag.StringVar(
+		&flags.configFilePath,
+		"config",
+		config.DefaultDynamicNetworksControllerConfigFile,
+		"Specify the path to the multus-daemon configuration")
+	flag.StringVar(
+		&flags.criSocketPath,
+		"cri-socket",
+		"",
+		"Specify the path of the container runtime socket - or a comma separated list of paths, tried in order until a runtime answers -, the runtime being detected from the path; overrides the multus-daemon configuration when set")
+	flag.StringVar(
+		&flags.multusSocketPath,
+		"multus-socket",
+		"",
+		"Specify the path of the multus-daemon socket the delegates are invoked through; overrides the multus-daemon configuration when set")
+	flag.StringVar(
+		&flags.netnsAnnotation,
+		"netns-annotation",
+		"",
+		"Specify the pod annotation - e.g. networks.cncf.io/netns - featuring the path of the pod's network namespace; when a pod features it, the container runtime is not asked for the path")
+}
+
+// registerProcessingFlags registers the flags tuning how the dynamic
+// attachment requests are processed.
+func (flags *controllerFlags) registerProcessingFlags() {
+	flag.IntVar(
+		&flags.workerCount,
+		"workers",
+		1,
+		"Specify the number of workers concurrently processing dynamic attachment requests")
+	flag.DurationVar(
+		&flags.delegateTimeout,
+		"delegate-timeout",
+		controller.DefaultDelegateTimeout,
+		"Specify how long each multus delegate invocation can take")
+	flag.IntVar(
+		&flags.maxConcurrentDelegates,
+		"max-concurrent-delegates",
+		0,
+		"Specify how many multus delegate invocations can run concurrently, regardless of the number of workers; 0 leaves them unbounded")
+	flag.IntVar(
+		&flags.maxAttachmentsPerPod,
+		"max-attachments-per-pod",
+		0,
+		"Specify how many networks a pod's networks annotation can request; the updates requesting more are rejected. 0 leaves them unbounded")
+	flag.DurationVar(
+		&flags.cacheSyncTimeout,
+		"cache-sync-timeout",
+		controller.DefaultCacheSyncTimeout,
+		"Specify how long the pod and network-attachment-definition caches are given to synchronize on start; the controller exits when they do not. 0 waits indefinitely")
+	flag.DurationVar(
+		&flags.drainTimeout,
+		"drain-timeout",
+		controller.DefaultDrainTimeout,
+		"Specify how long the queued dynamic attachment requests are given to complete on shutdown")
+	flag.DurationVar(
+		&flags.shutdownTimeout,
+		"shutdown-timeout",
+		controller.DefaultShutdownTimeout,
+		"Specify how long the workers are given to finish once the requests are drained, before the controller exits regardless - e.g. abandoning hung CNI calls. 0 waits indefinitely")
+	flag.DurationVar(
+		&flags.resyncPeriod,
+		"resync-period",
+		defaultResyncPeriod,
+		"Specify how often the pods' interfaces are reconciled against their network selection elements; 0 disables it")
+	flag.IntVar(
+		&flags.maxRetries,
+		"max-retries",
+		controller.DefaultMaxRetries,
+		"Specify how many times a failed dynamic attachment request is retried before being dropped - i.e. it is attempted up to max-retries + 1 times")
+	flag.DurationVar(
+		&flags.retryBaseDelay,
+		"retry-base-delay",
+		controller.DefaultRetryBaseDelay,
+		"Specify the delay before the first retry of a failed dynamic attachment request; it doubles on each retry")
+	flag.DurationVar(
+		&flags.retryMaxDelay,
+		"retry-max-delay",
+		controller.DefaultRetryMaxDelay,
+		"Specify the maximum delay between the retries of a failed dynamic attachment request")
+	flag.DurationVar(
+		&flags.eventDeduplicationWindow,
+		"event-deduplication-window",
+		controller.DefaultEventDeduplicationWindow,
+		"Specify how long the events identical to one emitted on the same pod are dropped for; 0 emits them all")
+}
+
+// registerObservabilityFlags registers the flags of the endpoints the
+// controller serves, and of where it reports its traces and audit events to.
+func (flags *controllerFlags) registerObservabilityFlags() {
+	flag.StringVar(
+		&flags.metricsAddress,
+		"metrics-address",
+		"",
+		"Specify the address the prometheus metrics are served on; the metrics are not served when empty")
+	flag.StringVar(
+		&flags.healthAddress,
+		"health-address",
+		defaultHealthAddress,
+		"Specify the address the liveness and readiness endpoints are served on; they are not served when empty")
+	flag.StringVar(
+		&flags.debugAddress,
+		"debug-address",
+		"",
+		"Specify the address the state of the pods' attachments is served on - under "+controller.DebugPodsEndpoint+"<namespace>/<name> -, "+
+			"along with the inventory of the dynamic interfaces - under "+controller.DebugInterfacesEndpoint+"; they are not served when empty")
+	flag.StringVar(
+		&flags.adminAddress,
+		"admin-address",
+		"",
+		"Specify the address the administrative endpoints are served on - "+controller.AttachEndpoint+", attaching networks to the pods matching a label selector, "+
+			controller.DrainAttachmentsEndpoint+", removing the pods' dynamic attachments, "+
+			controller.PauseEndpoint+" / "+controller.ResumeEndpoint+", pausing and resuming the processing of the requests, and "+
+			controller.ReconcileEndpoint+"<namespace>/<name>, reconciling the networks of a pod on demand; they are not served when empty")
+	flag.StringVar(
+		&flags.otlpEndpoint,
+		"otlp-endpoint",
+		"",
+		"Specify the OTLP gRPC endpoint the traces of the dynamic attachment requests are exported to; tracing is disabled when empty")
+	flag.StringVar(
+		&flags.auditLogPath,
+		"audit-log",
+		"",
+		"Specify the path of the file the attachments added to, and removed from, the pods are audited in - as JSON lines; they are not audited when empty")
+}
+
+// registerLeaderElectionFlags registers the flags of the leader election.
+func (flags *controllerFlags) registerLeaderElectionFlags() {
+	flag.BoolVar(
+		&flags.leaderElect,
+		"leader-elect",
+		false,
+		"Specify if the replicas elect a leader, thus having a single one of them handling the pods' networks")
+	flag.StringVar(
+		&flags.leaseNamespace,
+		"leader-election-namespace",
+		defaultLeaseNamespace,
+		"Specify the namespace of the leader election lease")
+	flag.StringVar(
+		&flags.leaseName,
+		"leader-election-lease-name",
+		"",
+		"Specify the name of the leader election lease; defaults to a lease per node, since each replica handles the pods of its node")
+}
+
+// registerPodFlags registers the flags selecting the pods whose networks are
+// handled, and tuning how they are handled.
+func (flags *controllerFlags) registerPodFlags() {
+	flag.StringVar(
+		&flags.namespace,
+		"namespace",
+		v1.NamespaceAll,
+		"Specify the namespace of the pods whose networks are handled by the controller; the pods of all namespaces are handled when empty")
+	flag.StringVar(
+		&flags.podSelector,
+		"pod-selector",
+		"",
+		"Specify the label selector of the pods whose networks are handled by the controller; all pods are handled when empty")
+	flag.BoolVar(
+		&flags.reattachOnNetAttachDefUpdate,
+		"reattach-on-nad-update",
+		false,
+		"Specify if the pods are re-attached to the networks whose network-attachment-definition is updated; this is disruptive, since their interfaces are removed then added back")
+	flag.BoolVar(
+		&flags.verifyNetworkStatus,
+		"verify-network-status",
+		false,
+		"Specify if the pods' network-status is read back after each added interface, being written again when a concurrent update dropped the interface")
+	flag.BoolVar(
+		&flags.dryRun,
+		"dry-run",
+		false,
+		"Specify if the controller only reports the interfaces it would add and remove, without changing the pods' networks")
+	flag.BoolVar(
+		&flags.podFinalizer,
+		"pod-finalizer",
+		false,
+		"Specify if a finalizer holds the deletion of the pods with dynamic attachments until the attachments are removed; beware the pods' deletion is blocked while the controller is down")
+	flag.BoolVar(
+		&flags.interfaceStates,
+		"interface-states",
+		false,
+		"Specify if the state of the interfaces being added and removed - Attaching, Attached, Detaching, or Failed - is recorded in the pods' interface-states annotation")
+	flag.BoolVar(
+		&flags.networksReadyCondition,
+		"networks-ready-condition",
+		false,
+		"Specify if the pods' DynamicNetworksReady condition reflects whether the last batch of their requests fully applied their networks annotation")
+	flag.StringVar(
+		&flags.annotationPrefix,
+		"annotation-prefix",
+		annotations.DefaultBookkeepingPrefix,
+		"Specify the prefix - a DNS subdomain - of the pod annotations the controller records its own bookkeeping in")
+	flag.BoolVar(
+		&flags.restrictCrossNamespaceRefs,
+		"restrict-cross-namespace-references",
+		false,
+		"Specify if the pods can only attach to the network-attachment-definitions of other namespaces when labeled with "+
+			controller.AllowCrossNamespaceReferencesLabel+"=true")
+	flag.BoolVar(
+		&flags.podNetworkAttachments,
+		"pod-network-attachments",
+		false,
+		"Specify if the pods' networks are reconciled with the PodNetworkAttachment named after them - if any -, whose CRD must be installed")
+	flag.StringVar(
+		&flags.networksUpdateWebhook,
+		"networks-update-webhook",
+		"",
+		"Specify the URL of the webhook vetting each networks update before it is acted upon; the updates are not vetted when empty")
+	flag.StringVar(
+		&flags.cniArgsPodLabels,
+		"cni-args-pod-labels",
+		"",
+		"Specify the comma-separated keys of the pod labels forwarded to the delegates in their CNI_ARGS")
+	flag.StringVar(
+		&flags.cniArgsPodAnnotations,
+		"cni-args-pod-annotations",
+		"",
+		"Specify the comma-separated keys of the pod annotations forwarded to the delegates in their CNI_ARGS")
+}
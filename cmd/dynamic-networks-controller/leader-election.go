@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/klog/v2"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/metrics"
+)
+
+const (
+	leaseDuration = 15 * time.Second
+	renewDeadline = 10 * time.Second
+	retryPeriod   = 2 * time.Second
+)
+
+// runAsLeader runs the controller once this replica acquires the lease; the
+// controller stops when the lease is lost, or when the stop channel fires.
+// The controller failing to run is returned, having released the lease.
+func runAsLeader(stopChannel chan struct{}, leaseNamespace string, leaseName string, run func(stopChan <-chan struct{}) error) error {
+	cfg, err := rest.InClusterConfig()
+	if err != nil {
+		return fmt.Errorf("failed to implicitly generate the kubeconfig: %w", err)
+	}
+	k8sClient, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create the K8S client: %v", err)
+	}
+	identity, err := os.Hostname()
+	if err != nil {
+		return fmt.Errorf("failed to compute the leader election identity: %v", err)
+	}
+
+	var runErr error
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stopChannel
+		cancel()
+	}()
+
+	leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+		Lock: &resourcelock.LeaseLock{
+			LeaseMeta: metav1.ObjectMeta{
+				Name:      leaseName,
+				Namespace: leaseNamespace,
+			},
+			Client:     k8sClient.CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
+		},
+		LeaseDuration:   leaseDuration,
+		RenewDeadline:   renewDeadline,
+		RetryPeriod:     retryPeriod,
+		ReleaseOnCancel: true,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				klog.Infof("%s acquired the %s/%s lease", identity, leaseNamespace, leaseName)
+				metrics.IsLeader.Set(1)
+				if err := run(ctx.Done()); err != nil {
+					runErr = err
+					cancel()
+				}
+			},
+			OnStoppedLeading: func() {
+				klog.Infof("%s released the %s/%s lease", identity, leaseNamespace, leaseName)
+				metrics.IsLeader.Set(0)
+			},
+			OnNewLeader: func(leaderIdentity string) {
+				klog.Infof("the %s/%s lease is held by %s", leaseNamespace, leaseName, leaderIdentity)
+			},
+		},
+	})
+	return runErr
+}
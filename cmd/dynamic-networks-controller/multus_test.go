@@ -0,0 +1,18 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	fakemultusclient "github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni/fake"
+)
+
+func TestMultusServerCheck(t *testing.T) {
+	const timeout = time.Second
+	if err := checkMultusServer(fakemultusclient.NewFakeClient(), timeout); err != nil {
+		t.Fatalf("failed to reach a reachable multus server: %v", err)
+	}
+	if err := checkMultusServer(fakemultusclient.NewUnreachableFakeClient(), timeout); err == nil {
+		t.Fatal("reached an unreachable multus server")
+	}
+}
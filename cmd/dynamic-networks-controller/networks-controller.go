@@ -1,14 +1,23 @@
 package main
 
 import (
-	"flag"
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
 	v1coreinformerfactory "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -19,50 +28,209 @@ import (
 
 	nadclient "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/client/clientset/versioned"
 	nadinformers "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/client/informers/externalversions"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/trace"
 
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/audit"
 	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/config"
 	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/controller"
 	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/cri"
-	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/cri/containerd"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/health"
 	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/logging"
 	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/webhook"
 )
 
 const (
 	ErrorLoadingConfig int = iota
 	ErrorBuildingController
+	ErrorParsingPodSelector
+	ErrorElectingLeader
+	ErrorSettingUpTracing
+	ErrorOpeningAuditLog
+	ErrorReachingMultusServer
+	ErrorSyncingCaches
 )
 
-func main() {
-	klog.InitFlags(nil)
-	configFilePath := flag.String(
-		"config",
-		config.DefaultDynamicNetworksControllerConfigFile,
-		"Specify the path to the multus-daemon configuration")
+const (
+	defaultResyncPeriod  = 5 * time.Minute
+	defaultHealthAddress = ":8081"
+	multusPingTimeout    = 5 * time.Second
 
-	flag.Parse()
+	defaultLeaseNamespace = "kube-system"
+	leaseNamePrefix       = "dynamic-networks-controller-"
+	nodeNameEnvVariable   = "NODE_NAME"
+)
+
+func main() {
+	flags := parseFlags()
 
-	controllerConfig, err := config.LoadConfig(*configFilePath)
+	controllerConfig, err := loadConfig(flags)
 	if err != nil {
 		klog.Errorf("failed to load the multus-daemon configuration: %v", err)
 		os.Exit(ErrorLoadingConfig)
 	}
 
+	multusClient := multuscni.NewClient(controllerConfig.MultusSocketPath)
+	if err := checkMultusServer(multusClient, multusPingTimeout); err != nil {
+		klog.Errorf("failed to reach the multus-daemon on %s: %v", controllerConfig.MultusSocketPath, err)
+		os.Exit(ErrorReachingMultusServer)
+	}
+
+	selector, err := labels.Parse(flags.podSelector)
+	if err != nil {
+		klog.Errorf("failed to parse the pod selector: %v", err)
+		os.Exit(ErrorParsingPodSelector)
+	}
+
+	tracerProvider, shutdownTracing, err := newTracerProvider(flags.otlpEndpoint)
+	if err != nil {
+		klog.Errorf("failed to set up tracing: %v", err)
+		os.Exit(ErrorSettingUpTracing)
+	}
+	defer shutdownTracing()
+
+	auditSink, closeAuditLog, err := newAuditSink(flags.auditLogPath)
+	if err != nil {
+		klog.Errorf("failed to set up the audit log: %v", err)
+		os.Exit(ErrorOpeningAuditLog)
+	}
+	defer closeAuditLog()
+
 	stopChannel := make(chan struct{})
 
-	podNetworksController, err := newController(stopChannel, controllerConfig)
+	podNetworksController, err := newController(
+		stopChannel,
+		controllerConfig,
+		multusClient,
+		flags.namespace,
+		flags.podNetworkAttachments,
+		controllerOptions(flags, selector, tracerProvider, auditSink)...)
 	if err != nil {
 		klog.Errorf("failed to instantiate the %s controller: %v", controller.AdvertisedName, err)
 		close(stopChannel) // deferred calls will not be called after os.Exit is called
 		os.Exit(ErrorBuildingController)
 	}
 
-	defer close(stopChannel)
-	handleSignals(stopChannel, os.Interrupt)
-	podNetworksController.Start(stopChannel)
+	serveEndpoints(flags, podNetworksController)
+
+	stop := handleSignals(stopChannel, shutdownSignals...)
+	handleDrainSignals(podNetworksController.DrainAttachments, drainAttachmentsSignals...)
+	defer stop()
+	if !flags.leaderElect {
+		if err := podNetworksController.Start(stopChannel); err != nil {
+			klog.Errorf("failed to start the %s controller: %v", controller.AdvertisedName, err)
+			stop() // deferred calls will not be called after os.Exit is called
+			os.Exit(ErrorSyncingCaches)
+		}
+		return
+	}
+
+	if flags.leaseName == "" {
+		flags.leaseName = leaseNamePrefix + os.Getenv(nodeNameEnvVariable)
+	}
+	if err := runAsLeader(stopChannel, flags.leaseNamespace, flags.leaseName, podNetworksController.Start); err != nil {
+		stop() // deferred calls will not be called after os.Exit is called
+		if errors.Is(err, controller.ErrCachesNotSynced) {
+			klog.Errorf("failed to start the %s controller: %v", controller.AdvertisedName, err)
+			os.Exit(ErrorSyncingCaches)
+		}
+		klog.Errorf("failed to run the leader election: %v", err)
+		os.Exit(ErrorElectingLeader)
+	}
 }
 
-func newController(stopChannel chan struct{}, configuration *config.Multus) (*controller.PodNetworksController, error) {
+// loadConfig returns the multus-daemon configuration, overridden by the
+// sockets specified on the command line.
+func loadConfig(flags *controllerFlags) (*config.Multus, error) {
+	controllerConfig, err := config.LoadConfig(flags.configFilePath)
+	if err != nil {
+		return nil, err
+	}
+	if flags.criSocketPath != "" {
+		controllerConfig.CriSocketPath = flags.criSocketPath
+		controllerConfig.CriSocketPaths = nil
+		if strings.Contains(flags.criSocketPath, ",") {
+			controllerConfig.CriSocketPath = ""
+			controllerConfig.CriSocketPaths = strings.Split(flags.criSocketPath, ",")
+		}
+		// the runtime listening on the socket is detected, rather than
+		// assumed from the multus-daemon configuration's socket
+		controllerConfig.CriType = ""
+	}
+	if flags.multusSocketPath != "" {
+		controllerConfig.MultusSocketPath = flags.multusSocketPath
+	}
+	return controllerConfig, nil
+}
+
+// controllerOptions returns the options of the controller, as specified on the
+// command line.
+func controllerOptions(
+	flags *controllerFlags,
+	selector labels.Selector,
+	tracerProvider trace.TracerProvider,
+	auditSink audit.Sink,
+) []controller.Option {
+	return []controller.Option{
+		controller.WithWorkers(flags.workerCount),
+		controller.WithDelegateTimeout(flags.delegateTimeout),
+		controller.WithMaxConcurrentDelegates(flags.maxConcurrentDelegates),
+		controller.WithMaxAttachmentsPerPod(flags.maxAttachmentsPerPod),
+		controller.WithDrainTimeout(flags.drainTimeout),
+		controller.WithShutdownTimeout(flags.shutdownTimeout),
+		controller.WithCacheSyncTimeout(flags.cacheSyncTimeout),
+		controller.WithResyncPeriod(flags.resyncPeriod),
+		controller.WithPodSelector(selector),
+		// the replicas not leading would otherwise queue stale requests
+		controller.WithStandby(flags.leaderElect),
+		controller.WithMaxRetries(flags.maxRetries),
+		controller.WithRetryBackoff(flags.retryBaseDelay, flags.retryMaxDelay),
+		controller.WithEventDeduplicationWindow(flags.eventDeduplicationWindow),
+		controller.WithReattachOnNetAttachDefUpdate(flags.reattachOnNetAttachDefUpdate),
+		controller.WithNetworkStatusVerification(flags.verifyNetworkStatus),
+		controller.WithDryRun(flags.dryRun),
+		controller.WithPodFinalizer(flags.podFinalizer),
+		controller.WithInterfaceStates(flags.interfaceStates),
+		controller.WithNetworksReadyCondition(flags.networksReadyCondition),
+		controller.WithBookkeepingAnnotationPrefix(flags.annotationPrefix),
+		controller.WithNetNSAnnotation(flags.netnsAnnotation),
+		controller.WithPodLabelsAsCNIArgs(metadataKeys(flags.cniArgsPodLabels)),
+		controller.WithPodAnnotationsAsCNIArgs(metadataKeys(flags.cniArgsPodAnnotations)),
+		controller.WithTracerProvider(tracerProvider),
+		controller.WithCrossNamespaceReferencesRestricted(flags.restrictCrossNamespaceRefs),
+		controller.WithNetworksUpdateValidator(newNetworksUpdateValidator(flags.networksUpdateWebhook)),
+		controller.WithAuditSink(auditSink),
+	}
+}
+
+// serveEndpoints serves the metrics, health, debug, and administrative
+// endpoints on the addresses specified on the command line - if any.
+func serveEndpoints(flags *controllerFlags, podNetworksController *controller.PodNetworksController) {
+	if flags.metricsAddress != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		serve(flags.metricsAddress, mux)
+	}
+	if flags.healthAddress != "" {
+		serve(flags.healthAddress, health.NewHandler(podNetworksController.HasSynced))
+	}
+	if flags.debugAddress != "" {
+		serve(flags.debugAddress, podNetworksController.DebugHandler())
+	}
+	if flags.adminAddress != "" {
+		serve(flags.adminAddress, podNetworksController.AdminHandler())
+	}
+}
+
+func newController(
+	stopChannel chan struct{},
+	configuration *config.Multus,
+	multusClient multuscni.Client,
+	namespace string,
+	watchPodNetworkAttachments bool,
+	opts ...controller.Option,
+) (*controller.PodNetworksController, error) {
 	klog.V(logging.Debug).Infof("creating pod update controller ...")
 	cfg, err := rest.InClusterConfig()
 	if err != nil {
@@ -80,11 +248,23 @@ func newController(stopChannel chan struct{}, configuration *config.Multus) (*co
 	}
 
 	const noResyncPeriod = 0
-	podInformerFactory := v1coreinformerfactory.NewSharedInformerFactoryWithOptions(
-		k8sClient, noResyncPeriod, listenOnCoLocatedNode())
+	podInformerFactory := controller.NewPodInformerFactory(
+		k8sClient, noResyncPeriod, namespace, listenOnCoLocatedNode())
 
 	nadInformerFactory := nadinformers.NewSharedInformerFactory(nadClientSet, noResyncPeriod)
 
+	var podNetworkAttachmentInformerFactory dynamicinformer.DynamicSharedInformerFactory
+	if watchPodNetworkAttachments {
+		dynamicClient, err := dynamic.NewForConfig(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the dynamic client: %v", err)
+		}
+		podNetworkAttachmentInformerFactory = dynamicinformer.NewFilteredDynamicSharedInformerFactory(
+			dynamicClient, noResyncPeriod, namespace, nil)
+		opts = append(opts, controller.WithPodNetworkAttachmentInformer(
+			podNetworkAttachmentInformerFactory.ForResource(controller.PodNetworkAttachmentsResource).Informer()))
+	}
+
 	eventBroadcaster := newEventBroadcaster(k8sClient)
 
 	containerRuntime, err := newContainerRuntime(configuration)
@@ -100,7 +280,8 @@ func newController(stopChannel chan struct{}, configuration *config.Multus) (*co
 		k8sClient,
 		nadClientSet,
 		containerRuntime,
-		multuscni.NewClient(configuration.MultusSocketPath))
+		multusClient,
+		opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create the pod networks controller: %v", err)
 	}
@@ -108,18 +289,67 @@ func newController(stopChannel chan struct{}, configuration *config.Multus) (*co
 	klog.V(logging.Debug).Infof("starting informer factories ...")
 	podInformerFactory.Start(stopChannel)
 	nadInformerFactory.Start(stopChannel)
+	if podNetworkAttachmentInformerFactory != nil {
+		podNetworkAttachmentInformerFactory.Start(stopChannel)
+	}
 
 	klog.V(logging.Debug).Infof("finished creating the pod networks controller")
 	return podNetworksController, nil
 }
 
+// newNetworksUpdateValidator returns the validator posting the networks
+// updates to the webhook at `url`; nil - thus not vetting them - when empty.
+func newNetworksUpdateValidator(url string) webhook.Validator {
+	if url == "" {
+		return nil
+	}
+	return webhook.NewHTTPValidator(url, webhook.DefaultTimeout)
+}
+
+// checkMultusServer fails unless the multus server replies within the
+// timeout; thus a misconfigured socket is reported on startup, rather than on
+// each delegate invocation.
+func checkMultusServer(pinger multuscni.Pinger, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return pinger.Ping(ctx)
+}
+
+// metadataKeys returns the keys of the comma-separated list, which may be
+// empty.
+func metadataKeys(list string) []string {
+	var keys []string
+	for _, key := range strings.Split(list, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// newAuditSink returns the sink appending the audit events to the file at
+// `path`, along with the function closing it; the events are discarded when
+// the path is empty.
+func newAuditSink(path string) (audit.Sink, func(), error) {
+	if path == "" {
+		return audit.NewNoopSink(), func() {}, nil
+	}
+	sink, err := audit.NewFileSink(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	closeSink := func() {
+		if err := sink.Close(); err != nil {
+			klog.Errorf("failed to close the audit log: %v", err)
+		}
+	}
+	return sink, closeSink, nil
+}
+
 func listenOnCoLocatedNode() v1coreinformerfactory.SharedInformerOption {
 	return v1coreinformerfactory.WithTweakListOptions(
 		func(options *v1.ListOptions) {
-			const (
-				filterKey           = "spec.nodeName"
-				nodeNameEnvVariable = "NODE_NAME"
-			)
+			const filterKey = "spec.nodeName"
 			options.FieldSelector = fields.OneTermEqualSelector(filterKey, os.Getenv(nodeNameEnvVariable)).String()
 		})
 }
@@ -135,16 +365,60 @@ func newEventRecorder(broadcaster record.EventBroadcaster) record.EventRecorder
 	return broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: controller.AdvertisedName})
 }
 
-func handleSignals(stopChannel chan struct{}, signals ...os.Signal) {
+// shutdownSignals are the signals stopping the controller; the kubelet sends
+// SIGTERM when the pod is deleted.
+var shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// handleSignals closes the stop channel - thus stopping all of its receivers -
+// once any of the signals is received. The returned function closes it on
+// demand, whether or not a signal was received.
+func handleSignals(stopChannel chan struct{}, signals ...os.Signal) func() {
+	var once sync.Once
+	stop := func() {
+		once.Do(func() { close(stopChannel) })
+	}
 	signalChannel := make(chan os.Signal, 1)
 	signal.Notify(signalChannel, signals...)
 	go func() {
 		<-signalChannel
-		stopChannel <- struct{}{}
+		stop()
+	}()
+	return stop
+}
+
+// drainAttachmentsSignals have the controller remove the pods' dynamic
+// attachments - e.g. sent by a preStop hook when the node is drained.
+var drainAttachmentsSignals = []os.Signal{syscall.SIGUSR1}
+
+// handleDrainSignals drains the pods' dynamic attachments whenever any of the
+// signals is received.
+func handleDrainSignals(drain func() []string, signals ...os.Signal) {
+	signalChannel := make(chan os.Signal, 1)
+	signal.Notify(signalChannel, signals...)
+	go func() {
+		for range signalChannel {
+			klog.Infof("draining the dynamic attachments of pods %v", drain())
+		}
+	}()
+}
+
+func serve(address string, handler http.Handler) {
+	go func() {
+		if err := http.ListenAndServe(address, handler); err != nil {
+			klog.Errorf("failed to serve on %s: %v", address, err)
+		}
 	}()
 }
 
 func newContainerRuntime(configuration *config.Multus) (cri.ContainerRuntime, error) {
 	const withoutTimeout = 0
-	return containerd.NewContainerdRuntime(configuration.CriSocketPath, withoutTimeout)
+	if len(configuration.CriSocketPaths) > 0 {
+		containerRuntime, socketPath, err := cri.NewRuntimeFromSockets(configuration.CriSocketPaths, withoutTimeout)
+		if err != nil {
+			return nil, err
+		}
+		klog.Infof("querying the container runtime listening on %s", socketPath)
+		return containerRuntime, nil
+	}
+	return cri.NewRuntime(configuration.CriSocketPath, configuration.CriType, withoutTimeout)
 }
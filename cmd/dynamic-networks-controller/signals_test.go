@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// The signals handler is tested without ginkgo, whose interrupt handler would
+// abort the suite when the test process is sent SIGTERM.
+
+func TestStopOnSIGTERM(t *testing.T) {
+	stopChannel := make(chan struct{})
+	stop := handleSignals(stopChannel, shutdownSignals...)
+	defer stop()
+
+	const receivers = 2
+	stoppedReceivers := make(chan struct{}, receivers)
+	for i := 0; i < receivers; i++ {
+		go func() {
+			<-stopChannel
+			stoppedReceivers <- struct{}{}
+		}()
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatalf("failed to send SIGTERM: %v", err)
+	}
+
+	for i := 0; i < receivers; i++ {
+		select {
+		case <-stoppedReceivers:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only %d out of %d receivers were stopped by SIGTERM", i, receivers)
+		}
+	}
+}
+
+func TestStopOnDemand(t *testing.T) {
+	stopChannel := make(chan struct{})
+	stop := handleSignals(stopChannel, shutdownSignals...)
+
+	stop()
+	stop()
+	select {
+	case <-stopChannel:
+	default:
+		t.Fatal("the stop channel was not closed")
+	}
+}
+
+func TestDrainAttachmentsOnSIGUSR1(t *testing.T) {
+	drained := make(chan struct{}, 1)
+	handleDrainSignals(func() []string {
+		drained <- struct{}{}
+		return nil
+	}, drainAttachmentsSignals...)
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatalf("failed to send SIGUSR1: %v", err)
+	}
+
+	select {
+	case <-drained:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the attachments were not drained on SIGUSR1")
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/klog/v2"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/controller"
+)
+
+const tracingShutdownTimeout = 5 * time.Second
+
+// newTracerProvider returns a provider exporting the traces to the OTLP
+// endpoint, along with the function flushing them on shutdown. When no
+// endpoint is specified, a no-op provider is returned.
+func newTracerProvider(otlpEndpoint string) (trace.TracerProvider, func(), error) {
+	if otlpEndpoint == "" {
+		return trace.NewNoopTracerProvider(), func() {}, nil
+	}
+
+	exporter, err := otlptracegrpc.New(
+		context.Background(),
+		otlptracegrpc.WithEndpoint(otlpEndpoint),
+		otlptracegrpc.WithInsecure())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create the OTLP trace exporter: %w", err)
+	}
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String(controller.AdvertisedName))))
+	shutdown := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if err := tracerProvider.Shutdown(ctx); err != nil {
+			klog.Errorf("failed to flush the traces: %v", err)
+		}
+	}
+	return tracerProvider, shutdown, nil
+}
@@ -0,0 +1,56 @@
+package annotations
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// DefaultBookkeepingPrefix prefixes the keys of the annotations the controller
+// records its own bookkeeping in, unless overridden.
+const DefaultBookkeepingPrefix = "dynamic-networks-controller.k8s.cni.cncf.io"
+
+// BookkeepingKeys are the keys of the annotations the controller records its
+// own bookkeeping in - as opposed to the multus annotations it reads and
+// writes -, all of them sharing a prefix.
+type BookkeepingKeys struct {
+	// InterfaceNames records - indexed by network - the interface names picked
+	// for the attachments not requesting one. Re-adding an attachment - e.g.
+	// once its network-attachment-definition is updated - reuses its previous
+	// interface name, thus keeping the pod's interface names stable.
+	InterfaceNames string
+	// Sandbox records the network namespace of the pod's sandbox the dynamic
+	// interfaces were added to. When the sandbox is re-created - e.g. once the
+	// node reboots - its network namespace changes, and the dynamic interfaces
+	// are gone, while the network-status still lists them.
+	Sandbox string
+	// InterfaceStates records - indexed by interface name - the state of the
+	// interfaces the controller is adding to, or removing from, the pod; thus
+	// letting the pod's consumers wait for an interface to be attached.
+	InterfaceStates string
+	// DynamicInterfaces records the names of the interfaces the controller
+	// added to the pod, in the order they were added.
+	DynamicInterfaces string
+}
+
+// NewBookkeepingKeys returns the bookkeeping annotation keys under the prefix,
+// which must be a DNS subdomain - e.g. `example.com`.
+func NewBookkeepingKeys(prefix string) (BookkeepingKeys, error) {
+	if errs := validation.IsDNS1123Subdomain(prefix); len(errs) > 0 {
+		return BookkeepingKeys{}, fmt.Errorf("invalid annotation prefix %q: %s", prefix, strings.Join(errs, "; "))
+	}
+	return BookkeepingKeys{
+		InterfaceNames:    prefix + "/interface-names",
+		Sandbox:           prefix + "/sandbox",
+		InterfaceStates:   prefix + "/interface-states",
+		DynamicInterfaces: prefix + "/dynamic-interfaces",
+	}, nil
+}
+
+// DefaultBookkeepingKeys returns the bookkeeping annotation keys under the
+// default prefix.
+func DefaultBookkeepingKeys() BookkeepingKeys {
+	keys, _ := NewBookkeepingKeys(DefaultBookkeepingPrefix)
+	return keys
+}
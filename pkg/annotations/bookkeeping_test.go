@@ -0,0 +1,35 @@
+package annotations
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("The bookkeeping annotation keys", func() {
+	It("default to the controller's prefix", func() {
+		Expect(DefaultBookkeepingKeys()).To(Equal(BookkeepingKeys{
+			InterfaceNames:    "dynamic-networks-controller.k8s.cni.cncf.io/interface-names",
+			Sandbox:           "dynamic-networks-controller.k8s.cni.cncf.io/sandbox",
+			InterfaceStates:   "dynamic-networks-controller.k8s.cni.cncf.io/interface-states",
+			DynamicInterfaces: "dynamic-networks-controller.k8s.cni.cncf.io/dynamic-interfaces",
+		}))
+	})
+
+	It("respect a custom prefix", func() {
+		Expect(NewBookkeepingKeys("networks.example.com")).To(Equal(BookkeepingKeys{
+			InterfaceNames:    "networks.example.com/interface-names",
+			Sandbox:           "networks.example.com/sandbox",
+			InterfaceStates:   "networks.example.com/interface-states",
+			DynamicInterfaces: "networks.example.com/dynamic-interfaces",
+		}))
+	})
+
+	DescribeTable("reject the prefixes which are not DNS subdomains", func(prefix string) {
+		_, err := NewBookkeepingKeys(prefix)
+		Expect(err).To(MatchError(ContainSubstring("invalid annotation prefix")))
+	},
+		Entry("when empty", ""),
+		Entry("when featuring a slash", "example.com/networks"),
+		Entry("when featuring upper case letters", "Example.com"),
+	)
+})
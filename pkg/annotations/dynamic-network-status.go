@@ -3,6 +3,9 @@ package annotations
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
+
+	cni100 "github.com/containernetworking/cni/pkg/types/100"
 
 	corev1 "k8s.io/api/core/v1"
 
@@ -11,6 +14,8 @@ import (
 	multusapi "gopkg.in/k8snetworkplumbingwg/multus-cni.v3/pkg/server/api"
 )
 
+// AddDynamicIfaceToStatus returns the pod's network-status featuring the
+// interface the delegate's response describes.
 func AddDynamicIfaceToStatus(currentPod *corev1.Pod, networkSelectionElement *nettypes.NetworkSelectionElement, response *multusapi.Response) (string, error) {
 	currentIfaceStatus, err := podDynamicNetworkStatus(currentPod)
 	if err != nil {
@@ -18,8 +23,13 @@ func AddDynamicIfaceToStatus(currentPod *corev1.Pod, networkSelectionElement *ne
 	}
 
 	if response != nil && response.Result != nil {
+		// the result is shaped after the current spec version, whichever
+		// version it is labeled with - e.g. when relayed by an older multus -;
+		// converting it from the labeled version would fail
+		result := *response.Result
+		result.CNIVersion = cni100.ImplementedSpecVersion
 		newIfaceStatus, err := nadutils.CreateNetworkStatus(
-			response.Result,
+			&result,
 			NamespacedName(networkSelectionElement.Namespace, networkSelectionElement.Name),
 			false,
 			nil,
@@ -28,7 +38,7 @@ func AddDynamicIfaceToStatus(currentPod *corev1.Pod, networkSelectionElement *ne
 			return "", fmt.Errorf("failed to create NetworkStatus from the response: %v", err)
 		}
 
-		newIfaceString, err := json.Marshal(append(currentIfaceStatus, *newIfaceStatus))
+		newIfaceString, err := json.Marshal(sortedNetworkStatus(append(currentIfaceStatus, *newIfaceStatus)))
 		if err != nil {
 			return "", fmt.Errorf("failed to marshall the dynamic networks status after interface creation")
 		}
@@ -37,6 +47,9 @@ func AddDynamicIfaceToStatus(currentPod *corev1.Pod, networkSelectionElement *ne
 	return "", fmt.Errorf("got an empty response from multus: %+v", response)
 }
 
+// DeleteDynamicIfaceFromStatus returns the pod's network-status without the
+// attachment's entry; the entry is matched by network and interface name, thus
+// leaving alone the other interfaces of a network attached more than once.
 func DeleteDynamicIfaceFromStatus(currentPod *corev1.Pod, networkSelectionElement *nettypes.NetworkSelectionElement) (string, error) {
 	currentIfaceStatus, err := podDynamicNetworkStatus(currentPod)
 	if err != nil {
@@ -53,13 +66,29 @@ func DeleteDynamicIfaceFromStatus(currentPod *corev1.Pod, networkSelectionElemen
 		newIfaceStatus = append(newIfaceStatus, currentIfaceStatus[i])
 	}
 
-	newIfaceString, err := json.Marshal(newIfaceStatus)
+	newIfaceString, err := json.Marshal(sortedNetworkStatus(newIfaceStatus))
 	if err != nil {
 		return "", fmt.Errorf("failed to marshall the dynamic networks status after deleting interface")
 	}
 	return string(newIfaceString), nil
 }
 
+// sortedNetworkStatus sorts the network-status entries by interface name - the
+// default network's entry staying first - thus keeping the annotation stable
+// regardless of the order the interfaces were added and removed in.
+func sortedNetworkStatus(networkStatus []nettypes.NetworkStatus) []nettypes.NetworkStatus {
+	sort.SliceStable(networkStatus, func(i, j int) bool {
+		if networkStatus[i].Default != networkStatus[j].Default {
+			return networkStatus[i].Default
+		}
+		if networkStatus[i].Interface != networkStatus[j].Interface {
+			return networkStatus[i].Interface < networkStatus[j].Interface
+		}
+		return networkStatus[i].Name < networkStatus[j].Name
+	})
+	return networkStatus
+}
+
 func podDynamicNetworkStatus(currentPod *corev1.Pod) ([]nettypes.NetworkStatus, error) {
 	var currentIfaceStatus []nettypes.NetworkStatus
 	if currentIfaceStatusString, wasFound := currentPod.Annotations[nettypes.NetworkStatusAnnot]; wasFound {
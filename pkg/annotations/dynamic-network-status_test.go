@@ -53,7 +53,27 @@ var _ = Describe("NetworkStatusFromResponse", func() {
 				Mac:       "00:00:00:20:10:00",
 			}},
 			[]string{"10.10.10.10/24"},
-			`[{"name":"net1","interface":"iface1","mac":"00:00:00:20:10:00","dns":{}},{"name":"ns1/tenantnetwork","interface":"newiface","ips":["10.10.10.10"],"mac":"02:03:04:05:06:07","dns":{}}]`))
+			`[{"name":"net1","interface":"iface1","mac":"00:00:00:20:10:00","dns":{}},{"name":"ns1/tenantnetwork","interface":"newiface","ips":["10.10.10.10"],"mac":"02:03:04:05:06:07","dns":{}}]`),
+		Entry("result with dual stack IPs", []nadv1.NetworkStatus{},
+			[]string{"10.10.10.10/24", "10.10.20.10/24", "fd10::10/64"},
+			`[{"name":"ns1/tenantnetwork","interface":"newiface","ips":["10.10.10.10","10.10.20.10","fd10::10"],"mac":"02:03:04:05:06:07","dns":{}}]`),
+		Entry("result with IPv6 IPs only", []nadv1.NetworkStatus{},
+			[]string{"2001:db8:abcd:12:1:2:3:4/64", "fe80::aaaa:bbbb:cccc:dddd/64"},
+			`[{"name":"ns1/tenantnetwork","interface":"newiface","ips":["2001:db8:abcd:12:1:2:3:4","fe80::aaaa:bbbb:cccc:dddd"],"mac":"02:03:04:05:06:07","dns":{}}]`))
+
+	It("add the interface described by a result labeled with an older spec version", func() {
+		response := newResponse("newiface", "02:03:04:05:06:07", "10.10.10.10/24")
+		response.Result.CNIVersion = "0.3.1"
+
+		Expect(
+			AddDynamicIfaceToStatus(
+				newPod(podName, namespace),
+				newNetworkSelectionElementWithIface(networkName, ifaceName, namespace),
+				response,
+			),
+		).To(Equal(`[{"name":"ns1/tenantnetwork","interface":"newiface","ips":["10.10.10.10"],"mac":"02:03:04:05:06:07","dns":{}}]`))
+		Expect(response.Result.CNIVersion).To(Equal("0.3.1"))
+	})
 
 	DescribeTable("remove an interface to the current network status", func(initialNetStatus []nadv1.NetworkStatus, networkName, ifaceToRemove, expectedNetworkStatus string) {
 		Expect(
@@ -87,7 +107,59 @@ var _ = Describe("NetworkStatusFromResponse", func() {
 				Interface: "iface2",
 				Mac:       "aa:bb:cc:20:10:00",
 			},
-		}, "net2", "iface2", `[{"name":"ns1/tenantnetwork","interface":"iface1","mac":"00:00:00:20:10:00","dns":{}}]`))
+		}, "net2", "iface2", `[{"name":"ns1/tenantnetwork","interface":"iface1","mac":"00:00:00:20:10:00","dns":{}}]`),
+		Entry("when we remove one of the interfaces of a network attached more than once", []nadv1.NetworkStatus{
+			{
+				Name:      NamespacedName(namespace, networkName),
+				Interface: "iface1",
+				Mac:       "00:00:00:20:10:00",
+			},
+			{
+				Name:      NamespacedName(namespace, networkName),
+				Interface: "iface2",
+				Mac:       "aa:bb:cc:20:10:00",
+			},
+		}, networkName, "iface1", `[{"name":"ns1/tenantnetwork","interface":"iface2","mac":"aa:bb:cc:20:10:00","dns":{}}]`))
+
+	It("keeps the network status sorted by interface name across additions and removals", func() {
+		const macAddr = "02:03:04:05:06:07"
+		pod := newPod(podName, namespace, nadv1.NetworkStatus{Name: "default/cluster-net", Interface: "eth0", Default: true})
+		addIface := func(ifaceName string) {
+			status, err := AddDynamicIfaceToStatus(
+				pod,
+				newNetworkSelectionElementWithIface(networkName, ifaceName, namespace),
+				newResponse(ifaceName, macAddr))
+			Expect(err).NotTo(HaveOccurred())
+			pod.Annotations[nadv1.NetworkStatusAnnot] = status
+		}
+		removeIface := func(ifaceName string) {
+			status, err := DeleteDynamicIfaceFromStatus(pod, newNetworkSelectionElementWithIface(networkName, ifaceName, namespace))
+			Expect(err).NotTo(HaveOccurred())
+			pod.Annotations[nadv1.NetworkStatusAnnot] = status
+		}
+		ifaceNames := func() []string {
+			var status []nadv1.NetworkStatus
+			Expect(json.Unmarshal([]byte(pod.Annotations[nadv1.NetworkStatusAnnot]), &status)).To(Succeed())
+			var names []string
+			for i := range status {
+				names = append(names, status[i].Interface)
+			}
+			return names
+		}
+
+		addIface("net3")
+		addIface("net1")
+		addIface("a-iface")
+		removeIface("net1")
+		addIface("net2")
+		addIface("net1")
+		removeIface("a-iface")
+		Expect(ifaceNames()).To(Equal([]string{"eth0", "net1", "net2", "net3"}))
+
+		removeIface("net2")
+		addIface("net2")
+		Expect(ifaceNames()).To(Equal([]string{"eth0", "net1", "net2", "net3"}))
+	})
 })
 
 func newPod(podName string, namespace string, netStatus ...nadv1.NetworkStatus) *corev1.Pod {
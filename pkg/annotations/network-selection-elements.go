@@ -22,6 +22,7 @@ import (
 	"net"
 	"regexp"
 	"strings"
+	"unicode"
 
 	"k8s.io/klog/v2"
 
@@ -61,36 +62,83 @@ func ParsePodNetworkAnnotations(podNetworks, defaultNamespace string) ([]*nadv1.
 	}
 
 	for _, n := range networks {
-		if n.Namespace == "" {
-			n.Namespace = defaultNamespace
+		canonicalizeNetworkSelectionElement(n, defaultNamespace)
+	}
+
+	return networks, nil
+}
+
+// canonicalizeNetworkSelectionElement normalizes the network selection element
+// parsed from either annotation syntax, thus making the elements describing
+// the same attachment - e.g. `ns/net@eth1` and its JSON counterpart - equal.
+func canonicalizeNetworkSelectionElement(networkSelectionElement *nadv1.NetworkSelectionElement, defaultNamespace string) {
+	networkSelectionElement.Name = strings.TrimSpace(networkSelectionElement.Name)
+	networkSelectionElement.Namespace = strings.TrimSpace(networkSelectionElement.Namespace)
+	networkSelectionElement.InterfaceRequest = strings.TrimSpace(networkSelectionElement.InterfaceRequest)
+	networkSelectionElement.MacRequest = strings.ToLower(strings.TrimSpace(networkSelectionElement.MacRequest))
+	if networkSelectionElement.Namespace == "" {
+		networkSelectionElement.Namespace = defaultNamespace
+	}
+	if len(networkSelectionElement.IPRequest) == 0 {
+		networkSelectionElement.IPRequest = nil
+	}
+	if len(networkSelectionElement.GatewayRequest) == 0 {
+		networkSelectionElement.GatewayRequest = nil
+	}
+}
+
+// maxInterfaceNameLength is the longest interface name Linux accepts, i.e.
+// IFNAMSIZ without the terminating NUL.
+const maxInterfaceNameLength = 15
+
+// ValidateInterfaceName checks Linux accepts the interface name: it holds 1 to
+// 15 characters, none of them `/`, `:`, or whitespace, and is neither `.` nor
+// `..`.
+func ValidateInterfaceName(ifaceName string) error {
+	if ifaceName == "" {
+		return fmt.Errorf("the interface name is empty")
+	}
+	if len(ifaceName) > maxInterfaceNameLength {
+		return fmt.Errorf("interface name %q is longer than %d characters", ifaceName, maxInterfaceNameLength)
+	}
+	if ifaceName == "." || ifaceName == ".." {
+		return fmt.Errorf("interface name %q is reserved", ifaceName)
+	}
+	if strings.ContainsAny(ifaceName, "/:") || strings.IndexFunc(ifaceName, unicode.IsSpace) >= 0 {
+		return fmt.Errorf("interface name %q features invalid characters: '/', ':', or whitespace", ifaceName)
+	}
+	return nil
+}
+
+// ValidateNetworkSelectionElement checks the interface name, MAC address,
+// infiniband GUID, and IPs requested by the network selection element are well
+// formed; the IPs can be bare IP addresses, or in CIDR notation.
+func ValidateNetworkSelectionElement(networkSelectionElement *nadv1.NetworkSelectionElement) error {
+	if networkSelectionElement.InterfaceRequest != "" {
+		if err := ValidateInterfaceName(networkSelectionElement.InterfaceRequest); err != nil {
+			return err
 		}
-		if n.MacRequest != "" {
-			// validate MAC address
-			if _, err := net.ParseMAC(n.MacRequest); err != nil {
-				return nil, fmt.Errorf("parsePodNetworkAnnotation: failed to mac: %v", err)
-			}
+	}
+	if networkSelectionElement.MacRequest != "" {
+		if _, err := net.ParseMAC(networkSelectionElement.MacRequest); err != nil {
+			return fmt.Errorf("failed to validate MAC address %q: %v", networkSelectionElement.MacRequest, err)
 		}
-		if n.InfinibandGUIDRequest != "" {
-			// validate GUID address
-			if _, err := net.ParseMAC(n.InfinibandGUIDRequest); err != nil {
-				return nil, fmt.Errorf("parsePodNetworkAnnotation: failed to validate infiniband GUID: %v", err)
-			}
+	}
+	if networkSelectionElement.InfinibandGUIDRequest != "" {
+		if _, err := net.ParseMAC(networkSelectionElement.InfinibandGUIDRequest); err != nil {
+			return fmt.Errorf("failed to validate infiniband GUID %q: %v", networkSelectionElement.InfinibandGUIDRequest, err)
 		}
-		if n.IPRequest != nil {
-			for _, ip := range n.IPRequest {
-				// validate IP address
-				if strings.Contains(ip, "/") {
-					if _, _, err := net.ParseCIDR(ip); err != nil {
-						return nil, fmt.Errorf("failed to parse CIDR %q: %v", ip, err)
-					}
-				} else if net.ParseIP(ip) == nil {
-					return nil, fmt.Errorf("failed to parse IP address %q", ip)
-				}
+	}
+	for _, ip := range networkSelectionElement.IPRequest {
+		if strings.Contains(ip, "/") {
+			if _, _, err := net.ParseCIDR(ip); err != nil {
+				return fmt.Errorf("failed to parse CIDR %q: %v", ip, err)
 			}
+		} else if net.ParseIP(ip) == nil {
+			return fmt.Errorf("failed to parse IP address %q", ip)
 		}
 	}
-
-	return networks, nil
+	return nil
 }
 
 func parsePodNetworkObjectName(podnetwork string) (string, string, string, error) {
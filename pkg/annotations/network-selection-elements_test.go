@@ -71,6 +71,78 @@ var _ = Describe("Parsing annotations", func() {
 				newNetworkSelectionElement("macvlan-conf-1", namespace),
 				newNetworkSelectionElementWithIface("macvlan-conf-2", "ens4", namespace)))
 	})
+
+	It("the same networks in either syntax", func() {
+		const (
+			shortSyntax = "net1@eth1, ns2/net2"
+			jsonSyntax  = `[{"name": " net1 ", "interface": "eth1", "ips": []}, {"name": "net2", "namespace": "ns2"}]`
+		)
+		shortSyntaxNetworks, err := ParsePodNetworkAnnotations(shortSyntax, namespace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ParsePodNetworkAnnotations(jsonSyntax, namespace)).To(Equal(shortSyntaxNetworks))
+	})
+
+	It("a MAC address request in lower case", func() {
+		const networkSelectionElementsString = `[{"name": "net1", "mac": "02:03:04:0A:0B:0C"}]`
+		networks, err := ParsePodNetworkAnnotations(networkSelectionElementsString, namespace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(networks).To(HaveLen(1))
+		Expect(networks[0].MacRequest).To(Equal("02:03:04:0a:0b:0c"))
+	})
+})
+
+var _ = Describe("Validating network selection elements", func() {
+	const (
+		namespace   = "ns1"
+		networkName = "net1"
+	)
+
+	DescribeTable("accepts well formed requests", func(ips []string, mac string) {
+		networkSelectionElement := newNetworkSelectionElement(networkName, namespace)
+		networkSelectionElement.IPRequest = ips
+		networkSelectionElement.MacRequest = mac
+		Expect(ValidateNetworkSelectionElement(networkSelectionElement)).To(Succeed())
+	},
+		Entry("without any request", nil, ""),
+		Entry("with a bare IPv4 address", []string{"10.10.10.10"}, ""),
+		Entry("with an IPv4 CIDR", []string{"10.10.10.10/24"}, ""),
+		Entry("with a bare IPv6 address", []string{"fd10::10"}, ""),
+		Entry("with an IPv6 CIDR", []string{"fd10::10/64"}, ""),
+		Entry("with dual stack CIDRs", []string{"10.10.10.10/24", "fd10::10/64"}, ""),
+		Entry("with a MAC address", nil, "02:03:04:05:06:07"),
+	)
+
+	DescribeTable("rejects malformed requests", func(ips []string, mac string, expectedError string) {
+		networkSelectionElement := newNetworkSelectionElement(networkName, namespace)
+		networkSelectionElement.IPRequest = ips
+		networkSelectionElement.MacRequest = mac
+		Expect(ValidateNetworkSelectionElement(networkSelectionElement)).To(MatchError(ContainSubstring(expectedError)))
+	},
+		Entry("with a malformed IPv4 address", []string{"10.10.10.300"}, "", `failed to parse IP address "10.10.10.300"`),
+		Entry("with a malformed IPv4 CIDR", []string{"10.10.10.10/33"}, "", `failed to parse CIDR "10.10.10.10/33"`),
+		Entry("with a malformed IPv6 address", []string{"fd10::10::10"}, "", `failed to parse IP address "fd10::10::10"`),
+		Entry("with a malformed IPv6 CIDR", []string{"fd10::10/129"}, "", `failed to parse CIDR "fd10::10/129"`),
+		Entry("with a single malformed IP", []string{"10.10.10.10/24", "fd10::zz/64"}, "", `failed to parse CIDR "fd10::zz/64"`),
+		Entry("with a too short MAC address", nil, "02:03:04:05:06", `failed to validate MAC address "02:03:04:05:06"`),
+		Entry("with a non hexadecimal MAC address", nil, "02:03:04:05:06:zz", `failed to validate MAC address "02:03:04:05:06:zz"`),
+	)
+
+	DescribeTable("rejects the interface names Linux would not accept", func(ifaceName string, expectedError string) {
+		networkSelectionElement := newNetworkSelectionElementWithIface(networkName, ifaceName, namespace)
+		Expect(ValidateNetworkSelectionElement(networkSelectionElement)).To(MatchError(expectedError))
+	},
+		Entry("with 16 characters", "abcdefghijklmnop", `interface name "abcdefghijklmnop" is longer than 15 characters`),
+		Entry("with a slash", "net/1", `interface name "net/1" features invalid characters: '/', ':', or whitespace`),
+		Entry("with a colon", "net:1", `interface name "net:1" features invalid characters: '/', ':', or whitespace`),
+		Entry("with whitespace", "net\t1", `interface name "net\t1" features invalid characters: '/', ':', or whitespace`),
+		Entry("being the current directory", ".", `interface name "." is reserved`),
+		Entry("being the parent directory", "..", `interface name ".." is reserved`),
+	)
+
+	It("accepts the interface names of 15 characters", func() {
+		networkSelectionElement := newNetworkSelectionElementWithIface(networkName, "abcdefghijklmno", namespace)
+		Expect(ValidateNetworkSelectionElement(networkSelectionElement)).To(Succeed())
+	})
 })
 
 func networkSelectionElements(networkNames ...string) string {
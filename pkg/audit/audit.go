@@ -0,0 +1,97 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// Operation is the change made to the pod's networks
+type Operation string
+
+const (
+	OperationAdd    Operation = "add"
+	OperationRemove Operation = "remove"
+)
+
+// Result is the outcome of the audited operation
+type Result string
+
+const (
+	ResultSuccess Result = "success"
+	ResultFailure Result = "failure"
+)
+
+// Event records an attachment being added to, or removed from, a pod; the
+// error explains the failed ones. The request ID correlates the event with
+// the controller's logs and traces.
+type Event struct {
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"requestID,omitempty"`
+	Operation Operation `json:"operation"`
+	Pod       string    `json:"pod"`
+	Network   string    `json:"network"`
+	Interface string    `json:"interface,omitempty"`
+	Result    Result    `json:"result"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Sink records the audit events
+type Sink interface {
+	// Record records the event; being bookkeeping, failing to record it
+	// must not fail the audited operation.
+	Record(event *Event)
+}
+
+// NoopSink discards the audit events
+type NoopSink struct{}
+
+// NewNoopSink returns a Sink discarding the events
+func NewNoopSink() *NoopSink {
+	return &NoopSink{}
+}
+
+// Record discards the event
+func (NoopSink) Record(*Event) {}
+
+// FileSink appends the audit events - JSON encoded, one per line - to a file.
+type FileSink struct {
+	lock sync.Mutex
+	file *os.File
+}
+
+// NewFileSink returns a Sink appending the events to the file at `path`,
+// which is created when missing.
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the audit log %s: %w", path, err)
+	}
+	return &FileSink{file: file}, nil
+}
+
+// Record appends the event to the file; failures are logged.
+func (s *FileSink) Record(event *Event) {
+	line, err := json.Marshal(event)
+	if err != nil {
+		klog.Errorf("failed to marshal the audit event %+v: %v", event, err)
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		klog.Errorf("failed to append the audit event %s: %v", line, err)
+	}
+}
+
+// Close closes the file
+func (s *FileSink) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.file.Close()
+}
@@ -0,0 +1,89 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestAudit(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Audit suite")
+}
+
+var _ = Describe("The audit log file", func() {
+	var path string
+
+	BeforeEach(func() {
+		path = filepath.Join(GinkgoT().TempDir(), "audit.log")
+	})
+
+	recordedEvents := func() []*Event {
+		file, err := os.Open(path)
+		Expect(err).NotTo(HaveOccurred())
+		defer file.Close()
+
+		var events []*Event
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			event := &Event{}
+			Expect(json.Unmarshal(scanner.Bytes(), event)).To(Succeed())
+			events = append(events, event)
+		}
+		Expect(scanner.Err()).NotTo(HaveOccurred())
+		return events
+	}
+
+	record := func(events ...*Event) {
+		sink, err := NewFileSink(path)
+		Expect(err).NotTo(HaveOccurred())
+		for _, event := range events {
+			sink.Record(event)
+		}
+		Expect(sink.Close()).To(Succeed())
+	}
+
+	timestamp := time.Date(2022, time.August, 1, 10, 0, 0, 0, time.UTC)
+	added := &Event{
+		Time:      timestamp,
+		RequestID: "1234",
+		Operation: OperationAdd,
+		Pod:       "ns1/tpod",
+		Network:   "ns1/tenantnetwork",
+		Interface: "net1",
+		Result:    ResultSuccess,
+	}
+	failedRemoval := &Event{
+		Time:      timestamp.Add(time.Second),
+		Operation: OperationRemove,
+		Pod:       "ns1/tpod",
+		Network:   "ns1/tenantnetwork",
+		Interface: "net1",
+		Result:    ResultFailure,
+		Error:     "failed to remove delegate",
+	}
+
+	It("features one JSON encoded event per line", func() {
+		record(added, failedRemoval)
+
+		Expect(recordedEvents()).To(Equal([]*Event{added, failedRemoval}))
+	})
+
+	It("is appended to", func() {
+		record(added)
+		record(failedRemoval)
+
+		Expect(recordedEvents()).To(Equal([]*Event{added, failedRemoval}))
+	})
+
+	It("cannot be opened in a missing directory", func() {
+		_, err := NewFileSink(filepath.Join(path, "audit.log"))
+		Expect(err).To(MatchError(ContainSubstring("failed to open the audit log")))
+	})
+})
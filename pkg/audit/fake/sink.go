@@ -0,0 +1,30 @@
+package fake
+
+import (
+	"sync"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/audit"
+)
+
+// Sink records the audit events in memory.
+type Sink struct {
+	lock   sync.Mutex
+	events []*audit.Event
+}
+
+func NewFakeSink() *Sink {
+	return &Sink{}
+}
+
+func (s *Sink) Record(event *audit.Event) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.events = append(s.events, event)
+}
+
+// Events returns the recorded events, in order.
+func (s *Sink) Events() []*audit.Event {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]*audit.Event{}, s.events...)
+}
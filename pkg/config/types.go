@@ -20,7 +20,15 @@ type Multus struct {
 	// path to the socket through which the controller will query the CRI
 	CriSocketPath string `json:"criSocketPath"`
 
-	// CRI-O or containerd
+	// paths to the sockets tried in order - the controller querying the
+	// first one a runtime answers on -, for the nodes running different
+	// runtimes; the runtime type is detected from each path. Exclusive with
+	// criSocketPath and criType
+	CriSocketPaths []string `json:"criSocketPaths,omitempty"`
+
+	// the container runtime type - only containerd is supported, CRI-O is
+	// not; unless specified, it is detected from the socket path, and
+	// defaults to containerd when no socket path is specified either
 	CriType cri.RuntimeType `json:"criType"`
 
 	// Points to the path of the unix domain socket through which the
@@ -44,14 +52,24 @@ func LoadConfig(configPath string) (*Multus, error) {
 		daemonNetConf.MultusSocketPath = defaultMultusSocketPath
 	}
 
-	if daemonNetConf.CriSocketPath == "" {
-		daemonNetConf.CriSocketPath = containerdSocketPath
+	if daemonNetConf.CriType != "" && isInvalidRuntime(daemonNetConf.CriType) {
+		return nil, invalidRuntimeError(daemonNetConf.CriType)
 	}
 
-	if daemonNetConf.CriType == "" {
-		daemonNetConf.CriType = cri.Containerd
-	} else if isInvalidRuntime(daemonNetConf.CriType) {
-		return nil, invalidRuntimeError(daemonNetConf.CriType)
+	if len(daemonNetConf.CriSocketPaths) > 0 {
+		if daemonNetConf.CriSocketPath != "" || daemonNetConf.CriType != "" {
+			return nil, fmt.Errorf("the CRI socket paths cannot be specified along with the CRI socket path, nor the CRI type")
+		}
+		return daemonNetConf, nil
+	}
+
+	// the runtime type defaults to containerd along with its socket; it is
+	// detected from any other socket path
+	if daemonNetConf.CriSocketPath == "" {
+		daemonNetConf.CriSocketPath = containerdSocketPath
+		if daemonNetConf.CriType == "" {
+			daemonNetConf.CriType = cri.Containerd
+		}
 	}
 
 	return daemonNetConf, nil
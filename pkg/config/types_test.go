@@ -91,6 +91,44 @@ var _ = Describe("The dynamic network attachment configuration", func() {
 		})
 	})
 
+	It("detects the runtime type of the CRI socket when only its path is provided", func() {
+		Expect(
+			os.WriteFile(
+				configurationFilePath(configurationDir),
+				[]byte(configurationStringWithDefaultCRIType("/var/run/crio/crio.sock", "")), allowAllPermissions),
+		).To(Succeed())
+
+		multusConfig, err := LoadConfig(configurationFilePath(configurationDir))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(multusConfig.CriSocketPath).To(Equal("/var/run/crio/crio.sock"))
+		Expect(multusConfig.CriType).To(BeEmpty())
+	})
+
+	It("features the CRI socket paths, tried in order, without defaulting the socket path", func() {
+		Expect(
+			os.WriteFile(
+				configurationFilePath(configurationDir),
+				[]byte(`{"criSocketPaths": ["/run/containerd/containerd.sock", "/var/run/crio/crio.sock"]}`), allowAllPermissions),
+		).To(Succeed())
+
+		multusConfig, err := LoadConfig(configurationFilePath(configurationDir))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(multusConfig.CriSocketPaths).To(Equal([]string{"/run/containerd/containerd.sock", "/var/run/crio/crio.sock"}))
+		Expect(multusConfig.CriSocketPath).To(BeEmpty())
+		Expect(multusConfig.CriType).To(BeEmpty())
+	})
+
+	It("fails when the config file features the CRI socket paths along with the CRI type", func() {
+		Expect(
+			os.WriteFile(
+				configurationFilePath(configurationDir),
+				[]byte(`{"criSocketPaths": ["/var/run/crio/crio.sock"], "criType": "crio"}`), allowAllPermissions),
+		).To(Succeed())
+
+		_, err := LoadConfig(configurationFilePath(configurationDir))
+		Expect(err).To(MatchError(ContainSubstring("the CRI socket paths cannot be specified along with")))
+	})
+
 	It("fails when the config file is not present", func() {
 		const aPath = "non-existent-path"
 		_, err := LoadConfig(configurationFilePath(aPath))
@@ -0,0 +1,45 @@
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/audit"
+)
+
+// audit records - in the audit sink, and in the pod's operation history - the
+// outcome of adding the attachments to, or removing them from, the pod; a nil
+// error stands for a success. The dry-run mode changes nothing, thus it is not
+// audited.
+func (pnc *PodNetworksController) audit(
+	dynamicAttachmentRequest *DynamicAttachmentRequest,
+	pod *corev1.Pod,
+	operation audit.Operation,
+	err error,
+	networks ...*nadv1.NetworkSelectionElement,
+) {
+	if pnc.dryRun {
+		return
+	}
+
+	now := pnc.clock.Now()
+	for _, network := range networks {
+		event := &audit.Event{
+			Time:      now,
+			RequestID: dynamicAttachmentRequest.ID,
+			Operation: operation,
+			Pod:       annotations.NamespacedName(pod.GetNamespace(), pod.GetName()),
+			Network:   annotations.NamespacedName(network.Namespace, network.Name),
+			Interface: network.InterfaceRequest,
+			Result:    audit.ResultSuccess,
+		}
+		if err != nil {
+			event.Result = audit.ResultFailure
+			event.Error = err.Error()
+		}
+		pnc.auditSink.Record(event)
+		pnc.podStates.recordOperation(event.Pod, *event, now)
+	}
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	nad "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/audit"
+	fakeaudit "github.com/maiqueb/multus-dynamic-networks-controller/pkg/audit/fake"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni"
+	fakemultusclient "github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni/fake"
+)
+
+var _ = Describe("The audit records", func() {
+	const ifaceName = "net1"
+
+	var auditSink *fakeaudit.Sink
+
+	process := func(multusClient *fakemultusclient.Client, requestType DynamicAttachmentRequestType, opts ...Option) {
+		pod := podSpec(podName, namespace)
+		if requestType == remove {
+			pod.Annotations[nad.NetworkStatusAnnot] = `[{"name":"default/tiny-net","interface":"net1"}]`
+		}
+		auditSink = fakeaudit.NewFakeSink()
+		podController := newSynchedPodController(pod, multusClient, tinyNetAttachDef())
+		WithAuditSink(auditSink)(podController)
+		for _, opt := range opts {
+			opt(podController)
+		}
+
+		podController.enqueue(&DynamicAttachmentRequest{
+			ID:              "1234",
+			PodName:         podName,
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{{Name: networkName, Namespace: namespace, InterfaceRequest: ifaceName}},
+			Type:            requestType,
+			PodNetNS:        netnsPath,
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+	}
+
+	// expectAuditEvent asserts a single event was recorded, returning its error
+	expectAuditEvent := func(operation audit.Operation, result audit.Result) string {
+		Expect(auditSink.Events()).To(HaveLen(1))
+		event := *auditSink.Events()[0]
+		Expect(event.Time).NotTo(BeZero())
+		auditedErr := event.Error
+		event.Time = time.Time{}
+		event.Error = ""
+		Expect(event).To(Equal(audit.Event{
+			RequestID: "1234",
+			Operation: operation,
+			Pod:       annotations.NamespacedName(namespace, podName),
+			Network:   annotations.NamespacedName(namespace, networkName),
+			Interface: ifaceName,
+			Result:    result,
+		}))
+		return auditedErr
+	}
+
+	It("feature the added interfaces", func() {
+		process(fakemultusclient.NewFakeClient(networkConfig(multuscni.CmdAdd, ifaceName, ifaceName, macAddr)), add)
+
+		Expect(expectAuditEvent(audit.OperationAdd, audit.ResultSuccess)).To(BeEmpty())
+	})
+
+	It("feature the interfaces that failed to be added", func() {
+		process(fakemultusclient.NewFakeClient(), add)
+
+		Expect(expectAuditEvent(audit.OperationAdd, audit.ResultFailure)).To(ContainSubstring("failed to ADD delegate"))
+	})
+
+	It("feature the removed interfaces", func() {
+		process(fakemultusclient.NewFakeClient(networkConfig(multuscni.CmdDel, ifaceName, "", "")), remove)
+
+		Expect(expectAuditEvent(audit.OperationRemove, audit.ResultSuccess)).To(BeEmpty())
+	})
+
+	It("feature the interfaces that failed to be removed", func() {
+		process(fakemultusclient.NewFakeClient(), remove)
+
+		Expect(expectAuditEvent(audit.OperationRemove, audit.ResultFailure)).To(ContainSubstring("failed to remove delegate"))
+	})
+
+	It("are not recorded in dry-run mode", func() {
+		process(fakemultusclient.NewFakeClient(networkConfig(multuscni.CmdAdd, ifaceName, ifaceName, macAddr)), add, WithDryRun(true))
+
+		Expect(auditSink.Events()).To(BeEmpty())
+	})
+})
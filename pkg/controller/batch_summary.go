@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+)
+
+const (
+	attachSummaryReason = "AttachSummary"
+	detachSummaryReason = "DetachSummary"
+)
+
+// batchSummary tallies the outcome of the attachments of a request, which is
+// summarized in a single event - on top of the per interface ones - when the
+// request features multiple attachments.
+type batchSummary struct {
+	succeeded int
+	failures  []string
+}
+
+// fail records the attachment failed, and why.
+func (bs *batchSummary) fail(network *nadv1.NetworkSelectionElement, err error) {
+	bs.failures = append(bs.failures, fmt.Sprintf(
+		"%s (%s): %v",
+		network.InterfaceRequest,
+		annotations.NamespacedName(network.Namespace, network.Name),
+		err))
+}
+
+// summarizeBatch emits the summary event of the request's attachments, added
+// or removed - as per `operation` -; a warning when any of them failed. The
+// requests of a single attachment - already described by its own event -, the
+// rollbacks, and the dry-runs are not summarized.
+func (pnc *PodNetworksController) summarizeBatch(
+	pod *corev1.Pod,
+	dynamicAttachmentRequest *DynamicAttachmentRequest,
+	reason string,
+	operation string,
+	summary *batchSummary,
+) {
+	if len(dynamicAttachmentRequest.AttachmentNames) < 2 || dynamicAttachmentRequest.rollback || pnc.dryRun {
+		return
+	}
+	eventType := corev1.EventTypeNormal
+	if len(summary.failures) > 0 {
+		eventType = corev1.EventTypeWarning
+	}
+	pnc.Eventf(pod, eventType, reason, batchSummaryEventFormat(pod, operation, summary))
+}
+
+func batchSummaryEventFormat(pod *corev1.Pod, operation string, summary *batchSummary) string {
+	message := fmt.Sprintf(
+		"pod [%s]: %d %s, %d failed",
+		annotations.NamespacedName(pod.GetNamespace(), pod.GetName()),
+		summary.succeeded,
+		operation,
+		len(summary.failures),
+	)
+	if len(summary.failures) == 0 {
+		return message
+	}
+	return fmt.Sprintf("%s: %s", message, strings.Join(summary.failures, "; "))
+}
@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/client-go/tools/record"
+
+	nad "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni"
+	fakemultusclient "github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni/fake"
+)
+
+var _ = Describe("The summary of a multi-attachment request", func() {
+	const maxEvents = 10
+
+	var (
+		eventRecorder *record.FakeRecorder
+		podController *PodNetworksController
+	)
+
+	BeforeEach(func() {
+		addConfig := networkConfig(multuscni.CmdAdd, "net1", "net1", macAddr)
+		addConfig.Response.Result.Interfaces[0].Sandbox = netnsPath
+		podController = newSynchedPodController(podSpec(podName, namespace), fakemultusclient.NewFakeClient(addConfig), tinyNetAttachDef())
+		eventRecorder = record.NewFakeRecorder(maxEvents)
+		podController.recorder = eventRecorder
+	})
+
+	// the summary comes after the per interface events
+	lastEvent := func() string {
+		var event string
+		for len(eventRecorder.Events) > 0 {
+			event = <-eventRecorder.Events
+		}
+		return event
+	}
+
+	It("tells how many attachments were added, and why the others failed", func() {
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:      podName,
+			PodNamespace: namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{
+				{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"},
+				{Name: networkName, Namespace: namespace, InterfaceRequest: "net2", MacRequest: "not-a-mac"},
+				{Name: "missing-net", Namespace: namespace, InterfaceRequest: "net3"},
+			},
+			Type:     add,
+			PodNetNS: netnsPath,
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		summary := lastEvent()
+		Expect(summary).To(HavePrefix(fmt.Sprintf(
+			"Warning AttachSummary pod [%s]: 1 added, 2 failed: ",
+			annotations.NamespacedName(namespace, podName))))
+		Expect(summary).To(ContainSubstring(fmt.Sprintf("net2 (%s): ", annotations.NamespacedName(namespace, networkName))))
+		Expect(summary).To(ContainSubstring("not-a-mac"))
+		Expect(summary).To(ContainSubstring(fmt.Sprintf("net3 (%s): ", annotations.NamespacedName(namespace, "missing-net"))))
+	})
+
+	It("is not emitted for a single attachment", func() {
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:         podName,
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"}},
+			Type:            add,
+			PodNetNS:        netnsPath,
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		Expect(lastEvent()).To(HavePrefix("Normal AddedInterface"))
+	})
+})
@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+)
+
+// AllowCrossNamespaceReferencesLabel - set to "true" on a namespace - allows
+// the pods of other namespaces to attach to the namespace's
+// network-attachment-definitions when cross namespace references are
+// restricted; thus a tenant cannot attach to the networks it was not granted.
+const AllowCrossNamespaceReferencesLabel = "dynamic-networks-controller.k8s.cni.cncf.io/allow-cross-namespace-references"
+
+// checkNetworkReference fails with errCrossNamespaceReferenceDenied when the
+// pod references a network-attachment-definition of a namespace which does
+// not allow it. The references within the pod's namespace are always allowed,
+// as are all of them unless restricted.
+func (pnc *PodNetworksController) checkNetworkReference(
+	ctx context.Context,
+	pod *corev1.Pod,
+	network *nadv1.NetworkSelectionElement,
+) error {
+	if !pnc.restrictCrossNamespaceRefs || network.Namespace == "" || network.Namespace == pod.GetNamespace() {
+		return nil
+	}
+
+	namespace, err := pnc.k8sClientSet.CoreV1().Namespaces().Get(ctx, network.Namespace, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		// neither does the network-attachment-definition; reported as such
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up the network-attachment-definition's namespace %s: %w", network.Namespace, err)
+	}
+	if namespace.GetLabels()[AllowCrossNamespaceReferencesLabel] != "true" {
+		return fmt.Errorf("%w: %s", errCrossNamespaceReferenceDenied, network.Namespace)
+	}
+	return nil
+}
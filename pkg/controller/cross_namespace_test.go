@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	nad "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni"
+	fakemultusclient "github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni/fake"
+)
+
+var _ = Describe("The cross namespace references", func() {
+	const (
+		ifaceName      = "net1"
+		maxEvents      = 5
+		otherNamespace = "network-owner"
+	)
+
+	var eventRecorder *record.FakeRecorder
+
+	attachment := &nad.NetworkSelectionElement{Name: networkName, Namespace: otherNamespace, InterfaceRequest: ifaceName}
+
+	// attach has the pod attach to the network of the other namespace - which
+	// features the provided labels - returning the controller's pod.
+	attach := func(restricted bool, namespaceLabels map[string]string) *corev1.Pod {
+		eventRecorder = record.NewFakeRecorder(maxEvents)
+		config := networkConfig(multuscni.CmdAdd, ifaceName, ifaceName, macAddr)
+		config.Response.Result.Interfaces[0].Sandbox = netnsPath
+		podController := newSynchedPodController(
+			podSpec(podName, namespace),
+			fakemultusclient.NewFakeClient(config),
+			netAttachDef(networkName, otherNamespace, dummyNetSpec(networkName, cniVersion)))
+		podController.recorder = eventRecorder
+		WithCrossNamespaceReferencesRestricted(restricted)(podController)
+		_, err := podController.k8sClientSet.CoreV1().Namespaces().Create(
+			context.TODO(),
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: otherNamespace, Labels: namespaceLabels}},
+			metav1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:         podName,
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{attachment},
+			Type:            add,
+			PodNetNS:        netnsPath,
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		pod, err := podController.k8sClientSet.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		return pod
+	}
+
+	It("are allowed unless restricted", func() {
+		pod := attach(false, nil)
+
+		Expect(isInterfaceInNetworkStatus(pod, attachment)).To(BeTrue())
+	})
+
+	It("are allowed by the namespaces labeled as such", func() {
+		pod := attach(true, map[string]string{AllowCrossNamespaceReferencesLabel: "true"})
+
+		Expect(isInterfaceInNetworkStatus(pod, attachment)).To(BeTrue())
+	})
+
+	It("are denied - and reported - by the other namespaces", func() {
+		pod := attach(true, map[string]string{AllowCrossNamespaceReferencesLabel: "false"})
+
+		Expect(isInterfaceInNetworkStatus(pod, attachment)).To(BeFalse())
+		Expect(eventRecorder.Events).To(Receive(Equal(fmt.Sprintf(
+			"Warning CrossNamespaceReferenceDenied pod [%s]: skipped adding interface %s to network: %s: "+
+				"namespace %s does not allow references to its network-attachment-definitions from other namespaces",
+			annotations.NamespacedName(namespace, podName),
+			ifaceName,
+			networkName,
+			otherNamespace))))
+	})
+})
@@ -0,0 +1,367 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/klog/v2"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	multusapi "gopkg.in/k8snetworkplumbingwg/multus-cni.v3/pkg/server/api"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+)
+
+// validateNetAttachDefConfig checks the network-attachment-definition
+// features a network configuration - in JSON -, since the delegate is invoked
+// with it; a misconfigured definition would otherwise fail the delegate
+// cryptically.
+func validateNetAttachDefConfig(netAttachDef *nadv1.NetworkAttachmentDefinition) error {
+	netAttachDefName := annotations.NamespacedName(netAttachDef.GetNamespace(), netAttachDef.GetName())
+	if strings.TrimSpace(netAttachDef.Spec.Config) == "" {
+		return fmt.Errorf("%w: the config of network-attachment-definition %s is empty", errInvalidNetAttachDefConfig, netAttachDefName)
+	}
+	if !json.Valid([]byte(netAttachDef.Spec.Config)) {
+		return fmt.Errorf("%w: the config of network-attachment-definition %s is not valid JSON", errInvalidNetAttachDefConfig, netAttachDefName)
+	}
+	return nil
+}
+
+// delegateConfig returns the network configuration handed to the multus
+// delegate, featuring the attributes requested by the network selection element
+// - just like multus does when the pod is created: the runtime config entries
+// are passed in the `runtimeConfig`, while the CNI args - but the ones the
+// controller consumes itself - are passed under `args.cni`. When the
+// configuration is a list, every plugin in it gets them.
+func delegateConfig(netConfig []byte, pod *corev1.Pod, network *nadv1.NetworkSelectionElement) ([]byte, error) {
+	runtimeConfig, err := runtimeConfigArgs(pod, network)
+	if err != nil {
+		return nil, err
+	}
+	cniArgs := delegateCNIArgs(network)
+	if len(runtimeConfig) == 0 && len(cniArgs) == 0 {
+		return netConfig, nil
+	}
+
+	var config map[string]interface{}
+	if err = json.Unmarshal(netConfig, &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the network configuration: %v", err)
+	}
+	if plugins, isConfList := config["plugins"].([]interface{}); isConfList {
+		for i := range plugins {
+			pluginConfig, ok := plugins[i].(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid plugin configuration in the network configuration list: %v", plugins[i])
+			}
+			injectDelegateArgs(pluginConfig, runtimeConfig, cniArgs)
+		}
+	} else {
+		injectDelegateArgs(config, runtimeConfig, cniArgs)
+	}
+	return json.Marshal(config)
+}
+
+// runtimeConfigArgs returns the runtime config entries requested by the
+// attachment: its IPs, MAC address, gateway, port mappings, bandwidth, MTU and
+// IPAM override. The gateway - i.e. the attachment's `default-route` - is what
+// makes a hotplugged interface the pod's default route.
+func runtimeConfigArgs(pod *corev1.Pod, network *nadv1.NetworkSelectionElement) (map[string]interface{}, error) {
+	runtimeConfig := map[string]interface{}{}
+	if len(network.IPRequest) > 0 {
+		runtimeConfig["ips"] = network.IPRequest
+	}
+	if network.MacRequest != "" {
+		runtimeConfig["mac"] = network.MacRequest
+	}
+	if len(network.GatewayRequest) > 0 {
+		runtimeConfig["gateway"] = network.GatewayRequest
+	}
+	if len(network.PortMappingsRequest) > 0 {
+		runtimeConfig["portMappings"] = network.PortMappingsRequest
+	}
+	bandwidth, err := bandwidthRequest(pod, network)
+	if err != nil {
+		return nil, err
+	}
+	if bandwidth != nil {
+		runtimeConfig["bandwidth"] = bandwidth
+	}
+	mtu, err := mtuRequest(network)
+	if err != nil {
+		return nil, err
+	}
+	if mtu > 0 {
+		runtimeConfig["mtu"] = mtu
+	}
+	ipam, err := ipamOverride(network)
+	if err != nil {
+		return nil, err
+	}
+	if ipam != nil {
+		runtimeConfig["ipam"] = ipam
+	}
+	return runtimeConfig, nil
+}
+
+// delegateRequest returns the multus delegate request of the CNI command on
+// the pod's interface; its CNI_ARGS feature the pod's identity - like the
+// kubelet's do - and the pod's metadata configured to be forwarded.
+func (pnc *PodNetworksController) delegateRequest(
+	ctx context.Context,
+	cniCommand string,
+	containerID string,
+	netnsPath string,
+	ifaceName string,
+	pod *corev1.Pod,
+	netConfig []byte,
+) *multusapi.Request {
+	request := multusapi.CreateDelegateRequest(
+		cniCommand,
+		containerID,
+		netnsPath,
+		ifaceName,
+		pod.GetNamespace(),
+		pod.GetName(),
+		string(pod.UID),
+		netConfig,
+	)
+	if metadataArgs := pnc.podMetadataCNIArgs(ctx, pod); len(metadataArgs) > 0 {
+		request.Env["CNI_ARGS"] = strings.Join(append([]string{request.Env["CNI_ARGS"]}, metadataArgs...), ";")
+	}
+	return request
+}
+
+// podMetadataCNIArgs returns the `<key>=<value>` CNI args of the pod's labels,
+// then annotations, configured to be forwarded to the delegates - the ones the
+// pod does not feature being left out -, preceded by `IgnoreUnknown=1` since
+// the plugins would not know them. The values a CNI arg cannot hold - i.e.
+// featuring `;` or `=` - are left out as well.
+func (pnc *PodNetworksController) podMetadataCNIArgs(ctx context.Context, pod *corev1.Pod) []string {
+	var args []string
+	appendArgs := func(metadata map[string]string, keys []string) {
+		for _, key := range keys {
+			value, wasFound := metadata[key]
+			if !wasFound {
+				continue
+			}
+			if strings.ContainsAny(value, ";=") {
+				klog.FromContext(ctx).Info("not forwarding the pod metadata to the delegate: the value cannot be a CNI arg", "key", key)
+				continue
+			}
+			args = append(args, fmt.Sprintf("%s=%s", key, value))
+		}
+	}
+	appendArgs(pod.GetLabels(), pnc.cniArgsPodLabels)
+	appendArgs(pod.GetAnnotations(), pnc.cniArgsPodAnnotations)
+	if len(args) == 0 {
+		return nil
+	}
+	return append([]string{"IgnoreUnknown=1"}, args...)
+}
+
+// controllerCNIArgs are the cni-args keys consumed by the controller, which
+// are never passed to the delegate.
+var controllerCNIArgs = []string{
+	sysctlsCNIArg,
+	targetContainerCNIArg,
+	mtuCNIArg,
+	ipamCNIArg,
+}
+
+// delegateCNIArgs returns the attachment's cni-args, without the ones consumed
+// by the controller.
+func delegateCNIArgs(network *nadv1.NetworkSelectionElement) map[string]interface{} {
+	if network.CNIArgs == nil {
+		return nil
+	}
+	cniArgs := make(map[string]interface{}, len(*network.CNIArgs))
+	for key, value := range *network.CNIArgs {
+		cniArgs[key] = value
+	}
+	for _, key := range controllerCNIArgs {
+		delete(cniArgs, key)
+	}
+	return cniArgs
+}
+
+func injectDelegateArgs(config map[string]interface{}, runtimeConfig map[string]interface{}, cniArgs map[string]interface{}) {
+	runtimeConfig = capabilityArgs(config, runtimeConfig)
+	if len(runtimeConfig) > 0 {
+		currentRuntimeConfig, ok := config["runtimeConfig"].(map[string]interface{})
+		if !ok {
+			currentRuntimeConfig = map[string]interface{}{}
+		}
+		for key, value := range runtimeConfig {
+			currentRuntimeConfig[key] = value
+		}
+		config["runtimeConfig"] = currentRuntimeConfig
+	}
+	if len(cniArgs) > 0 {
+		args, ok := config["args"].(map[string]interface{})
+		if !ok {
+			args = map[string]interface{}{}
+		}
+		currentCNIArgs, ok := args["cni"].(map[string]interface{})
+		if !ok {
+			currentCNIArgs = map[string]interface{}{}
+		}
+		for key, value := range cniArgs {
+			currentCNIArgs[key] = value
+		}
+		args["cni"] = currentCNIArgs
+		config["args"] = args
+	}
+}
+
+// capabilityArgs returns the runtime config entries of the capabilities the
+// plugin declares, or all of them when it does not declare its capabilities -
+// like libcni does; e.g. the portmap and bandwidth chained plugins only get
+// the port mappings and the bandwidth.
+func capabilityArgs(pluginConfig map[string]interface{}, runtimeConfig map[string]interface{}) map[string]interface{} {
+	capabilities, declaresCapabilities := pluginConfig["capabilities"].(map[string]interface{})
+	if !declaresCapabilities {
+		return runtimeConfig
+	}
+	args := map[string]interface{}{}
+	for capability, value := range runtimeConfig {
+		if isEnabled, ok := capabilities[capability].(bool); ok && isEnabled {
+			args[capability] = value
+		}
+	}
+	return args
+}
+
+const (
+	// mtuCNIArg is the cni-args key requesting the MTU of the attachment's
+	// interface, overriding the one in the network configuration.
+	mtuCNIArg = "mtu"
+
+	// minMTU is the smallest MTU an IPv4 interface may have, while maxMTU is the
+	// largest an IP packet fits in.
+	minMTU = 68
+	maxMTU = 65535
+)
+
+// mtuRequest returns the MTU requested by the attachment's cni-args, or 0 when
+// it does not request any. The MTU is a JSON number, or the string holding it;
+// it is passed in the `runtimeConfig`, overriding the network configuration's.
+func mtuRequest(network *nadv1.NetworkSelectionElement) (int, error) {
+	if network.CNIArgs == nil {
+		return 0, nil
+	}
+	rawMTU, wasFound := (*network.CNIArgs)[mtuCNIArg]
+	if !wasFound {
+		return 0, nil
+	}
+	var mtu float64
+	switch value := rawMTU.(type) {
+	case float64:
+		mtu = value
+	case string:
+		parsedMTU, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, fmt.Errorf("the %q cni-arg %q is not a number", mtuCNIArg, value)
+		}
+		mtu = float64(parsedMTU)
+	default:
+		return 0, fmt.Errorf("the %q cni-arg must be a number: %v", mtuCNIArg, rawMTU)
+	}
+	if mtu != math.Trunc(mtu) || mtu < minMTU || mtu > maxMTU {
+		return 0, fmt.Errorf("the %q cni-arg %v is out of the [%d, %d] range", mtuCNIArg, rawMTU, minMTU, maxMTU)
+	}
+	return int(mtu), nil
+}
+
+// ipamCNIArg is the cni-args key steering the IPAM of the attachment's
+// interface - e.g. `"cni-args": {"ipam": {"pool": "blue"}}` -; the override is
+// a JSON object, or the string holding it, whose attributes the IPAM plugin
+// interprets.
+const ipamCNIArg = "ipam"
+
+// ipamOverride returns the IPAM override requested by the attachment's
+// cni-args, or nil when it does not request any; it is passed in the
+// `runtimeConfig`, for the IPAM plugin to interpret.
+func ipamOverride(network *nadv1.NetworkSelectionElement) (map[string]interface{}, error) {
+	if network.CNIArgs == nil {
+		return nil, nil
+	}
+	rawOverride, wasFound := (*network.CNIArgs)[ipamCNIArg]
+	if !wasFound {
+		return nil, nil
+	}
+	switch value := rawOverride.(type) {
+	case map[string]interface{}:
+		return value, nil
+	case string:
+		var override map[string]interface{}
+		if err := json.Unmarshal([]byte(value), &override); err != nil || override == nil {
+			return nil, fmt.Errorf("the %q cni-arg %q is not a JSON object", ipamCNIArg, value)
+		}
+		return override, nil
+	default:
+		return nil, fmt.Errorf("the %q cni-arg must be a JSON object: %v", ipamCNIArg, rawOverride)
+	}
+}
+
+const (
+	ingressBandwidthAnnot = "kubernetes.io/ingress-bandwidth"
+	egressBandwidthAnnot  = "kubernetes.io/egress-bandwidth"
+)
+
+// bandwidthRequest returns the bandwidth requested by the attachment or - when
+// it does not request any - by the pod's bandwidth annotations; nil is returned
+// when neither request one. Like the kubelet does, the burst of the rates read
+// from the annotations is not limited.
+func bandwidthRequest(pod *corev1.Pod, network *nadv1.NetworkSelectionElement) (*nadv1.BandwidthEntry, error) {
+	if network.BandwidthRequest != nil {
+		return network.BandwidthRequest, nil
+	}
+	if pod == nil {
+		return nil, nil
+	}
+
+	ingressRate, err := bandwidthAnnotation(pod, ingressBandwidthAnnot)
+	if err != nil {
+		return nil, err
+	}
+	egressRate, err := bandwidthAnnotation(pod, egressBandwidthAnnot)
+	if err != nil {
+		return nil, err
+	}
+	if ingressRate == 0 && egressRate == 0 {
+		return nil, nil
+	}
+	bandwidth := &nadv1.BandwidthEntry{}
+	if ingressRate > 0 {
+		bandwidth.IngressRate = ingressRate
+		bandwidth.IngressBurst = math.MaxInt32
+	}
+	if egressRate > 0 {
+		bandwidth.EgressRate = egressRate
+		bandwidth.EgressBurst = math.MaxInt32
+	}
+	return bandwidth, nil
+}
+
+// bandwidthAnnotation returns the rate - in bits per second - of the pod's
+// bandwidth annotation, or 0 if the pod does not feature it.
+func bandwidthAnnotation(pod *corev1.Pod, annotation string) (int, error) {
+	rawRate, wasFound := pod.Annotations[annotation]
+	if !wasFound {
+		return 0, nil
+	}
+	rate, err := resource.ParseQuantity(rawRate)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse the %s annotation %q: %v", annotation, rawRate, err)
+	}
+	if rate.Sign() <= 0 || rate.Value() > math.MaxInt32 {
+		return 0, fmt.Errorf("the %s annotation %q is out of range", annotation, rawRate)
+	}
+	return int(rate.Value()), nil
+}
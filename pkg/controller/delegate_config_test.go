@@ -0,0 +1,462 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	nad "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+	fakelink "github.com/maiqueb/multus-dynamic-networks-controller/pkg/link/fake"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni"
+	fakemultusclient "github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni/fake"
+)
+
+var _ = Describe("The delegate configuration", func() {
+	cniArgs := func() *map[string]interface{} {
+		return &map[string]interface{}{"foo": "bar"}
+	}
+
+	It("features the attributes requested by the attachment", func() {
+		pod := podSpec(podName, namespace)
+		multusClient := fakemultusclient.NewFakeClient(networkConfig(multuscni.CmdAdd, "net1", networkName, macAddr))
+		podController := newSynchedPodController(
+			pod,
+			multusClient,
+			tinyNetAttachDef())
+
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:      podName,
+			PodNamespace: namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{
+				{
+					Name:             networkName,
+					Namespace:        namespace,
+					InterfaceRequest: "net1",
+					IPRequest:        []string{"10.10.10.10/24"},
+					MacRequest:       macAddr,
+					GatewayRequest:   []net.IP{net.ParseIP("10.10.10.1")},
+					CNIArgs:          cniArgs(),
+				},
+			},
+			Type:     add,
+			PodNetNS: netnsPath,
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		Expect(multusClient.Requests()).To(HaveLen(1))
+		var delegateConfig map[string]interface{}
+		Expect(json.Unmarshal(multusClient.Requests()[0].Config, &delegateConfig)).To(Succeed())
+		Expect(delegateConfig).To(HaveKeyWithValue("runtimeConfig", map[string]interface{}{
+			"ips":     []interface{}{"10.10.10.10/24"},
+			"mac":     macAddr,
+			"gateway": []interface{}{"10.10.10.1"},
+		}))
+		Expect(delegateConfig).To(HaveKeyWithValue("args", map[string]interface{}{
+			"cni": map[string]interface{}{"foo": "bar"},
+		}))
+	})
+
+	It("features the default route requested in the pod's networks annotation", func() {
+		pod := podSpec(podName, namespace)
+		multusClient := fakemultusclient.NewFakeClient(networkConfig(multuscni.CmdAdd, "net1", networkName, macAddr))
+		podController := newSynchedPodController(
+			pod,
+			multusClient,
+			tinyNetAttachDef())
+		podController.recorder = record.NewFakeRecorder(5)
+
+		updatedPod := pod.DeepCopy()
+		updatedPod.Annotations[nad.NetworkAttachmentAnnot] = fmt.Sprintf(
+			`[{"name":%q,"interface":"net1","ips":["10.10.10.10/24"],"default-route":["10.10.10.1"]}]`,
+			networkName)
+		podController.handlePodUpdate(pod, updatedPod)
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		Expect(multusClient.Requests()).To(HaveLen(1))
+		var delegateConfig map[string]interface{}
+		Expect(json.Unmarshal(multusClient.Requests()[0].Config, &delegateConfig)).To(Succeed())
+		Expect(delegateConfig).To(HaveKeyWithValue("runtimeConfig", HaveKeyWithValue("gateway", []interface{}{"10.10.10.1"})))
+	})
+
+	It("features the CNI args in every plugin of a configuration list", func() {
+		const confList = `{"cniVersion":"0.3.0","name":"tiny-net","plugins":[{"type":"bridge"},{"type":"tuning"}]}`
+
+		config, err := delegateConfig(
+			[]byte(confList),
+			podSpec(podName, namespace),
+			&nad.NetworkSelectionElement{Name: networkName, Namespace: namespace, CNIArgs: cniArgs()})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config).To(MatchJSON(
+			`{"cniVersion":"0.3.0","name":"tiny-net","plugins":[` +
+				`{"type":"bridge","args":{"cni":{"foo":"bar"}}},` +
+				`{"type":"tuning","args":{"cni":{"foo":"bar"}}}]}`))
+	})
+
+	It("is the network configuration when the attachment does not request any attribute", func() {
+		netConfig := []byte(dummyNetSpec(networkName, cniVersion))
+		Expect(delegateConfig(netConfig, podSpec(podName, namespace), &nad.NetworkSelectionElement{Name: networkName, Namespace: namespace})).To(Equal(netConfig))
+	})
+
+	It("features the port mappings and bandwidth requested by the attachment", func() {
+		config, err := delegateConfig(
+			[]byte(dummyNetSpec(networkName, cniVersion)),
+			podSpec(podName, namespace),
+			&nad.NetworkSelectionElement{
+				Name:                networkName,
+				Namespace:           namespace,
+				PortMappingsRequest: []*nad.PortMapEntry{{HostPort: 8080, ContainerPort: 80, Protocol: "tcp"}},
+				BandwidthRequest:    &nad.BandwidthEntry{IngressRate: 1000, IngressBurst: 2000, EgressRate: 3000, EgressBurst: 4000},
+			})
+		Expect(err).NotTo(HaveOccurred())
+
+		var delegateConfig map[string]interface{}
+		Expect(json.Unmarshal(config, &delegateConfig)).To(Succeed())
+		Expect(delegateConfig).To(HaveKeyWithValue("runtimeConfig", map[string]interface{}{
+			"portMappings": []interface{}{
+				map[string]interface{}{"hostPort": float64(8080), "containerPort": float64(80), "protocol": "tcp"},
+			},
+			"bandwidth": map[string]interface{}{
+				"ingressRate":  float64(1000),
+				"ingressBurst": float64(2000),
+				"egressRate":   float64(3000),
+				"egressBurst":  float64(4000),
+			},
+		}))
+	})
+
+	It("features the bandwidth requested by the pod's annotations, unless the attachment requests one", func() {
+		pod := podSpec(podName, namespace)
+		pod.Annotations[ingressBandwidthAnnot] = "1M"
+		pod.Annotations[egressBandwidthAnnot] = "2M"
+
+		config, err := delegateConfig(
+			[]byte(dummyNetSpec(networkName, cniVersion)),
+			pod,
+			&nad.NetworkSelectionElement{Name: networkName, Namespace: namespace})
+		Expect(err).NotTo(HaveOccurred())
+		var delegateConfig map[string]interface{}
+		Expect(json.Unmarshal(config, &delegateConfig)).To(Succeed())
+		Expect(delegateConfig).To(HaveKeyWithValue("runtimeConfig", map[string]interface{}{
+			"bandwidth": map[string]interface{}{
+				"ingressRate":  float64(1000000),
+				"ingressBurst": float64(math.MaxInt32),
+				"egressRate":   float64(2000000),
+				"egressBurst":  float64(math.MaxInt32),
+			},
+		}))
+
+		Expect(bandwidthRequest(pod, &nad.NetworkSelectionElement{BandwidthRequest: &nad.BandwidthEntry{IngressRate: 10}})).To(
+			Equal(&nad.BandwidthEntry{IngressRate: 10}))
+	})
+
+	It("does not feature the CNI args consumed by the controller", func() {
+		config, err := delegateConfig(
+			[]byte(dummyNetSpec(networkName, cniVersion)),
+			podSpec(podName, namespace),
+			&nad.NetworkSelectionElement{
+				Name:             networkName,
+				Namespace:        namespace,
+				InterfaceRequest: "net1",
+				CNIArgs: &map[string]interface{}{
+					"foo":                 "bar",
+					sysctlsCNIArg:         map[string]interface{}{"net.ipv4.conf.net1.arp_notify": "1"},
+					targetContainerCNIArg: "sidecar",
+				},
+			})
+		Expect(err).NotTo(HaveOccurred())
+
+		var delegateConfig map[string]interface{}
+		Expect(json.Unmarshal(config, &delegateConfig)).To(Succeed())
+		Expect(delegateConfig).To(HaveKeyWithValue("args", map[string]interface{}{
+			"cni": map[string]interface{}{"foo": "bar"},
+		}))
+	})
+
+	It("features the device specific CNI args of the hotplugged attachment unchanged, as when the pod is created", func() {
+		const sriovCNIArgs = `{"trust":"on","spoofchk":"off","vlan":100,"queues":4,"offload":{"tso":true,"gro":false}}`
+		pod := podSpec(podName, namespace)
+		multusClient := fakemultusclient.NewFakeClient(networkConfig(multuscni.CmdAdd, "net1", networkName, macAddr))
+		podController := newSynchedPodController(pod, multusClient, tinyNetAttachDef())
+		updatedPod := pod.DeepCopy()
+		updatedPod.Annotations[nad.NetworkAttachmentAnnot] = fmt.Sprintf(
+			`[{"name":%q,"interface":"net1","cni-args":%s}]`, networkName, sriovCNIArgs)
+		Expect(podController.podsInformer.GetStore().Update(updatedPod)).To(Succeed())
+
+		podController.handlePodUpdate(pod, updatedPod)
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		Expect(multusClient.Requests()).To(HaveLen(1))
+		var delegateConfig struct {
+			Args struct {
+				CNI json.RawMessage `json:"cni"`
+			} `json:"args"`
+		}
+		Expect(json.Unmarshal(multusClient.Requests()[0].Config, &delegateConfig)).To(Succeed())
+		Expect(delegateConfig.Args.CNI).To(MatchJSON(sriovCNIArgs))
+	})
+
+	It("features the MTU requested by the attachment's cni-args, which are not passed to the delegate", func() {
+		pod := podSpec(podName, namespace)
+		multusClient := fakemultusclient.NewFakeClient(networkConfig(multuscni.CmdAdd, "net1", networkName, macAddr))
+		podController := newSynchedPodController(pod, multusClient, tinyNetAttachDef())
+
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:      podName,
+			PodNamespace: namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{
+				{
+					Name:             networkName,
+					Namespace:        namespace,
+					InterfaceRequest: "net1",
+					CNIArgs:          &map[string]interface{}{mtuCNIArg: float64(9000)},
+				},
+			},
+			Type:     add,
+			PodNetNS: netnsPath,
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		Expect(multusClient.Requests()).To(HaveLen(1))
+		var delegateConfig map[string]interface{}
+		Expect(json.Unmarshal(multusClient.Requests()[0].Config, &delegateConfig)).To(Succeed())
+		Expect(delegateConfig).To(HaveKeyWithValue("runtimeConfig", map[string]interface{}{"mtu": float64(9000)}))
+		Expect(delegateConfig).NotTo(HaveKey("args"))
+	})
+
+	It("features the MTU requested as a string", func() {
+		Expect(mtuRequest(&nad.NetworkSelectionElement{CNIArgs: &map[string]interface{}{mtuCNIArg: "1400"}})).To(Equal(1400))
+	})
+
+	It("is not computed - and the attachment is skipped - when the requested MTU is out of range", func() {
+		for _, mtu := range []interface{}{float64(0), float64(-1500), float64(67), float64(65536), float64(1500.5), "-1", "jumbo", true} {
+			_, err := mtuRequest(&nad.NetworkSelectionElement{CNIArgs: &map[string]interface{}{mtuCNIArg: mtu}})
+			Expect(err).To(HaveOccurred(), "MTU %v", mtu)
+		}
+
+		pod := podSpec(podName, namespace)
+		multusClient := fakemultusclient.NewFakeClient()
+		podController := newSynchedPodController(pod, multusClient, tinyNetAttachDef())
+		eventRecorder := record.NewFakeRecorder(5)
+		podController.recorder = eventRecorder
+
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:      podName,
+			PodNamespace: namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{
+				{
+					Name:             networkName,
+					Namespace:        namespace,
+					InterfaceRequest: "net1",
+					CNIArgs:          &map[string]interface{}{mtuCNIArg: float64(0)},
+				},
+			},
+			Type:     add,
+			PodNetNS: netnsPath,
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		Expect(multusClient.Requests()).To(BeEmpty())
+		Expect(eventRecorder.Events).To(Receive(Equal(fmt.Sprintf(
+			"Warning InvalidInterfaceRequest pod [%s]: skipped adding interface net1 to network: %s: %s",
+			annotations.NamespacedName(namespace, podName),
+			networkName,
+			`the "mtu" cni-arg 0 is out of the [68, 65535] range`))))
+	})
+
+	It("features the IPAM override requested by the attachment's cni-args, which is not passed to the delegate", func() {
+		pod := podSpec(podName, namespace)
+		multusClient := fakemultusclient.NewFakeClient(networkConfig(multuscni.CmdAdd, "net1", networkName, macAddr))
+		podController := newSynchedPodController(pod, multusClient, tinyNetAttachDef())
+
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:      podName,
+			PodNamespace: namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{
+				{
+					Name:             networkName,
+					Namespace:        namespace,
+					InterfaceRequest: "net1",
+					CNIArgs:          &map[string]interface{}{ipamCNIArg: map[string]interface{}{"pool": "blue"}},
+				},
+			},
+			Type:     add,
+			PodNetNS: netnsPath,
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		Expect(multusClient.Requests()).To(HaveLen(1))
+		var delegateConfig map[string]interface{}
+		Expect(json.Unmarshal(multusClient.Requests()[0].Config, &delegateConfig)).To(Succeed())
+		Expect(delegateConfig).To(HaveKeyWithValue("runtimeConfig", map[string]interface{}{
+			"ipam": map[string]interface{}{"pool": "blue"},
+		}))
+		Expect(delegateConfig).NotTo(HaveKey("args"))
+	})
+
+	It("features the IPAM override requested as a string", func() {
+		Expect(ipamOverride(&nad.NetworkSelectionElement{CNIArgs: &map[string]interface{}{ipamCNIArg: `{"pool":"blue"}`}})).To(
+			Equal(map[string]interface{}{"pool": "blue"}))
+	})
+
+	It("rejects the IPAM overrides which are not JSON objects", func() {
+		for _, override := range []interface{}{`{"pool":`, `["blue"]`, "null", "blue", float64(1), true} {
+			_, err := ipamOverride(&nad.NetworkSelectionElement{CNIArgs: &map[string]interface{}{ipamCNIArg: override}})
+			Expect(err).To(HaveOccurred(), "IPAM override %v", override)
+		}
+	})
+
+	It("features only the runtime config of the capabilities declared by the plugin", func() {
+		const confList = `{"cniVersion":"0.4.0","name":"tiny-net","plugins":[` +
+			`{"type":"macvlan","capabilities":{"ips":true,"mac":false}},` +
+			`{"type":"tuning","capabilities":{"mac":true}},` +
+			`{"type":"sbr"}]}`
+		pod := podSpec(podName, namespace)
+		multusClient := fakemultusclient.NewFakeClient(networkConfig(multuscni.CmdAdd, "net1", networkName, macAddr))
+		podController := newSynchedPodController(pod, multusClient, netAttachDef(networkName, namespace, confList))
+
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:      podName,
+			PodNamespace: namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{
+				{
+					Name:             networkName,
+					Namespace:        namespace,
+					InterfaceRequest: "net1",
+					IPRequest:        []string{"10.10.10.10/24"},
+					MacRequest:       macAddr,
+				},
+			},
+			Type:     add,
+			PodNetNS: netnsPath,
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		Expect(multusClient.Requests()).To(HaveLen(1))
+		Expect(multusClient.Requests()[0].Config).To(MatchJSON(`{"cniVersion":"0.4.0","name":"tiny-net","plugins":[` +
+			`{"type":"macvlan","capabilities":{"ips":true,"mac":false},"runtimeConfig":{"ips":["10.10.10.10/24"]}},` +
+			`{"type":"tuning","capabilities":{"mac":true},"runtimeConfig":{"mac":"` + macAddr + `"}},` +
+			`{"type":"sbr","runtimeConfig":{"ips":["10.10.10.10/24"],"mac":"` + macAddr + `"}}]}`))
+	})
+
+	It("cannot be computed when the pod's bandwidth annotations are malformed", func() {
+		pod := podSpec(podName, namespace)
+		pod.Annotations[ingressBandwidthAnnot] = "lots"
+
+		_, err := delegateConfig(
+			[]byte(dummyNetSpec(networkName, cniVersion)),
+			pod,
+			&nad.NetworkSelectionElement{Name: networkName, Namespace: namespace})
+		Expect(err).To(MatchError(ContainSubstring("failed to parse the kubernetes.io/ingress-bandwidth annotation")))
+	})
+})
+
+var _ = Describe("Removing an attachment whose delegate cannot be configured", func() {
+	// the requested MAC address is injected in the malformed configuration
+	removedAttachment := func() *nad.NetworkSelectionElement {
+		return &nad.NetworkSelectionElement{Name: networkName, Namespace: namespace, InterfaceRequest: "net0", MacRequest: macAddr}
+	}
+
+	It("deletes the interface by name, rather than being dropped", func() {
+		multusClient := fakemultusclient.NewFakeClient()
+		podController := newSynchedPodController(
+			podSpec(podName, namespace, networkName),
+			multusClient,
+			netAttachDef(networkName, namespace, "{not json"))
+		podController.recorder = record.NewFakeRecorder(5)
+		linkDeleter := fakelink.NewFakeDeleter(nil)
+		WithLinkDeleter(linkDeleter)(podController)
+
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:         podName,
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{removedAttachment()},
+			Type:            remove,
+			PodNetNS:        netnsPath,
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		Expect(linkDeleter.Deleted(netnsPath)).To(ConsistOf("net0"))
+		Expect(multusClient.Requests()).To(BeEmpty())
+		pod, err := podController.k8sClientSet.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(isInterfaceInNetworkStatus(pod, removedAttachment())).To(BeFalse())
+	})
+
+	It("is retried when failing for good", func() {
+		podController := newSynchedPodController(
+			podSpec(podName, namespace, networkName),
+			fakemultusclient.NewFakeClient(),
+			netAttachDef(networkName, namespace, "{not json"))
+		podController.recorder = record.NewFakeRecorder(5)
+		WithLinkDeleter(fakelink.NewFakeDeleter(errors.New("operation not permitted")))(podController)
+
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:         podName,
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{removedAttachment()},
+			Type:            remove,
+			PodNetNS:        netnsPath,
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		Expect(podController.workqueue.NumRequeues(annotations.NamespacedName(namespace, podName))).To(Equal(1))
+	})
+})
+
+var _ = Describe("The delegate CNI args", func() {
+	var (
+		multusClient  *fakemultusclient.Client
+		pod           *corev1.Pod
+		podController *PodNetworksController
+	)
+
+	BeforeEach(func() {
+		pod = podSpec(podName, namespace)
+		pod.Labels = map[string]string{"app": "router", "tier": "edge", "team": "network"}
+		pod.Annotations["example.com/vrf"] = "blue"
+		pod.Annotations["example.com/route"] = "10.0.0.0/8=eth0"
+		multusClient = fakemultusclient.NewFakeClient(networkConfig(multuscni.CmdAdd, "net1", networkName, macAddr))
+		podController = newSynchedPodController(pod, multusClient, tinyNetAttachDef())
+	})
+
+	addAttachment := func() {
+		podController.handlePodUpdate(pod, updatePodSpec(pod, networkName))
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+		Expect(multusClient.Requests()).To(HaveLen(1))
+	}
+
+	It("feature the pod labels and annotations configured to be forwarded", func() {
+		WithPodLabelsAsCNIArgs([]string{"tier", "app", "zone"})(podController)
+		WithPodAnnotationsAsCNIArgs([]string{"example.com/vrf", "example.com/route"})(podController)
+
+		addAttachment()
+
+		Expect(multusClient.Requests()[0].Env["CNI_ARGS"]).To(Equal(fmt.Sprintf(
+			"K8S_POD_NAMESPACE=%s;K8S_POD_NAME=%s;K8S_POD_UID=%s;IgnoreUnknown=1;tier=edge;app=router;example.com/vrf=blue",
+			namespace,
+			podName,
+			pod.GetUID())))
+	})
+
+	It("only feature the pod's identity unless configured otherwise", func() {
+		addAttachment()
+
+		Expect(multusClient.Requests()[0].Env["CNI_ARGS"]).To(Equal(fmt.Sprintf(
+			"K8S_POD_NAMESPACE=%s;K8S_POD_NAME=%s;K8S_POD_UID=%s",
+			namespace,
+			podName,
+			pod.GetUID())))
+	})
+})
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+
+	"k8s.io/klog/v2"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+)
+
+// DrainAttachmentsEndpoint drains the dynamic attachments of the pods the
+// controller handles; see DrainAttachments.
+const DrainAttachmentsEndpoint = "/drain-attachments"
+
+// DrainedAttachments lists - sorted - the pods whose dynamic attachments are
+// being removed.
+type DrainedAttachments struct {
+	Pods []string `json:"pods"`
+}
+
+// attachmentsDrain tells if the controller drained the pods' dynamic
+// attachments - e.g. since the node is being drained. Once drained, only the
+// removals are processed: the attachments would otherwise be added back by the
+// pods' networks annotations.
+type attachmentsDrain struct {
+	lock       sync.RWMutex
+	isDraining bool
+}
+
+func (d *attachmentsDrain) draining() bool {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	return d.isDraining
+}
+
+func (d *attachmentsDrain) start() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.isDraining = true
+}
+
+// DrainAttachments removes the dynamic attachments of the pods the controller
+// handles - thus releasing the external resources, e.g. IPAM leases, the CNI
+// plugins allocated for them - returning the keys of the pods, sorted. From
+// then on the controller stops adding attachments, until restarted; meant for
+// the controllers run per node, when the node is drained.
+func (pnc *PodNetworksController) DrainAttachments() []string {
+	pnc.attachmentsDrain.start()
+
+	pods, err := pnc.podsLister.List(pnc.podSelector)
+	if err != nil {
+		klog.ErrorS(err, "failed to list the pods to drain")
+		return nil
+	}
+
+	podKeys := []string{}
+	for _, pod := range pods {
+		currentNetworks, err := networkStatus(pod.Annotations)
+		if err != nil {
+			continue
+		}
+		toRemove := pnc.dynamicAttachments(pod, currentNetworks)
+		if len(toRemove) == 0 {
+			continue
+		}
+		netnsPath, err := pnc.netnsPath(pod)
+		if err != nil {
+			klog.InfoS("draining the attachments of the pod without its network namespace", "pod", klog.KObj(pod), "reason", err)
+		}
+		pnc.enqueue(
+			&DynamicAttachmentRequest{
+				PodName:         pod.GetName(),
+				PodUID:          pod.GetUID(),
+				PodNamespace:    pod.GetNamespace(),
+				AttachmentNames: toRemove,
+				Type:            remove,
+				PodNetNS:        netnsPath,
+			})
+		podKeys = append(podKeys, annotations.NamespacedName(pod.GetNamespace(), pod.GetName()))
+	}
+	sort.Strings(podKeys)
+	klog.InfoS("draining the pods' dynamic attachments", "pods", len(podKeys))
+	return podKeys
+}
+
+func (pnc *PodNetworksController) serveDrainAttachments(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "expected a POST request", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(DrainedAttachments{Pods: pnc.DrainAttachments()}); err != nil {
+		klog.ErrorS(err, "failed to reply the drained attachments")
+	}
+}
@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+
+	nad "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+	fakecri "github.com/maiqueb/multus-dynamic-networks-controller/pkg/cri/fake"
+	fakemultusclient "github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni/fake"
+)
+
+var _ = Describe("Draining the attachments", func() {
+	var (
+		podController *PodNetworksController
+		server        *httptest.Server
+	)
+
+	podWithInterfaces := func(name string, dynamicIfaces string) *corev1.Pod {
+		pod := podSpec(name, namespace)
+		pod.Annotations[nad.NetworkStatusAnnot] = `[{"name":"cluster-net","interface":"eth0","default":true},` +
+			`{"name":"default/tiny-net","interface":"net0"},` +
+			`{"name":"default/tiny-net","interface":"net1"},` +
+			`{"name":"default/tiny-net","interface":"net2"}]`
+		if dynamicIfaces != "" {
+			pod.Annotations[defaultBookkeepingKeys.DynamicInterfaces] = dynamicIfaces
+		}
+		return pod
+	}
+
+	dynamicAttachment := func(ifaceName string) *nad.NetworkSelectionElement {
+		return &nad.NetworkSelectionElement{Name: networkName, Namespace: namespace, InterfaceRequest: ifaceName}
+	}
+
+	BeforeEach(func() {
+		pods := []*corev1.Pod{
+			podWithInterfaces("pod-a", `["net1"]`),
+			podWithInterfaces("pod-b", `["net1","net2"]`),
+			// the interfaces multus added when creating the pod are left alone
+			podWithInterfaces("pod-c", ""),
+		}
+		var runningPods []corev1.Pod
+		for _, pod := range pods {
+			runningPods = append(runningPods, *pod)
+		}
+		podController = newUnstartedPodController(fakecri.NewFakeRuntime(runningPods...), fakemultusclient.NewFakeClient())
+		for _, pod := range pods {
+			Expect(podController.podsInformer.GetStore().Add(pod)).To(Succeed())
+		}
+		server = httptest.NewServer(podController.AdminHandler())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("removes the dynamic attachments of all the pods", func() {
+		response, err := http.Post(server.URL+DrainAttachmentsEndpoint, "application/json", http.NoBody)
+		Expect(err).NotTo(HaveOccurred())
+		defer response.Body.Close()
+		Expect(response.StatusCode).To(Equal(http.StatusAccepted))
+		var drained DrainedAttachments
+		Expect(json.NewDecoder(response.Body).Decode(&drained)).To(Succeed())
+		Expect(drained.Pods).To(Equal([]string{
+			annotations.NamespacedName(namespace, "pod-a"),
+			annotations.NamespacedName(namespace, "pod-b"),
+		}))
+
+		Expect(podController.workqueue.Len()).To(Equal(2))
+		request := podController.pendingRequests.peek(annotations.NamespacedName(namespace, "pod-a"))
+		Expect(request.Type).To(Equal(remove))
+		Expect(request.AttachmentNames).To(ConsistOf(dynamicAttachment("net1")))
+		request = podController.pendingRequests.peek(annotations.NamespacedName(namespace, "pod-b"))
+		Expect(request.Type).To(Equal(remove))
+		Expect(request.AttachmentNames).To(ConsistOf(dynamicAttachment("net1"), dynamicAttachment("net2")))
+		Expect(podController.pendingRequests.has(annotations.NamespacedName(namespace, "pod-c"))).To(BeFalse())
+	})
+
+	It("stops adding attachments", func() {
+		podController.DrainAttachments()
+		queuedRequests := podController.workqueue.Len()
+
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:         "pod-c",
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{dynamicAttachment("net3")},
+			Type:            add,
+		})
+
+		Expect(podController.workqueue.Len()).To(Equal(queuedRequests))
+		Expect(podController.pendingRequests.has(annotations.NamespacedName(namespace, "pod-c"))).To(BeFalse())
+	})
+})
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+)
+
+// dynamicInterfaces returns the names of the interfaces the controller added
+// to the pod, in the order they were added, as recorded in the dynamic
+// interfaces annotation. The interfaces multus added when the pod was created
+// are not listed: the controller leaves them alone.
+func (pnc *PodNetworksController) dynamicInterfaces(pod *corev1.Pod) []string {
+	recordedIfaces, wasFound := pod.Annotations[pnc.bookkeepingKeys.DynamicInterfaces]
+	if !wasFound {
+		return nil
+	}
+	var ifaceNames []string
+	if err := json.Unmarshal([]byte(recordedIfaces), &ifaceNames); err != nil {
+		klog.ErrorS(err, "ignoring the malformed dynamic interfaces", "pod", klog.KObj(pod))
+		return nil
+	}
+	return ifaceNames
+}
+
+// dynamicAttachments returns the entries of the network-status the controller
+// added, in the order they were added.
+func (pnc *PodNetworksController) dynamicAttachments(
+	pod *corev1.Pod,
+	currentNetworks []nadv1.NetworkStatus,
+) []*nadv1.NetworkSelectionElement {
+	var attachments []*nadv1.NetworkSelectionElement
+	for _, ifaceName := range pnc.dynamicInterfaces(pod) {
+		for i := range currentNetworks {
+			if currentNetworks[i].Default || currentNetworks[i].Interface != ifaceName {
+				continue
+			}
+			if attachment := networkStatusSelectionElement(currentNetworks[i]); attachment != nil {
+				attachments = append(attachments, attachment)
+			}
+			break
+		}
+	}
+	return attachments
+}
+
+// isDynamicInterface indicates if the controller added the interface.
+func (pnc *PodNetworksController) isDynamicInterface(pod *corev1.Pod, ifaceName string) bool {
+	for _, dynamicIface := range pnc.dynamicInterfaces(pod) {
+		if dynamicIface == ifaceName {
+			return true
+		}
+	}
+	return false
+}
+
+// updatedDynamicInterfaces computes the dynamic interfaces recorded along the
+// pod's updated network-status: the added networks' interfaces are appended -
+// moving them last when re-added - and the interfaces missing from the
+// network-status dropped.
+func updatedDynamicInterfaces(
+	recordedIfaces []string,
+	networkStatus string,
+	addedNetworks []*nadv1.NetworkSelectionElement,
+) (string, error) {
+	var currentNetworks []nadv1.NetworkStatus
+	if err := json.Unmarshal([]byte(networkStatus), &currentNetworks); err != nil {
+		return "", fmt.Errorf("failed to unmarshal the network-status: %v", err)
+	}
+	listedIfaces := map[string]bool{}
+	for i := range currentNetworks {
+		listedIfaces[currentNetworks[i].Interface] = true
+	}
+	addedIfaces := map[string]bool{}
+	for _, addedNetwork := range addedNetworks {
+		addedIfaces[addedNetwork.InterfaceRequest] = true
+	}
+
+	ifaceNames := []string{}
+	for _, ifaceName := range recordedIfaces {
+		if listedIfaces[ifaceName] && !addedIfaces[ifaceName] {
+			ifaceNames = append(ifaceNames, ifaceName)
+		}
+	}
+	for _, addedNetwork := range addedNetworks {
+		if listedIfaces[addedNetwork.InterfaceRequest] {
+			ifaceNames = append(ifaceNames, addedNetwork.InterfaceRequest)
+		}
+	}
+	dynamicIfaces, err := json.Marshal(ifaceNames)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal the dynamic interfaces: %v", err)
+	}
+	return string(dynamicIfaces), nil
+}
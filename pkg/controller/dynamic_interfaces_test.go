@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1coreinformerfactory "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	nad "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	fakenadclient "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/client/clientset/versioned/fake"
+	nadinformers "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/client/informers/externalversions"
+
+	fakecri "github.com/maiqueb/multus-dynamic-networks-controller/pkg/cri/fake"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni"
+	fakemultusclient "github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni/fake"
+)
+
+var _ = Describe("The dynamic interfaces annotation", func() {
+	It("records the added interfaces, in the order they were added", func() {
+		pod := podSpec(podName, namespace, networkName)
+		pod.Annotations[defaultBookkeepingKeys.DynamicInterfaces] = `["net0"]`
+		var addInterfaceConfigs []fakemultusclient.NetworkConfig
+		for _, ifaceName := range []string{"net2", "net1"} {
+			addInterfaceConfig := networkConfig(multuscni.CmdAdd, ifaceName, ifaceName, macAddr)
+			addInterfaceConfig.Response.Result.Interfaces[0].Sandbox = netnsPath
+			addInterfaceConfigs = append(addInterfaceConfigs, addInterfaceConfig)
+		}
+		podController := newSynchedPodController(
+			pod,
+			fakemultusclient.NewFakeClient(addInterfaceConfigs...),
+			tinyNetAttachDef())
+		podController.recorder = record.NewFakeRecorder(5)
+
+		Expect(podController.handleDynamicInterfaceRequest(context.Background(), &DynamicAttachmentRequest{
+			PodName:      podName,
+			PodNamespace: namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{
+				{Name: networkName, Namespace: namespace, InterfaceRequest: "net2"},
+				{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"},
+			},
+			Type:     add,
+			PodNetNS: netnsPath,
+		})).To(Succeed())
+
+		updatedPod, err := podController.k8sClientSet.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updatedPod.Annotations).To(HaveKeyWithValue(defaultBookkeepingKeys.DynamicInterfaces, `["net0","net2","net1"]`))
+	})
+
+	It("is recorded under the custom bookkeeping prefix", func() {
+		const prefix = "networks.example.com"
+		pod := podSpec(podName, namespace)
+		addInterfaceConfig := networkConfig(multuscni.CmdAdd, "net1", "net1", macAddr)
+		addInterfaceConfig.Response.Result.Interfaces[0].Sandbox = netnsPath
+		podController := newUnstartedPodController(
+			fakecri.NewFakeRuntime(*pod),
+			fakemultusclient.NewFakeClient(addInterfaceConfig),
+			WithBookkeepingAnnotationPrefix(prefix))
+		netAttachDefinition := tinyNetAttachDef()
+		Expect(podController.netAttachDefInformer.GetStore().Add(&netAttachDefinition)).To(Succeed())
+		Expect(podController.podsInformer.GetStore().Add(pod)).To(Succeed())
+		_, err := podController.k8sClientSet.CoreV1().Pods(namespace).Create(context.TODO(), pod, metav1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(podController.handleDynamicInterfaceRequest(context.Background(), &DynamicAttachmentRequest{
+			PodName:         podName,
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"}},
+			Type:            add,
+			PodNetNS:        netnsPath,
+		})).To(Succeed())
+
+		updatedPod, err := podController.k8sClientSet.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updatedPod.Annotations).To(HaveKeyWithValue(prefix+"/dynamic-interfaces", `["net1"]`))
+		Expect(updatedPod.Annotations).To(HaveKeyWithValue(prefix+"/sandbox", netnsPath))
+		Expect(updatedPod.Annotations).NotTo(HaveKey(defaultBookkeepingKeys.DynamicInterfaces))
+		Expect(updatedPod.Annotations).NotTo(HaveKey(defaultBookkeepingKeys.Sandbox))
+		Expect(podController.dynamicInterfaces(updatedPod)).To(Equal([]string{"net1"}))
+	})
+
+	It("cannot be recorded under a prefix which is not a DNS subdomain", func() {
+		_, err := NewPodNetworksController(
+			v1coreinformerfactory.NewSharedInformerFactory(fake.NewSimpleClientset(), 0),
+			nadinformers.NewSharedInformerFactory(fakenadclient.NewSimpleClientset(), 0),
+			nil,
+			nil,
+			fake.NewSimpleClientset(),
+			fakenadclient.NewSimpleClientset(),
+			fakecri.NewFakeRuntime(),
+			fakemultusclient.NewFakeClient(),
+			WithBookkeepingAnnotationPrefix("example.com/networks"))
+		Expect(err).To(MatchError(ContainSubstring("invalid annotation prefix")))
+	})
+
+	It("does not list the interfaces multus added when the pod was created", func() {
+		pod := podSpec(podName, namespace, networkName)
+		podController := newSynchedPodController(pod, fakemultusclient.NewFakeClient())
+
+		currentNetworks, err := networkStatus(pod.Annotations)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(currentNetworks).NotTo(BeEmpty())
+		Expect(podController.dynamicAttachments(pod, currentNetworks)).To(BeEmpty())
+		Expect(podController.isDynamicInterface(pod, "net0")).To(BeFalse())
+	})
+})
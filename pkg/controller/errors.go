@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrCachesNotSynced reports the caches did not synchronize within the cache
+// sync timeout; see WithCacheSyncTimeout.
+var ErrCachesNotSynced = errors.New("timed out waiting for the caches to sync")
+
+var (
+	errNoRunningContainers  = errors.New("the pod does not feature any running container")
+	errNetAttachDefNotFound = errors.New("the network-attachment-definition does not exist")
+	errNoDelegateResult     = errors.New("the delegate did not reply with a result")
+
+	errRemovedIfaceStatusNotUpdated = errors.New("failed to drop the removed interface from the network-status")
+
+	errInvalidNetAttachDefConfig = errors.New("invalid network-attachment-definition")
+
+	errCrossNamespaceReferenceDenied = errors.New("the network-attachment-definition's namespace does not allow references from other namespaces")
+)
+
+// terminalError wraps the failures retrying the request cannot fix - e.g. a
+// malformed network configuration, or attachment; requests failing with them
+// are dropped right away.
+type terminalError struct {
+	err error
+}
+
+func newTerminalError(err error) error {
+	return &terminalError{err: err}
+}
+
+func (te *terminalError) Error() string {
+	return te.err.Error()
+}
+
+func (te *terminalError) Unwrap() error {
+	return te.err
+}
+
+// isTerminal indicates if retrying the request failing with `err` is
+// pointless; the other failures - e.g. API conflicts, or delegate invocation
+// errors - are deemed transient.
+func isTerminal(err error) bool {
+	var terminalErr *terminalError
+	return errors.As(err, &terminalErr) || errors.Is(err, errNetAttachDefNotFound)
+}
+
+// isRemoval indicates if the request removes attachments: since the removals
+// release the resources the attachments hold, they are always retried.
+func isRemoval(dynamicAttachmentRequest *DynamicAttachmentRequest) bool {
+	return dynamicAttachmentRequest.Type == remove || dynamicAttachmentRequest.Type == finalize
+}
+
+// interfaceNotFoundErrors are the messages the delegates fail removing an
+// interface that no longer exists with - e.g. netlink's, or the kernel's.
+var interfaceNotFoundErrors = []string{
+	"link not found",
+	"interface not found",
+	"no such device",
+}
+
+// isInterfaceNotFound indicates if the delegate failed removing an interface
+// because it was already gone - e.g. removed manually, or by a crashed
+// delegate; the removal has nothing left to do.
+func isInterfaceNotFound(err error) bool {
+	message := strings.ToLower(err.Error())
+	for _, notFoundError := range interfaceNotFoundErrors {
+		if strings.Contains(message, notFoundError) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// eventDeduplicator drops the events identical - same object, type, reason,
+// and message - to one emitted within the window; thus a flapping networks
+// annotation, or a request failing over and over, cannot flood the events API.
+type eventDeduplicator struct {
+	lock        sync.Mutex
+	lastEmitted map[string]time.Time
+}
+
+func newEventDeduplicator() *eventDeduplicator {
+	return &eventDeduplicator{lastEmitted: map[string]time.Time{}}
+}
+
+// shouldEmit indicates if the event - emitted `now` - is to be emitted,
+// recording its emission; the events emitted before the window are forgotten
+// along the way.
+func (ed *eventDeduplicator) shouldEmit(
+	object runtime.Object,
+	eventtype, reason, message string,
+	now time.Time,
+	window time.Duration,
+) bool {
+	if window <= 0 {
+		return true
+	}
+	key := eventKey(object, eventtype, reason, message)
+
+	ed.lock.Lock()
+	defer ed.lock.Unlock()
+
+	for emittedKey, emittedAt := range ed.lastEmitted {
+		if now.Sub(emittedAt) >= window {
+			delete(ed.lastEmitted, emittedKey)
+		}
+	}
+	if _, wasEmitted := ed.lastEmitted[key]; wasEmitted {
+		return false
+	}
+	ed.lastEmitted[key] = now
+	return true
+}
+
+func eventKey(object runtime.Object, eventtype, reason, message string) string {
+	objectKey := fmt.Sprintf("%p", object)
+	if accessor, err := meta.Accessor(object); err == nil {
+		objectKey = fmt.Sprintf("%s/%s/%s", accessor.GetNamespace(), accessor.GetName(), accessor.GetUID())
+	}
+	return fmt.Sprintf("%s:%s:%s:%s", objectKey, eventtype, reason, message)
+}
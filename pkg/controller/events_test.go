@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	fakemultusclient "github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni/fake"
+)
+
+var _ = Describe("The identical events", func() {
+	const (
+		maxEvents = 10
+		window    = time.Minute
+	)
+
+	var (
+		eventRecorder *record.FakeRecorder
+		fakeClock     *clocktesting.FakeClock
+		pod           *corev1.Pod
+		podController *PodNetworksController
+	)
+
+	BeforeEach(func() {
+		eventRecorder = record.NewFakeRecorder(maxEvents)
+		fakeClock = clocktesting.NewFakeClock(time.Now())
+		pod = podSpec(podName, namespace)
+		podController = newUnstartedPodController(
+			nil,
+			fakemultusclient.NewFakeClient(),
+			WithClock(fakeClock),
+			WithEventDeduplicationWindow(window))
+		podController.recorder = eventRecorder
+	})
+
+	emit := func(message string) {
+		podController.Eventf(pod, corev1.EventTypeWarning, "InterfaceAddFailed", "failed to add: %s", message)
+	}
+
+	It("emitted within the window are recorded once", func() {
+		for i := 0; i < 5; i++ {
+			emit("boom")
+		}
+
+		Expect(eventRecorder.Events).To(HaveLen(1))
+		Expect(eventRecorder.Events).To(Receive(Equal("Warning InterfaceAddFailed failed to add: boom")))
+	})
+
+	It("are recorded again once the window elapsed", func() {
+		emit("boom")
+		fakeClock.Step(window)
+		emit("boom")
+
+		Expect(eventRecorder.Events).To(HaveLen(2))
+	})
+
+	It("do not hide the events carrying a different message", func() {
+		emit("boom")
+		emit("bang")
+
+		Expect(eventRecorder.Events).To(HaveLen(2))
+	})
+
+	It("do not hide the events of a different object", func() {
+		emit("boom")
+		pod = podSpec("another-pod", namespace)
+		emit("boom")
+
+		Expect(eventRecorder.Events).To(HaveLen(2))
+	})
+
+	It("are all recorded when the window is zero", func() {
+		WithEventDeduplicationWindow(0)(podController)
+		for i := 0; i < 5; i++ {
+			emit("boom")
+		}
+
+		Expect(eventRecorder.Events).To(HaveLen(5))
+	})
+})
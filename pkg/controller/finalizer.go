@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+// podFinalizer holds the deletion of the pods with dynamic attachments until
+// the attachments are removed, thus allowing the CNI plugins to release the
+// resources - e.g. external IPAM leases - they allocated for them, even if the
+// pod is force deleted.
+const podFinalizer = "dynamic-networks-controller.k8s.cni.cncf.io/cleanup"
+
+func hasPodFinalizer(pod *corev1.Pod) bool {
+	for _, finalizer := range pod.GetFinalizers() {
+		if finalizer == podFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// ensurePodFinalizer adds the finalizer to the pod, unless it is disabled.
+func (pnc *PodNetworksController) ensurePodFinalizer(ctx context.Context, pod *corev1.Pod) error {
+	if !pnc.usePodFinalizer || pnc.dryRun || hasPodFinalizer(pod) {
+		return nil
+	}
+	klog.FromContext(ctx).Info("adding the pod finalizer")
+	return pnc.updatePodFinalizers(ctx, pod, func(finalizers []string) []string {
+		return append(finalizers, podFinalizer)
+	})
+}
+
+// finalizePod removes the attachments the controller added to the terminating
+// pod - leaving the ones multus added when the pod was created to the runtime
+// -, then its finalizer - thus letting the pod go.
+func (pnc *PodNetworksController) finalizePod(
+	ctx context.Context,
+	dynamicAttachmentRequest *DynamicAttachmentRequest,
+	pod *corev1.Pod,
+) error {
+	logger := klog.FromContext(ctx)
+	if !hasPodFinalizer(pod) {
+		logger.Info("the pod is already finalized")
+		return nil
+	}
+
+	if currentNetworks, err := networkStatus(pod.Annotations); err == nil {
+		toRemove := pnc.dynamicAttachments(pod, currentNetworks)
+		logger.Info("computed the attachments to remove from the terminating pod", "attachments", len(toRemove))
+		netnsPath, err := pnc.netnsPath(pod)
+		if err != nil {
+			logger.Info("removing the attachments of the terminating pod without its network namespace", "reason", err)
+		}
+		removalRequest := &DynamicAttachmentRequest{
+			ID:              dynamicAttachmentRequest.ID,
+			PodName:         dynamicAttachmentRequest.PodName,
+			PodNamespace:    dynamicAttachmentRequest.PodNamespace,
+			AttachmentNames: toRemove,
+			Type:            remove,
+			PodNetNS:        netnsPath,
+		}
+		if err := pnc.removeNetworks(ctx, removalRequest, pod); err != nil {
+			return err
+		}
+	}
+
+	return pnc.removePodFinalizer(ctx, pod)
+}
+
+// abandonPodFinalization removes the finalizer of the pod whose finalization
+// failed for good: leaking its remaining attachments beats keeping the pod
+// Terminating forever.
+func (pnc *PodNetworksController) abandonPodFinalization(ctx context.Context, dynamicAttachmentRequest *DynamicAttachmentRequest) {
+	logger := klog.FromContext(ctx)
+	pod, err := pnc.podsLister.Pods(dynamicAttachmentRequest.PodNamespace).Get(dynamicAttachmentRequest.PodName)
+	if err != nil || !hasPodFinalizer(pod) {
+		return
+	}
+	logger.Info("abandoning the pod finalization: its remaining attachments may leak")
+	if err := pnc.removePodFinalizer(ctx, pod.DeepCopy()); err != nil {
+		logger.Error(err, "failed to abandon the pod finalization")
+	}
+}
+
+func (pnc *PodNetworksController) removePodFinalizer(ctx context.Context, pod *corev1.Pod) error {
+	klog.FromContext(ctx).Info("removing the pod finalizer")
+	return pnc.updatePodFinalizers(ctx, pod, func(finalizers []string) []string {
+		var remainingFinalizers []string
+		for _, finalizer := range finalizers {
+			if finalizer != podFinalizer {
+				remainingFinalizers = append(remainingFinalizers, finalizer)
+			}
+		}
+		return remainingFinalizers
+	})
+}
+
+// updatePodFinalizers writes the finalizers computed by `update`, conditioned
+// on the pod's resource version; when the write conflicts with a concurrent pod
+// update, the pod is read again and its finalizers re-computed. The pod is
+// updated with the written finalizers, and its new resource version.
+func (pnc *PodNetworksController) updatePodFinalizers(
+	ctx context.Context,
+	pod *corev1.Pod,
+	update func(finalizers []string) []string,
+) error {
+	currentPod := pod
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		metadata := map[string]interface{}{"finalizers": update(currentPod.GetFinalizers())}
+		if resourceVersion := currentPod.GetResourceVersion(); resourceVersion != "" {
+			metadata["resourceVersion"] = resourceVersion
+		}
+		patch, err := json.Marshal(map[string]interface{}{"metadata": metadata})
+		if err != nil {
+			return err
+		}
+		updatedPod, err := pnc.k8sClientSet.CoreV1().Pods(pod.GetNamespace()).Patch(
+			ctx,
+			pod.GetName(),
+			types.MergePatchType,
+			patch,
+			metav1.PatchOptions{})
+		if apierrors.IsConflict(err) {
+			freshPod, getErr := pnc.k8sClientSet.CoreV1().Pods(pod.GetNamespace()).Get(ctx, pod.GetName(), metav1.GetOptions{})
+			if getErr != nil {
+				return getErr
+			}
+			currentPod = freshPod
+		}
+		if err != nil {
+			return err
+		}
+		pod.SetFinalizers(updatedPod.GetFinalizers())
+		pod.SetResourceVersion(updatedPod.GetResourceVersion())
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update the finalizers of pod %s: %v", pod.GetName(), err)
+	}
+	return nil
+}
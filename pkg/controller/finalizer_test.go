@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	nad "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni"
+	fakemultusclient "github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni/fake"
+)
+
+var _ = Describe("The pod finalizer", func() {
+	addRequest := func() *DynamicAttachmentRequest {
+		return &DynamicAttachmentRequest{
+			PodName:         podName,
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"}},
+			Type:            add,
+			PodNetNS:        netnsPath,
+		}
+	}
+
+	currentFinalizers := func(podController *PodNetworksController) []string {
+		pod, err := podController.k8sClientSet.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		return pod.GetFinalizers()
+	}
+
+	It("is added to the pods the dynamic attachments are added to, when enabled", func() {
+		podController := newSynchedPodController(
+			podSpec(podName, namespace),
+			fakemultusclient.NewFakeClient(networkConfig(multuscni.CmdAdd, "net1", networkName, macAddr)),
+			tinyNetAttachDef())
+		podController.recorder = record.NewFakeRecorder(5)
+		WithPodFinalizer(true)(podController)
+
+		Expect(podController.handleDynamicInterfaceRequest(context.Background(), addRequest())).To(Succeed())
+		Expect(currentFinalizers(podController)).To(ConsistOf(podFinalizer))
+	})
+
+	It("is not added by default", func() {
+		podController := newSynchedPodController(
+			podSpec(podName, namespace),
+			fakemultusclient.NewFakeClient(networkConfig(multuscni.CmdAdd, "net1", networkName, macAddr)),
+			tinyNetAttachDef())
+		podController.recorder = record.NewFakeRecorder(5)
+
+		Expect(podController.handleDynamicInterfaceRequest(context.Background(), addRequest())).To(Succeed())
+		Expect(currentFinalizers(podController)).To(BeEmpty())
+	})
+
+	It("is removed once the attachments the controller added to the terminating pod are removed", func() {
+		// net0 was added by multus when the pod was created, net1 by the controller
+		pod := podSpec(podName, namespace, networkName, networkName)
+		pod.Annotations[defaultBookkeepingKeys.DynamicInterfaces] = `["net1"]`
+		pod.Finalizers = []string{"example.com/other", podFinalizer}
+		multusClient := fakemultusclient.NewFakeClient(networkConfig(multuscni.CmdDel, "net1", "", ""))
+		podController := newSynchedPodController(
+			pod,
+			multusClient,
+			tinyNetAttachDef())
+		podController.recorder = record.NewFakeRecorder(5)
+
+		terminatingPod := pod.DeepCopy()
+		terminatingPod.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+		Expect(podController.podsInformer.GetStore().Update(terminatingPod)).To(Succeed())
+		podController.handlePodUpdate(pod, terminatingPod)
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		Expect(multusClient.Requests()).To(HaveLen(1))
+		Expect(multusClient.Requests()[0].Env).To(HaveKeyWithValue("CNI_COMMAND", multuscni.CmdDel))
+		Expect(multusClient.Requests()[0].Env).To(HaveKeyWithValue("CNI_IFNAME", "net1"))
+		Expect(currentFinalizers(podController)).To(ConsistOf("example.com/other"))
+	})
+
+	It("is removed once the removal of the terminating pod's attachments is dropped", func() {
+		pod := podSpec(podName, namespace, networkName)
+		pod.Annotations[defaultBookkeepingKeys.DynamicInterfaces] = `["net0"]`
+		pod.Finalizers = []string{podFinalizer}
+		// the delegate fails to remove net0
+		multusClient := fakemultusclient.NewFakeClient()
+		podController := newSynchedPodController(
+			pod,
+			multusClient,
+			tinyNetAttachDef())
+		podController.recorder = record.NewFakeRecorder(5)
+		WithMaxRetries(0)(podController)
+
+		terminatingPod := pod.DeepCopy()
+		terminatingPod.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+		Expect(podController.podsInformer.GetStore().Update(terminatingPod)).To(Succeed())
+		podController.handlePodUpdate(pod, terminatingPod)
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		Expect(multusClient.Requests()).To(HaveLen(1))
+		Expect(currentFinalizers(podController)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+)
+
+// InterfacePhase is the stage of a dynamic interface's lifecycle
+type InterfacePhase string
+
+const (
+	InterfaceAttaching InterfacePhase = "Attaching"
+	InterfaceAttached  InterfacePhase = "Attached"
+	InterfaceDetaching InterfacePhase = "Detaching"
+	InterfaceFailed    InterfacePhase = "Failed"
+)
+
+// InterfaceState is the state of a dynamic interface; the reason explains the
+// failed ones. The removed interfaces have no state.
+type InterfaceState struct {
+	Network            string         `json:"network"`
+	Phase              InterfacePhase `json:"phase"`
+	Reason             string         `json:"reason,omitempty"`
+	LastTransitionTime metav1.Time    `json:"lastTransitionTime"`
+}
+
+// interfaceStates returns the states of the pod's dynamic interfaces, indexed
+// by interface name; see annotations.BookkeepingKeys.InterfaceStates.
+func (pnc *PodNetworksController) interfaceStates(pod *corev1.Pod) map[string]InterfaceState {
+	states := map[string]InterfaceState{}
+	recordedStates, wasFound := pod.Annotations[pnc.bookkeepingKeys.InterfaceStates]
+	if !wasFound {
+		return states
+	}
+	if err := json.Unmarshal([]byte(recordedStates), &states); err != nil {
+		klog.ErrorS(err, "ignoring the malformed interface states", "pod", klog.KObj(pod))
+		return map[string]InterfaceState{}
+	}
+	return states
+}
+
+// recordInterfaceState records the phase of the attachment's interface; see
+// recordInterfacesState.
+func (pnc *PodNetworksController) recordInterfaceState(
+	ctx context.Context,
+	pod *corev1.Pod,
+	network *nadv1.NetworkSelectionElement,
+	phase InterfacePhase,
+	reason string,
+) {
+	pnc.recordInterfacesState(ctx, pod, []*nadv1.NetworkSelectionElement{network}, phase, reason)
+}
+
+// recordInterfacesState records the phase of the attachments' interfaces in
+// the pod's interface states; an empty phase drops their state. Being
+// bookkeeping, failing to record the states is logged, not returned. The
+// write is conditioned on the pod's resource version, the provided pod
+// adopting the resulting one - unless the pod changed in the meantime, thus
+// having the following network-status write notice the change.
+func (pnc *PodNetworksController) recordInterfacesState(
+	ctx context.Context,
+	pod *corev1.Pod,
+	networks []*nadv1.NetworkSelectionElement,
+	phase InterfacePhase,
+	reason string,
+) {
+	if !pnc.recordInterfaceStates || pnc.dryRun || len(networks) == 0 {
+		return
+	}
+
+	logger := klog.FromContext(ctx)
+	currentPod := pod
+	var recordedStates, resourceVersion string
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		states := pnc.interfaceStates(currentPod)
+		now := metav1.NewTime(pnc.clock.Now())
+		for _, network := range networks {
+			if phase == "" {
+				delete(states, network.InterfaceRequest)
+				continue
+			}
+			states[network.InterfaceRequest] = InterfaceState{
+				Network:            annotations.NamespacedName(network.Namespace, network.Name),
+				Phase:              phase,
+				Reason:             reason,
+				LastTransitionTime: now,
+			}
+		}
+		serializedStates, err := json.Marshal(states)
+		if err != nil {
+			return fmt.Errorf("failed to marshal the interface states: %v", err)
+		}
+		recordedStates = string(serializedStates)
+		resourceVersion = currentPod.GetResourceVersion()
+		patch, err := podAnnotationsPatch(
+			currentPod.GetResourceVersion(),
+			currentPod.Annotations,
+			map[string]string{pnc.bookkeepingKeys.InterfaceStates: recordedStates})
+		if err != nil {
+			return fmt.Errorf("failed to compute the interface states patch: %v", err)
+		}
+		if patch == nil {
+			return nil
+		}
+
+		updatedPod, err := pnc.k8sClientSet.CoreV1().Pods(pod.GetNamespace()).Patch(
+			ctx,
+			pod.GetName(),
+			types.MergePatchType,
+			patch,
+			metav1.PatchOptions{})
+		if apierrors.IsConflict(err) {
+			freshPod, getErr := pnc.k8sClientSet.CoreV1().Pods(pod.GetNamespace()).Get(ctx, pod.GetName(), metav1.GetOptions{})
+			if getErr != nil {
+				return getErr
+			}
+			currentPod = freshPod
+		}
+		if err != nil {
+			return err
+		}
+		resourceVersion = updatedPod.GetResourceVersion()
+		return nil
+	})
+	if err != nil {
+		logger.Error(err, "failed to record the interface states", "phase", phase)
+		return
+	}
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	pod.Annotations[pnc.bookkeepingKeys.InterfaceStates] = recordedStates
+	if currentPod == pod {
+		pod.SetResourceVersion(resourceVersion)
+	}
+}
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	nad "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni"
+	fakemultusclient "github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni/fake"
+)
+
+var _ = Describe("The interface states", func() {
+	const ifaceName = "net1"
+
+	var recordedStates []*InterfaceState
+
+	// newController returns a controller recording the interface states, the
+	// states of `ifaceName` it writes being appended to `recordedStates`; a nil
+	// state stands for the interface's state being dropped.
+	newController := func(pod *corev1.Pod, multusClient *fakemultusclient.Client) *PodNetworksController {
+		recordedStates = nil
+		podController := newSynchedPodController(pod, multusClient, tinyNetAttachDef())
+		WithInterfaceStates(true)(podController)
+		podController.k8sClientSet.(*fake.Clientset).PrependReactor(
+			"patch",
+			"pods",
+			func(action k8stesting.Action) (bool, runtime.Object, error) {
+				var patch struct {
+					Metadata struct {
+						Annotations map[string]string `json:"annotations"`
+					} `json:"metadata"`
+				}
+				Expect(json.Unmarshal(action.(k8stesting.PatchAction).GetPatch(), &patch)).To(Succeed())
+				serializedStates, wasFound := patch.Metadata.Annotations[defaultBookkeepingKeys.InterfaceStates]
+				if !wasFound {
+					return false, nil, nil
+				}
+				var states map[string]*InterfaceState
+				Expect(json.Unmarshal([]byte(serializedStates), &states)).To(Succeed())
+				recordedStates = append(recordedStates, states[ifaceName])
+				return false, nil, nil
+			})
+		return podController
+	}
+
+	request := func(requestType DynamicAttachmentRequestType) *DynamicAttachmentRequest {
+		return &DynamicAttachmentRequest{
+			PodName:         podName,
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{{Name: networkName, Namespace: namespace, InterfaceRequest: ifaceName}},
+			Type:            requestType,
+			PodNetNS:        netnsPath,
+		}
+	}
+
+	phases := func() []InterfacePhase {
+		var recordedPhases []InterfacePhase
+		for _, state := range recordedStates {
+			if state == nil {
+				recordedPhases = append(recordedPhases, "")
+				continue
+			}
+			Expect(state.Network).To(Equal(annotations.NamespacedName(namespace, networkName)))
+			recordedPhases = append(recordedPhases, state.Phase)
+		}
+		return recordedPhases
+	}
+
+	It("progress from attaching to attached when the interface is added", func() {
+		podController := newController(
+			podSpec(podName, namespace),
+			fakemultusclient.NewFakeClient(networkConfig(multuscni.CmdAdd, ifaceName, ifaceName, macAddr)))
+
+		podController.enqueue(request(add))
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		Expect(phases()).To(Equal([]InterfacePhase{InterfaceAttaching, InterfaceAttached}))
+	})
+
+	It("progress from attaching to failed - along with the reason - when adding the interface fails", func() {
+		podController := newController(podSpec(podName, namespace), fakemultusclient.NewFakeClient())
+
+		podController.enqueue(request(add))
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		Expect(phases()).To(Equal([]InterfacePhase{InterfaceAttaching, InterfaceFailed}))
+		Expect(recordedStates[1].Reason).To(ContainSubstring("failed to ADD delegate"))
+	})
+
+	It("progress from detaching to none when the interface is removed", func() {
+		pod := podSpec(podName, namespace)
+		pod.Annotations[nad.NetworkStatusAnnot] = `[{"name":"default/tiny-net","interface":"net1"}]`
+		podController := newController(
+			pod,
+			fakemultusclient.NewFakeClient(networkConfig(multuscni.CmdDel, ifaceName, "", "")))
+
+		podController.enqueue(request(remove))
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		Expect(phases()).To(Equal([]InterfacePhase{InterfaceDetaching, ""}))
+	})
+
+	It("are not recorded unless enabled", func() {
+		podController := newController(
+			podSpec(podName, namespace),
+			fakemultusclient.NewFakeClient(networkConfig(multuscni.CmdAdd, ifaceName, ifaceName, macAddr)))
+		WithInterfaceStates(false)(podController)
+
+		podController.enqueue(request(add))
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		Expect(recordedStates).To(BeEmpty())
+	})
+})
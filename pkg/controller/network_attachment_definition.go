@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/logging"
+)
+
+// handleNetAttachDefAdd reconciles the pods requesting the created network:
+// their attachments to it were skipped while it was missing, and - the
+// informers not resyncing - nothing else would add them.
+func (pnc *PodNetworksController) handleNetAttachDefAdd(obj interface{}) {
+	netAttachDef := obj.(*nadv1.NetworkAttachmentDefinition)
+	netName := annotations.NamespacedName(netAttachDef.GetNamespace(), netAttachDef.GetName())
+	klog.V(logging.Debug).InfoS("network-attachment-definition added", "nad", netName)
+
+	requestingPods, err := pnc.podsRequesting(netAttachDef.GetNamespace(), netAttachDef.GetName())
+	if err != nil {
+		klog.ErrorS(err, "failed to compute the pods requesting the network", "nad", netName)
+		return
+	}
+	for _, pod := range requestingPods {
+		pnc.reconcilePod(pod)
+	}
+}
+
+// podsRequesting returns the pods whose networks annotation requests the network.
+func (pnc *PodNetworksController) podsRequesting(namespace string, name string) ([]*corev1.Pod, error) {
+	pods, err := pnc.podsLister.List(pnc.podSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	var requestingPods []*corev1.Pod
+	for _, pod := range pods {
+		desiredNetworks, err := networkSelectionElements(pod.Annotations, pod.GetNamespace())
+		if err != nil {
+			continue
+		}
+		for _, desiredNetwork := range desiredNetworks {
+			if desiredNetwork.Namespace == namespace && desiredNetwork.Name == name {
+				requestingPods = append(requestingPods, pod)
+				break
+			}
+		}
+	}
+	return requestingPods, nil
+}
+
+// handleNetAttachDefUpdate reports the pods attached to a network whose
+// configuration changed; when re-attaching is enabled, their interfaces on that
+// network are removed, then added back using the updated configuration. Once a
+// misconfigured network is fixed, the pods requesting it are reconciled.
+func (pnc *PodNetworksController) handleNetAttachDefUpdate(oldObj interface{}, newObj interface{}) {
+	oldNetAttachDef := oldObj.(*nadv1.NetworkAttachmentDefinition)
+	newNetAttachDef := newObj.(*nadv1.NetworkAttachmentDefinition)
+
+	if reflect.DeepEqual(oldNetAttachDef.Spec, newNetAttachDef.Spec) {
+		return
+	}
+	netName := annotations.NamespacedName(newNetAttachDef.GetNamespace(), newNetAttachDef.GetName())
+	klog.V(logging.Debug).InfoS("network-attachment-definition updated", "nad", netName)
+	if validateNetAttachDefConfig(oldNetAttachDef) != nil && validateNetAttachDefConfig(newNetAttachDef) == nil {
+		// the attachments to the network were skipped while it was misconfigured
+		pnc.handleNetAttachDefAdd(newNetAttachDef)
+		return
+	}
+
+	affectedPods, err := pnc.podsAttachedTo(netName)
+	if err != nil {
+		klog.ErrorS(err, "failed to compute the pods attached to the network", "nad", netName)
+		return
+	}
+	for _, pod := range affectedPods {
+		if !pnc.reattachOnNetAttachDefUpdate {
+			klog.InfoS("pod attached to the updated network: its interfaces keep the previous configuration", "pod", pod.GetName(), "namespace", pod.GetNamespace(), "nad", netName)
+			continue
+		}
+		klog.InfoS("re-attaching pod to the updated network", "pod", pod.GetName(), "namespace", pod.GetNamespace(), "nad", netName)
+		pnc.reattachPod(pod, netName)
+	}
+}
+
+// podsAttachedTo returns the pods featuring an interface attached to the network.
+func (pnc *PodNetworksController) podsAttachedTo(netName string) ([]*corev1.Pod, error) {
+	pods, err := pnc.podsLister.List(pnc.podSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	var attachedPods []*corev1.Pod
+	for _, pod := range pods {
+		if len(podAttachments(pod, netName)) > 0 {
+			attachedPods = append(attachedPods, pod)
+		}
+	}
+	return attachedPods, nil
+}
+
+// reattachPod removes the pod's interfaces attached to the network, then adds
+// them back, keeping their interface names and requested attributes.
+func (pnc *PodNetworksController) reattachPod(pod *corev1.Pod, netName string) {
+	netnsPath, err := pnc.netnsPath(pod)
+	if err != nil {
+		klog.ErrorS(err, "cannot re-attach pod to the network", "pod", pod.GetName(), "namespace", pod.GetNamespace(), "nad", netName)
+		return
+	}
+	desiredNetworks, err := networkSelectionElements(pod.Annotations, pod.GetNamespace())
+	if err != nil {
+		klog.ErrorS(err, "cannot re-attach pod to the network", "pod", pod.GetName(), "namespace", pod.GetNamespace(), "nad", netName)
+		return
+	}
+
+	attachments := podAttachments(pod, netName)
+	pnc.enqueueReattachments(pod, netnsPath, attachments, desiredNetworks)
+}
+
+// enqueueReattachments enqueues the removal of the pod's attachments, then
+// their addition, keeping their interface names; the attachments are added
+// back requesting the attributes of their network selection element.
+func (pnc *PodNetworksController) enqueueReattachments(
+	pod *corev1.Pod,
+	netnsPath string,
+	attachments []*nadv1.NetworkSelectionElement,
+	desiredNetworks []*nadv1.NetworkSelectionElement,
+) {
+	reattachments := make([]*nadv1.NetworkSelectionElement, 0, len(attachments))
+	for _, attachment := range attachments {
+		reattachment := *attachment
+		for _, desiredNetwork := range desiredNetworks {
+			if desiredNetwork.Namespace == attachment.Namespace && desiredNetwork.Name == attachment.Name &&
+				(desiredNetwork.InterfaceRequest == "" || desiredNetwork.InterfaceRequest == attachment.InterfaceRequest) {
+				reattachment = *desiredNetwork
+				reattachment.InterfaceRequest = attachment.InterfaceRequest
+				break
+			}
+		}
+		reattachments = append(reattachments, &reattachment)
+	}
+
+	pnc.enqueue(
+		&DynamicAttachmentRequest{
+			PodName:         pod.GetName(),
+			PodUID:          pod.GetUID(),
+			PodNamespace:    pod.GetNamespace(),
+			AttachmentNames: attachments,
+			Type:            remove,
+			PodNetNS:        netnsPath,
+		})
+	pnc.enqueue(
+		&DynamicAttachmentRequest{
+			PodName:         pod.GetName(),
+			PodUID:          pod.GetUID(),
+			PodNamespace:    pod.GetNamespace(),
+			AttachmentNames: reattachments,
+			Type:            add,
+			PodNetNS:        netnsPath,
+		})
+}
+
+// podAttachments returns the pod's (non default) interfaces attached to the
+// network, according to its network-status.
+func podAttachments(pod *corev1.Pod, netName string) []*nadv1.NetworkSelectionElement {
+	currentNetworks, err := networkStatus(pod.Annotations)
+	if err != nil {
+		return nil
+	}
+
+	var attachments []*nadv1.NetworkSelectionElement
+	for i := range currentNetworks {
+		if currentNetworks[i].Default || currentNetworks[i].Name != netName {
+			continue
+		}
+		if attachment := networkStatusSelectionElement(currentNetworks[i]); attachment != nil {
+			attachments = append(attachments, attachment)
+		}
+	}
+	return attachments
+}
@@ -0,0 +1,202 @@
+package controller
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	nad "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+	fakecri "github.com/maiqueb/multus-dynamic-networks-controller/pkg/cri/fake"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni"
+	fakemultusclient "github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni/fake"
+)
+
+var _ = Describe("Missing network-attachment-definitions", func() {
+	const missingNetwork = "missing-net"
+
+	It("skip their attachments without retrying, while the request's other attachments are added", func() {
+		const maxEvents = 5
+		pod := podSpec(podName, namespace)
+		multusClient := fakemultusclient.NewFakeClient(networkConfig(multuscni.CmdAdd, "net1", "net1", macAddr))
+		podController := newSynchedPodController(
+			pod,
+			multusClient,
+			tinyNetAttachDef())
+		eventRecorder := record.NewFakeRecorder(maxEvents)
+		podController.recorder = eventRecorder
+
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:      podName,
+			PodNamespace: namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{
+				{Name: missingNetwork, Namespace: namespace, InterfaceRequest: "net0"},
+				{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"},
+			},
+			Type:     add,
+			PodNetNS: netnsPath,
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		podKey := annotations.NamespacedName(namespace, podName)
+		Expect(eventRecorder.Events).To(Receive(Equal(fmt.Sprintf(
+			"Warning NetworkAttachmentDefinitionNotFound pod [%s]: skipped adding interface net0 to network: %s: the network-attachment-definition %s does not exist",
+			podKey,
+			missingNetwork,
+			annotations.NamespacedName(namespace, missingNetwork)))))
+		Expect(eventRecorder.Events).To(Receive(HavePrefix("Normal AddedInterface")))
+		Expect(multusClient.Requests()).To(HaveLen(1))
+		Expect(podController.workqueue.Len()).To(BeZero())
+		Expect(podController.workqueue.NumRequeues(podKey)).To(BeZero())
+	})
+
+	It("are attached to the pods requesting them once created", func() {
+		pod := updatePodSpec(podSpec(podName, namespace, networkName), networkName, missingNetwork)
+		otherPod := podSpec("other-pod", namespace, networkName)
+		podController := newUnstartedPodController(fakecri.NewFakeRuntime(*pod), fakemultusclient.NewFakeClient())
+		Expect(podController.podsInformer.GetStore().Add(pod)).To(Succeed())
+		Expect(podController.podsInformer.GetStore().Add(otherPod)).To(Succeed())
+
+		createdNetAttachDef := netAttachDef(missingNetwork, namespace, dummyNetSpec(missingNetwork, cniVersion))
+		podController.handleNetAttachDefAdd(&createdNetAttachDef)
+
+		Expect(podController.workqueue.Len()).To(Equal(1))
+		request := podController.pendingRequests.peek(annotations.NamespacedName(namespace, podName))
+		Expect(request.Type).To(Equal(add))
+		Expect(request.AttachmentNames).To(HaveLen(1))
+		Expect(request.AttachmentNames[0].Name).To(Equal(missingNetwork))
+	})
+})
+
+var _ = Describe("Misconfigured network-attachment-definitions", func() {
+	const misconfiguredNetwork = "misconfigured-net"
+
+	// attach has the pod attach to the misconfigured network, along with a
+	// valid one, returning the recorded events
+	attach := func(config string) (*PodNetworksController, *fakemultusclient.Client, *record.FakeRecorder) {
+		const maxEvents = 5
+		pod := podSpec(podName, namespace)
+		multusClient := fakemultusclient.NewFakeClient(networkConfig(multuscni.CmdAdd, "net1", "net1", macAddr))
+		podController := newSynchedPodController(
+			pod,
+			multusClient,
+			tinyNetAttachDef(),
+			netAttachDef(misconfiguredNetwork, namespace, config))
+		eventRecorder := record.NewFakeRecorder(maxEvents)
+		podController.recorder = eventRecorder
+
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:      podName,
+			PodNamespace: namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{
+				{Name: misconfiguredNetwork, Namespace: namespace, InterfaceRequest: "net0"},
+				{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"},
+			},
+			Type:     add,
+			PodNetNS: netnsPath,
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+		return podController, multusClient, eventRecorder
+	}
+
+	invalidNetAttachDefEvent := func(reason string) string {
+		return fmt.Sprintf(
+			"Warning InvalidNetworkAttachmentDefinition pod [%s]: skipped adding interface net0 to network: %s: "+
+				"invalid network-attachment-definition: the config of network-attachment-definition %s %s",
+			annotations.NamespacedName(namespace, podName),
+			misconfiguredNetwork,
+			annotations.NamespacedName(namespace, misconfiguredNetwork),
+			reason)
+	}
+
+	It("skip their attachments without invoking the delegate, while the request's other attachments are added", func() {
+		podController, multusClient, eventRecorder := attach("")
+
+		Expect(eventRecorder.Events).To(Receive(Equal(invalidNetAttachDefEvent("is empty"))))
+		Expect(eventRecorder.Events).To(Receive(HavePrefix("Normal AddedInterface")))
+		Expect(multusClient.Requests()).To(HaveLen(1))
+		Expect(multusClient.Requests()[0].Env).To(HaveKeyWithValue("CNI_IFNAME", "net1"))
+		Expect(podController.workqueue.NumRequeues(annotations.NamespacedName(namespace, podName))).To(BeZero())
+	})
+
+	It("are reported when their config is not valid JSON", func() {
+		_, multusClient, eventRecorder := attach(`{"cniVersion": "0.3.0",`)
+
+		Expect(eventRecorder.Events).To(Receive(Equal(invalidNetAttachDefEvent("is not valid JSON"))))
+		Expect(multusClient.Requests()).To(HaveLen(1))
+	})
+
+	It("are attached to the pods requesting them once fixed", func() {
+		pod := updatePodSpec(podSpec(podName, namespace, networkName), networkName, misconfiguredNetwork)
+		podController := newUnstartedPodController(fakecri.NewFakeRuntime(*pod), fakemultusclient.NewFakeClient())
+		Expect(podController.podsInformer.GetStore().Add(pod)).To(Succeed())
+
+		oldNetAttachDef := netAttachDef(misconfiguredNetwork, namespace, "")
+		newNetAttachDef := netAttachDef(misconfiguredNetwork, namespace, dummyNetSpec(misconfiguredNetwork, cniVersion))
+		podController.handleNetAttachDefUpdate(&oldNetAttachDef, &newNetAttachDef)
+
+		Expect(podController.workqueue.Len()).To(Equal(1))
+		request := podController.pendingRequests.peek(annotations.NamespacedName(namespace, podName))
+		Expect(request.Type).To(Equal(add))
+		Expect(request.AttachmentNames).To(HaveLen(1))
+		Expect(request.AttachmentNames[0].Name).To(Equal(misconfiguredNetwork))
+	})
+})
+
+var _ = Describe("Network-attachment-definition updates", func() {
+	const otherNetworkName = "other-net"
+
+	var (
+		attachedPod   *corev1.Pod
+		podController *PodNetworksController
+	)
+
+	newPodController := func(opts ...Option) *PodNetworksController {
+		attachedPod = podSpec("attached-pod", namespace, networkName)
+		otherPod := podSpec("other-pod", namespace, otherNetworkName)
+		otherNamespacePod := podSpec("other-namespace-pod", "other-namespace", networkName)
+		otherNamespacePod.Annotations[nad.NetworkStatusAnnot] = podNetworkStatusAnnotations("other-namespace", networkName)
+
+		podController := newUnstartedPodController(fakecri.NewFakeRuntime(*attachedPod), fakemultusclient.NewFakeClient(), opts...)
+		for _, pod := range []*corev1.Pod{attachedPod, otherPod, otherNamespacePod} {
+			Expect(podController.podsInformer.GetStore().Add(pod)).To(Succeed())
+		}
+		return podController
+	}
+
+	updateNetAttachDef := func() {
+		oldNetAttachDef := tinyNetAttachDef()
+		newNetAttachDef := netAttachDef(networkName, namespace, dummyNetSpec(networkName, "1.0.0"))
+		podController.handleNetAttachDefUpdate(&oldNetAttachDef, &newNetAttachDef)
+	}
+
+	It("affect the pods attached to the updated network", func() {
+		podController = newPodController()
+		Expect(podController.podsAttachedTo(annotations.NamespacedName(namespace, networkName))).To(ConsistOf(attachedPod))
+	})
+
+	It("do not re-attach the affected pods by default", func() {
+		podController = newPodController()
+		updateNetAttachDef()
+		Expect(podController.workqueue.Len()).To(BeZero())
+	})
+
+	It("re-attach the affected pods when enabled", func() {
+		podController = newPodController(WithReattachOnNetAttachDefUpdate(true))
+		updateNetAttachDef()
+
+		podKey := annotations.NamespacedName(namespace, attachedPod.GetName())
+		Expect(podController.workqueue.Len()).To(Equal(1))
+		expectedAttachments := []*nad.NetworkSelectionElement{{Name: networkName, Namespace: namespace, InterfaceRequest: "net0"}}
+		Expect(podController.pendingRequests.peek(podKey).Type).To(Equal(remove))
+		Expect(podController.pendingRequests.peek(podKey).AttachmentNames).To(Equal(expectedAttachments))
+		Expect(podController.pendingRequests.pop(podKey)).To(Equal(1))
+		Expect(podController.pendingRequests.peek(podKey).Type).To(Equal(add))
+		Expect(podController.pendingRequests.peek(podKey).AttachmentNames).To(Equal(expectedAttachments))
+	})
+})
@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/webhook"
+)
+
+// isNetworksUpdateAllowed asks the networks update validator - if any -
+// whether the attachments can be added to, and removed from, the pod; a
+// denied update is reported on the pod. The update is denied as well when
+// no verdict is reached, since the policy enforced by the validator must not
+// be bypassed merely because it is unreachable.
+func (pnc *PodNetworksController) isNetworksUpdateAllowed(
+	pod *corev1.Pod,
+	toAdd []*nadv1.NetworkSelectionElement,
+	toRemove []*nadv1.NetworkSelectionElement,
+) bool {
+	if pnc.networksUpdateValidator == nil || len(toAdd)+len(toRemove) == 0 {
+		return true
+	}
+
+	logger := klog.LoggerWithValues(klog.Background(), "pod", pod.GetName(), "namespace", pod.GetNamespace())
+	verdict, err := pnc.networksUpdateValidator.Validate(context.Background(), &webhook.Review{
+		PodName:      pod.GetName(),
+		PodNamespace: pod.GetNamespace(),
+		Add:          toAdd,
+		Remove:       toRemove,
+	})
+	if err != nil {
+		logger.Error(err, "rejecting the networks update: failed to validate it")
+		pnc.Eventf(pod, corev1.EventTypeWarning, "NetworksUpdateDenied", networksUpdateValidationFailedEventFormat(pod, err))
+		return false
+	}
+	if !verdict.Allowed {
+		logger.Info("rejecting the networks update: denied by the validator", "reason", verdict.Reason)
+		pnc.Eventf(pod, corev1.EventTypeWarning, "NetworksUpdateDenied", networksUpdateDeniedEventFormat(pod, verdict.Reason))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	nad "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+	fakecri "github.com/maiqueb/multus-dynamic-networks-controller/pkg/cri/fake"
+	fakemultusclient "github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni/fake"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/webhook"
+	fakewebhook "github.com/maiqueb/multus-dynamic-networks-controller/pkg/webhook/fake"
+)
+
+var _ = Describe("Vetting the networks updates", func() {
+	var (
+		pod           *corev1.Pod
+		eventRecorder *record.FakeRecorder
+	)
+
+	BeforeEach(func() {
+		const maxEvents = 1
+		pod = podSpec(podName, namespace)
+		delete(pod.Annotations, nad.NetworkAttachmentAnnot)
+		eventRecorder = record.NewFakeRecorder(maxEvents)
+	})
+
+	updatePod := func(validator webhook.Validator) *DynamicAttachmentRequest {
+		podController := newUnstartedPodController(
+			fakecri.NewFakeRuntime(*pod),
+			fakemultusclient.NewFakeClient(),
+			WithNetworksUpdateValidator(validator))
+		podController.recorder = eventRecorder
+		podController.handlePodUpdate(pod, updatePodSpec(pod, networkName))
+		return podController.pendingRequests.peek(annotations.NamespacedName(namespace, podName))
+	}
+
+	It("submits the proposed attachments to the validator", func() {
+		validator := fakewebhook.NewFakeValidator(&webhook.Verdict{Allowed: true}, nil)
+		updatePod(validator)
+
+		Expect(validator.Reviews()).To(ConsistOf(&webhook.Review{
+			PodName:      podName,
+			PodNamespace: namespace,
+			Add:          []*nad.NetworkSelectionElement{{Name: networkName, Namespace: namespace, InterfaceRequest: "net0"}},
+		}))
+	})
+
+	It("acts upon the allowed updates", func() {
+		request := updatePod(fakewebhook.NewFakeValidator(&webhook.Verdict{Allowed: true}, nil))
+
+		Expect(request).NotTo(BeNil())
+		Expect(request.Type).To(Equal(add))
+		Expect(eventRecorder.Events).NotTo(Receive())
+	})
+
+	It("drops the denied updates, reporting them on the pod", func() {
+		request := updatePod(fakewebhook.NewFakeValidator(&webhook.Verdict{Allowed: false, Reason: "off limits"}, nil))
+
+		Expect(request).To(BeNil())
+		Expect(eventRecorder.Events).To(Receive(Equal(
+			"Warning NetworksUpdateDenied pod [default/tiny-winy-pod]: the networks update was denied: off limits")))
+	})
+
+	It("drops the updates it fails to validate, reporting them on the pod", func() {
+		request := updatePod(fakewebhook.NewFakeValidator(nil, errors.New("webhook unreachable")))
+
+		Expect(request).To(BeNil())
+		Expect(eventRecorder.Events).To(Receive(Equal(
+			"Warning NetworksUpdateDenied pod [default/tiny-winy-pod]: the networks update was denied: failed to validate it: webhook unreachable")))
+	})
+})
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// PauseEndpoint pauses the processing of the requests; see Pause.
+	PauseEndpoint = "/pause"
+	// ResumeEndpoint resumes the processing of the requests; see Resume.
+	ResumeEndpoint = "/resume"
+
+	// pausedRequeueDelay is how long the requests handed to the workers while
+	// paused wait before being handed again
+	pausedRequeueDelay = 5 * time.Second
+)
+
+// pause tells if the processing of the requests is paused - e.g. while the CNI
+// plugins are upgraded.
+type pause struct {
+	lock     sync.RWMutex
+	isPaused bool
+}
+
+func (p *pause) paused() bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.isPaused
+}
+
+// set pauses, or resumes, returning true when the state changed.
+func (p *pause) set(paused bool) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	wasPaused := p.isPaused
+	p.isPaused = paused
+	return wasPaused != paused
+}
+
+// Pause stops processing the requests - thus invoking the delegates - without
+// dropping them: the requests keep being queued, waiting for Resume. The
+// requests being processed complete.
+func (pnc *PodNetworksController) Pause() {
+	if pnc.pause.set(true) {
+		klog.InfoS("paused processing the dynamic attachment requests")
+	}
+}
+
+// Resume processes the requests queued while paused, in order.
+func (pnc *PodNetworksController) Resume() {
+	if !pnc.pause.set(false) {
+		return
+	}
+	podKeys := pnc.pendingRequests.podKeys()
+	klog.InfoS("resumed processing the dynamic attachment requests", "pods", len(podKeys))
+	for _, podKey := range podKeys {
+		// the requests need not wait for the paused requeue delay to elapse
+		pnc.workqueue.Add(podKey)
+	}
+}
+
+func (pnc *PodNetworksController) servePause(pauseOrResume func()) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "expected a POST request", http.StatusMethodNotAllowed)
+			return
+		}
+		pauseOrResume()
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	nad "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni"
+	fakemultusclient "github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni/fake"
+)
+
+var _ = Describe("Pausing the controller", func() {
+	const ifaceName = "net1"
+
+	var (
+		multusClient  *fakemultusclient.Client
+		podController *PodNetworksController
+		server        *httptest.Server
+	)
+
+	post := func(endpoint string) {
+		response, err := http.Post(server.URL+endpoint, "application/json", http.NoBody)
+		Expect(err).NotTo(HaveOccurred())
+		defer response.Body.Close()
+		Expect(response.StatusCode).To(Equal(http.StatusNoContent))
+	}
+
+	BeforeEach(func() {
+		addConfig := networkConfig(multuscni.CmdAdd, ifaceName, ifaceName, macAddr)
+		addConfig.Response.Result.Interfaces[0].Sandbox = netnsPath
+		multusClient = fakemultusclient.NewFakeClient(addConfig)
+		podController = newSynchedPodController(podSpec(podName, namespace), multusClient, tinyNetAttachDef())
+		server = httptest.NewServer(podController.AdminHandler())
+
+		post(PauseEndpoint)
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:         podName,
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{{Name: networkName, Namespace: namespace, InterfaceRequest: ifaceName}},
+			Type:            add,
+			PodNetNS:        netnsPath,
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("keeps the requests queued, without invoking the delegates", func() {
+		Expect(multusClient.Requests()).To(BeEmpty())
+		Expect(podController.pendingRequests.has(annotations.NamespacedName(namespace, podName))).To(BeTrue())
+	})
+
+	It("processes the queued requests once resumed", func() {
+		post(ResumeEndpoint)
+
+		Expect(podController.workqueue.Len()).To(Equal(1))
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+		Expect(multusClient.Requests()).To(HaveLen(1))
+		Expect(podController.pendingRequests.has(annotations.NamespacedName(namespace, podName))).To(BeFalse())
+	})
+})
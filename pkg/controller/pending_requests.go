@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"sync"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/logging"
+)
+
+// pendingRequests indexes the dynamic attachment requests awaiting processing
+// by the pod they refer to. The requests of each pod are kept in arrival order;
+// since the workqueue never hands the same key to more than one worker at a
+// time, keying the workqueue by pod serializes the requests of each pod while
+// allowing different pods to be processed concurrently.
+type pendingRequests struct {
+	lock     sync.Mutex
+	requests map[string][]*DynamicAttachmentRequest
+}
+
+func newPendingRequests() *pendingRequests {
+	return &pendingRequests{requests: map[string][]*DynamicAttachmentRequest{}}
+}
+
+// push appends the request to the ones pending for its pod, returning the pod
+// key. Requests duplicating a pending request are discarded, unless a request
+// of the opposite type is pending after it - e.g. adding a network, removing
+// it, then adding it back.
+func (pr *pendingRequests) push(request *DynamicAttachmentRequest) string {
+	pr.lock.Lock()
+	defer pr.lock.Unlock()
+
+	podKey := request.podKey()
+	if pr.isDuplicate(podKey, request) {
+		request.logger().V(logging.Debug).Info("discarding request: it is already pending")
+		return podKey
+	}
+	pr.requests[podKey] = append(pr.requests[podKey], request)
+	return podKey
+}
+
+func (pr *pendingRequests) isDuplicate(podKey string, request *DynamicAttachmentRequest) bool {
+	requestKey := request.key()
+	pending := pr.requests[podKey]
+	for i := len(pending) - 1; i >= 0; i-- {
+		if pending[i].Type != request.Type {
+			return false
+		}
+		if pending[i].key() == requestKey {
+			return true
+		}
+	}
+	return false
+}
+
+// peek returns the oldest request pending for the pod, or nil if there is none.
+func (pr *pendingRequests) peek(podKey string) *DynamicAttachmentRequest {
+	pr.lock.Lock()
+	defer pr.lock.Unlock()
+
+	if requests := pr.requests[podKey]; len(requests) > 0 {
+		return requests[0]
+	}
+	return nil
+}
+
+// has indicates if there are requests pending for the pod.
+func (pr *pendingRequests) has(podKey string) bool {
+	pr.lock.Lock()
+	defer pr.lock.Unlock()
+
+	_, wasFound := pr.requests[podKey]
+	return wasFound
+}
+
+// pop discards the oldest request pending for the pod, returning how many remain.
+func (pr *pendingRequests) pop(podKey string) int {
+	pr.lock.Lock()
+	defer pr.lock.Unlock()
+
+	requests := pr.requests[podKey]
+	if len(requests) <= 1 {
+		delete(pr.requests, podKey)
+		return 0
+	}
+	pr.requests[podKey] = requests[1:]
+	return len(requests) - 1
+}
+
+// podKeys returns the keys of the pods with pending requests.
+func (pr *pendingRequests) podKeys() []string {
+	pr.lock.Lock()
+	defer pr.lock.Unlock()
+
+	podKeys := make([]string, 0, len(pr.requests))
+	for podKey := range pr.requests {
+		podKeys = append(podKeys, podKey)
+	}
+	return podKeys
+}
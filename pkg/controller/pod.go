@@ -3,59 +3,191 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"reflect"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	v1coreinformerfactory "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	v1corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
+	cni100 "github.com/containernetworking/cni/pkg/types/100"
+	"github.com/google/uuid"
 	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 	nadclient "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/client/clientset/versioned"
 	nadinformers "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/client/informers/externalversions"
 	nadlisterv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/client/listers/k8s.cni.cncf.io/v1"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	multusapi "gopkg.in/k8snetworkplumbingwg/multus-cni.v3/pkg/server/api"
 
 	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/audit"
 	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/cri"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/link"
 	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/logging"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/metrics"
 	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/sysctl"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/webhook"
 )
 
 const (
-	AdvertisedName = "pod-networks-updates"
-	maxRetries     = 2
+	AdvertisedName     = "pod-networks-updates"
+	defaultWorkerCount = 1
+
+	// DefaultMaxRetries is how many times a failed request is retried by default, thus attempted up to 4 times
+	DefaultMaxRetries = 3
+	// DefaultRetryBaseDelay is the delay before the first retry of a failed request
+	DefaultRetryBaseDelay = 5 * time.Millisecond
+	// DefaultRetryMaxDelay caps the exponentially growing delay between retries
+	DefaultRetryMaxDelay = 1000 * time.Second
+
+	// DefaultDelegateTimeout is how long a multus delegate invocation can take by default
+	DefaultDelegateTimeout = 30 * time.Second
+
+	// DefaultDrainTimeout is how long the queued requests are processed by default once the controller is stopped
+	DefaultDrainTimeout = 30 * time.Second
+
+	// DefaultShutdownTimeout is how long the workers are given by default to finish once the requests are drained
+	DefaultShutdownTimeout = 10 * time.Second
+
+	// DefaultEventDeduplicationWindow is how long the identical events are dropped for by default
+	DefaultEventDeduplicationWindow = time.Minute
+
+	// DefaultCacheSyncTimeout is how long the caches are given to synchronize by default
+	DefaultCacheSyncTimeout = 2 * time.Minute
+
+	podNotRunningRequeueDelay = 2 * time.Second
+	// podNotRunningTimeout is how long a request waits for the pod to run
+	podNotRunningTimeout = 10 * time.Minute
+
+	// maxStatusWrites bounds how many times the network-status is re-written
+	// when its verification finds the added interface missing
+	maxStatusWrites = 3
+
+	implicitInterfacePrefix = "net"
+
+	containerIDSchemeSeparator = "://"
+
+	// tooManyAttachmentsReason labels the networks updates rejected for
+	// requesting more networks than allowed
+	tooManyAttachmentsReason = "too_many_attachments"
 )
 
+// netnsResolutionBackoff paces the workers' attempts to resolve a pod's
+// network namespace through the container runtime, which may not know a
+// container right as it starts.
+var netnsResolutionBackoff = wait.Backoff{
+	Steps:    4,
+	Duration: 50 * time.Millisecond,
+	Factor:   2,
+	Jitter:   0.1,
+}
+
 type DynamicAttachmentRequestType string
 
+const (
+	add    DynamicAttachmentRequestType = "add"
+	remove DynamicAttachmentRequestType = "remove"
+	// finalize removes the attachments of a terminating pod, then its finalizer
+	finalize DynamicAttachmentRequestType = "finalize"
+	// apply writes the networks declared by the pod's PodNetworkAttachment in
+	// its networks annotation
+	apply DynamicAttachmentRequestType = "apply"
+	// attach appends the attachments to the pod's networks annotation; see
+	// AttachEndpoint
+	attach DynamicAttachmentRequestType = "attach"
+	// replace swaps the replaced attachments for the request's ones in a
+	// single step; see replaceNetworks
+	replace DynamicAttachmentRequestType = "replace"
+)
+
 type DynamicAttachmentRequest struct {
-	PodName         string
+	// ID correlates the logs of the request, across its retries
+	ID      string
+	PodName string
+	// PodUID identifies the pod the request was issued for: the request is
+	// discarded once the pod is recreated under the same name. Any pod of
+	// that name is acted upon when empty.
+	PodUID          types.UID
 	PodNamespace    string
 	AttachmentNames []*nadv1.NetworkSelectionElement
-	Type            DynamicAttachmentRequestType
-	PodNetNS        string
+	// ReplacedAttachmentNames are the attachments a replace request swaps for
+	// its AttachmentNames.
+	ReplacedAttachmentNames []*nadv1.NetworkSelectionElement `json:",omitempty"`
+	Type                    DynamicAttachmentRequestType
+	PodNetNS                string
+
+	// deletedPod holds the last known state of the pod, when the request
+	// cleans up the attachments of a deleted pod.
+	deletedPod *corev1.Pod
+	// waitingForPodSince is when the request was first found waiting for the
+	// pod to run.
+	waitingForPodSince time.Time
+	// annotationUpdatedAt is when the networks annotation update the request
+	// stems from was observed; zero for the requests not issued by one.
+	annotationUpdatedAt time.Time
+	// rollback tells the request undoes the attachments another request
+	// added before failing.
+	rollback bool
 }
 
 func (dar *DynamicAttachmentRequest) String() string {
 	req, err := json.Marshal(dar)
 	if err != nil {
-		klog.Warningf("failed to marshal DynamicAttachmentRequest: %v", err)
+		klog.ErrorS(err, "failed to marshal DynamicAttachmentRequest")
 		return ""
 	}
 	return string(req)
 }
 
+// logger returns a logger identifying the request, and the pod it refers to.
+func (dar *DynamicAttachmentRequest) logger() klog.Logger {
+	return klog.LoggerWithValues(
+		klog.Background(),
+		"requestID", dar.ID,
+		"requestType", dar.Type,
+		"pod", dar.PodName,
+		"namespace", dar.PodNamespace,
+	)
+}
+
+func (dar *DynamicAttachmentRequest) podKey() string {
+	return annotations.NamespacedName(dar.PodNamespace, dar.PodName)
+}
+
+// key identifies the network change described by the request: requests for
+// the same pod, of the same type, and featuring the same attachments - in any
+// order - share the same key.
+func (dar *DynamicAttachmentRequest) key() string {
+	attachmentKeys := make([]string, 0, len(dar.AttachmentNames)+len(dar.ReplacedAttachmentNames))
+	for _, attachment := range dar.AttachmentNames {
+		attachmentKeys = append(attachmentKeys, networkSelectionElementIndexKey(*attachment))
+	}
+	for _, attachment := range dar.ReplacedAttachmentNames {
+		attachmentKeys = append(attachmentKeys, "-"+networkSelectionElementIndexKey(*attachment))
+	}
+	sort.Strings(attachmentKeys)
+	return fmt.Sprintf("%s/%s/%s", dar.podKey(), dar.Type, strings.Join(attachmentKeys, ","))
+}
+
 // PodNetworksController handles the cncf networks annotations update, and
 // triggers adding / removing networks from a running pod.
 type PodNetworksController struct {
@@ -72,6 +204,311 @@ type PodNetworksController struct {
 	nadClientSet            nadclient.Interface
 	containerRuntime        cri.ContainerRuntime
 	multusClient            multuscni.Client
+	pendingRequests         *pendingRequests
+	standby                 *standby
+	attachmentsDrain        *attachmentsDrain
+	pause                   *pause
+	podStates               *podStates
+	workerCount             int
+	resyncPeriod            time.Duration
+	delegateTimeout         time.Duration
+	drainTimeout            time.Duration
+	shutdownTimeout         time.Duration
+	cacheSyncTimeout        time.Duration
+	podSelector             labels.Selector
+	maxRetries              int
+	rateLimiter             workqueue.RateLimiter
+	maxConcurrentDelegates  int
+	maxAttachmentsPerPod    int
+	// delegateSlots bounds the concurrent delegate invocations; unbounded when nil
+	delegateSlots chan struct{}
+
+	reattachOnNetAttachDefUpdate bool
+	verifyNetworkStatus          bool
+	dryRun                       bool
+	usePodFinalizer              bool
+	recordInterfaceStates        bool
+	networksReadyCondition       bool
+	netnsAnnotation              string
+	tracer                       trace.Tracer
+	sysctlSetter                 sysctl.Setter
+	linkDeleter                  link.Deleter
+	networksUpdateValidator      webhook.Validator
+	auditSink                    audit.Sink
+	clock                        clock.Clock
+	eventDeduplicationWindow     time.Duration
+	events                       *eventDeduplicator
+	restrictCrossNamespaceRefs   bool
+	podNetworkAttachmentInformer cache.SharedIndexInformer
+	bookkeepingPrefix            string
+	bookkeepingKeys              annotations.BookkeepingKeys
+	cniArgsPodLabels             []string
+	cniArgsPodAnnotations        []string
+}
+
+// Option allows customizing the PodNetworksController
+type Option func(*PodNetworksController)
+
+// WithDelegateTimeout bounds how long each multus delegate invocation can take.
+func WithDelegateTimeout(delegateTimeout time.Duration) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.delegateTimeout = delegateTimeout
+	}
+}
+
+// WithDrainTimeout bounds how long the queued requests - and the ones being
+// processed - are given to complete once the controller is stopped.
+func WithDrainTimeout(drainTimeout time.Duration) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.drainTimeout = drainTimeout
+	}
+}
+
+// WithShutdownTimeout bounds how long the workers are given to finish - once
+// the requests are drained, see WithDrainTimeout - before the controller's
+// Start returns regardless, abandoning e.g. the hung delegate invocations; a
+// zero timeout waits for them indefinitely.
+func WithShutdownTimeout(shutdownTimeout time.Duration) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.shutdownTimeout = shutdownTimeout
+	}
+}
+
+// WithCacheSyncTimeout bounds how long the caches are given to synchronize on
+// start; a zero timeout waits for them indefinitely.
+func WithCacheSyncTimeout(cacheSyncTimeout time.Duration) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.cacheSyncTimeout = cacheSyncTimeout
+	}
+}
+
+// WithMaxRetries sets how many times a failed request is retried before being
+// dropped: each request is attempted up to maxRetries + 1 times; never retried
+// when 0.
+func WithMaxRetries(maxRetries int) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.maxRetries = maxRetries
+	}
+}
+
+// WithRetryBackoff sets the delay between the retries of a failed request; the
+// delay doubles on each retry, starting at `baseDelay`, up to `maxDelay`.
+func WithRetryBackoff(baseDelay time.Duration, maxDelay time.Duration) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.rateLimiter = workqueue.NewItemExponentialFailureRateLimiter(baseDelay, maxDelay)
+	}
+}
+
+// WithReattachOnNetAttachDefUpdate re-attaches the pods to the networks whose
+// network-attachment-definition is updated, thus having their interfaces use the
+// updated configuration. Re-attaching is disruptive: the pods' interfaces on the
+// updated network are removed, then added back.
+func WithReattachOnNetAttachDefUpdate(reattach bool) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.reattachOnNetAttachDefUpdate = reattach
+	}
+}
+
+// WithNetworkStatusVerification re-reads the pod after each added interface is
+// recorded in its network-status, writing the network-status again whenever a
+// concurrent pod update dropped the added interface from it.
+func WithNetworkStatusVerification(verify bool) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.verifyNetworkStatus = verify
+	}
+}
+
+// WithDryRun has the controller only report - via logs and events - the
+// interfaces it would add and remove, without invoking the delegate nor
+// updating the pods' network-status.
+func WithDryRun(dryRun bool) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.dryRun = dryRun
+	}
+}
+
+// WithTracerProvider has the controller trace the processing of each request
+// - down to the delegate invocations - through the provider's tracer.
+func WithTracerProvider(tracerProvider trace.TracerProvider) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.tracer = tracerProvider.Tracer(tracerName)
+	}
+}
+
+// WithPodFinalizer makes the controller add a finalizer to the pods it adds
+// attachments to, holding their deletion until the attachments are removed.
+// Beware: the pods' deletion is blocked while the controller is down.
+func WithPodFinalizer(enabled bool) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.usePodFinalizer = enabled
+	}
+}
+
+// WithSysctlSetter overrides how the sysctls requested by the attachments are
+// applied in the pods' network namespaces.
+func WithSysctlSetter(sysctlSetter sysctl.Setter) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.sysctlSetter = sysctlSetter
+	}
+}
+
+// WithLinkDeleter overrides how the interfaces whose network-attachment-definition
+// no longer exists are deleted from the pods' network namespaces.
+func WithLinkDeleter(linkDeleter link.Deleter) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.linkDeleter = linkDeleter
+	}
+}
+
+// WithPodSelector restricts the controller to the pods whose labels match the
+// selector; the networks of every other pod are left untouched.
+func WithPodSelector(podSelector labels.Selector) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.podSelector = podSelector
+	}
+}
+
+// WithStandby has the controller ignore the pods' events until it is started -
+// e.g. until this replica is elected leader -; it then catches up, reconciling
+// every pod.
+func WithStandby(standingBy bool) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.standby.isStandingBy = standingBy
+	}
+}
+
+// WithResyncPeriod periodically reconciles the interfaces of all pods, thus
+// converging their network-status to their network selection elements. The
+// reconciliation is disabled when the period is not positive.
+func WithResyncPeriod(resyncPeriod time.Duration) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.resyncPeriod = resyncPeriod
+	}
+}
+
+// WithWorkers sets the number of workers concurrently processing dynamic
+// attachment requests; the requests of a single pod are always processed
+// sequentially, in the order they were issued.
+func WithWorkers(workerCount int) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.workerCount = workerCount
+	}
+}
+
+// WithMaxConcurrentDelegates bounds how many multus delegate invocations run
+// concurrently - regardless of the number of workers -, thus sparing the node
+// a burst of CNI invocations; unbounded when 0.
+func WithMaxConcurrentDelegates(maxConcurrentDelegates int) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.maxConcurrentDelegates = maxConcurrentDelegates
+	}
+}
+
+// WithMaxAttachmentsPerPod caps how many networks a pod's networks annotation
+// can request; the updates requesting more are rejected, sparing the workers a
+// pathological batch of attachments. Unbounded when 0.
+func WithMaxAttachmentsPerPod(maxAttachmentsPerPod int) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.maxAttachmentsPerPod = maxAttachmentsPerPod
+	}
+}
+
+// WithInterfaceStates has the controller record the state - attaching,
+// attached, detaching, or failed - of the interfaces it adds and removes in the
+// pod's interface states annotation.
+func WithInterfaceStates(enabled bool) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.recordInterfaceStates = enabled
+	}
+}
+
+// WithNetworksReadyCondition has the controller mirror whether each batch of a
+// pod's requests fully applied its networks annotation in the pod's
+// DynamicNetworksReady condition; meant for the orchestration waiting on the
+// pods' conditions.
+func WithNetworksReadyCondition(enabled bool) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.networksReadyCondition = enabled
+	}
+}
+
+// WithNetNSAnnotation has the pods' network namespace path read from their
+// `annotation` - when set -, rather than resolved through the container
+// runtime.
+func WithNetNSAnnotation(annotation string) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.netnsAnnotation = annotation
+	}
+}
+
+// WithNetworksUpdateValidator has the validator vet each networks update -
+// whether it adds or removes attachments - before it is acted upon.
+func WithNetworksUpdateValidator(validator webhook.Validator) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.networksUpdateValidator = validator
+	}
+}
+
+// WithAuditSink has the sink record the outcome of every attachment added to,
+// or removed from, a pod.
+func WithAuditSink(sink audit.Sink) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.auditSink = sink
+	}
+}
+
+// WithClock has the controller tell the time - e.g. to time out the waits, or
+// to measure the latencies - using the clock.
+func WithClock(clock clock.Clock) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.clock = clock
+	}
+}
+
+// WithEventDeduplicationWindow drops the events identical to one emitted on
+// the same object within the window; a zero window emits them all.
+func WithEventDeduplicationWindow(window time.Duration) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.eventDeduplicationWindow = window
+	}
+}
+
+// WithCrossNamespaceReferencesRestricted restricts the pods to the
+// network-attachment-definitions of their own namespace, unless the
+// definition's namespace allows the references from other namespaces - see
+// AllowCrossNamespaceReferencesLabel.
+func WithCrossNamespaceReferencesRestricted(restricted bool) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.restrictCrossNamespaceRefs = restricted
+	}
+}
+
+// WithBookkeepingAnnotationPrefix overrides the prefix of the annotations the
+// controller records its own bookkeeping in - see annotations.BookkeepingKeys
+// -, e.g. to avoid colliding with another controller's; the prefix must be a
+// DNS subdomain.
+func WithBookkeepingAnnotationPrefix(prefix string) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.bookkeepingPrefix = prefix
+	}
+}
+
+// WithPodLabelsAsCNIArgs forwards the pod's labels of the given keys to the
+// delegates in their CNI_ARGS - as `<key>=<value>` -, for the plugins keying
+// their behavior off the pod's metadata; see podMetadataCNIArgs.
+func WithPodLabelsAsCNIArgs(keys []string) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.cniArgsPodLabels = keys
+	}
+}
+
+// WithPodAnnotationsAsCNIArgs forwards the pod's annotations of the given keys
+// to the delegates in their CNI_ARGS, like WithPodLabelsAsCNIArgs does with
+// the labels.
+func WithPodAnnotationsAsCNIArgs(keys []string) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.cniArgsPodAnnotations = keys
+	}
 }
 
 // NewPodNetworksController returns new PodNetworksController instance
@@ -84,47 +521,250 @@ func NewPodNetworksController(
 	nadClientSet nadclient.Interface,
 	containerRuntime cri.ContainerRuntime,
 	multusClient multuscni.Client,
+	opts ...Option,
 ) (*PodNetworksController, error) {
 	podInformer := k8sCoreInformerFactory.Core().V1().Pods().Informer()
 	nadInformer := nadInformers.K8sCniCncfIo().V1().NetworkAttachmentDefinitions().Informer()
 
 	podNetworksController := &PodNetworksController{
-		arePodsSynched:          podInformer.HasSynced,
-		areNetAttachDefsSynched: nadInformer.HasSynced,
-		podsInformer:            podInformer,
-		podsLister:              k8sCoreInformerFactory.Core().V1().Pods().Lister(),
-		netAttachDefInformer:    nadInformer,
-		netAttachDefLister:      nadInformers.K8sCniCncfIo().V1().NetworkAttachmentDefinitions().Lister(),
-		recorder:                recorder,
-		broadcaster:             broadcaster,
-		workqueue: workqueue.NewNamedRateLimitingQueue(
-			workqueue.DefaultControllerRateLimiter(),
-			AdvertisedName),
-		k8sClientSet:     k8sClientSet,
-		nadClientSet:     nadClientSet,
-		containerRuntime: containerRuntime,
-		multusClient:     multusClient,
+		arePodsSynched:           podInformer.HasSynced,
+		areNetAttachDefsSynched:  nadInformer.HasSynced,
+		podsInformer:             podInformer,
+		podsLister:               k8sCoreInformerFactory.Core().V1().Pods().Lister(),
+		netAttachDefInformer:     nadInformer,
+		netAttachDefLister:       nadInformers.K8sCniCncfIo().V1().NetworkAttachmentDefinitions().Lister(),
+		recorder:                 recorder,
+		broadcaster:              broadcaster,
+		k8sClientSet:             k8sClientSet,
+		nadClientSet:             nadClientSet,
+		containerRuntime:         containerRuntime,
+		multusClient:             multusClient,
+		pendingRequests:          newPendingRequests(),
+		standby:                  &standby{},
+		attachmentsDrain:         &attachmentsDrain{},
+		pause:                    &pause{},
+		podStates:                newPodStates(),
+		workerCount:              defaultWorkerCount,
+		delegateTimeout:          DefaultDelegateTimeout,
+		drainTimeout:             DefaultDrainTimeout,
+		shutdownTimeout:          DefaultShutdownTimeout,
+		cacheSyncTimeout:         DefaultCacheSyncTimeout,
+		podSelector:              labels.Everything(),
+		maxRetries:               DefaultMaxRetries,
+		rateLimiter:              workqueue.DefaultControllerRateLimiter(),
+		tracer:                   trace.NewNoopTracerProvider().Tracer(tracerName),
+		sysctlSetter:             sysctl.NewNetNSSetter(),
+		linkDeleter:              link.NewNetNSDeleter(),
+		auditSink:                audit.NewNoopSink(),
+		clock:                    clock.RealClock{},
+		eventDeduplicationWindow: DefaultEventDeduplicationWindow,
+		events:                   newEventDeduplicator(),
+		bookkeepingPrefix:        annotations.DefaultBookkeepingPrefix,
+	}
+	for _, opt := range opts {
+		opt(podNetworksController)
+	}
+	if podNetworksController.workerCount < 1 {
+		return nil, fmt.Errorf("the number of workers must be positive: %d", podNetworksController.workerCount)
+	}
+	if podNetworksController.maxRetries < 0 {
+		return nil, fmt.Errorf("the number of retries cannot be negative: %d", podNetworksController.maxRetries)
 	}
+	if podNetworksController.maxConcurrentDelegates < 0 {
+		return nil, fmt.Errorf("the number of concurrent delegates cannot be negative: %d", podNetworksController.maxConcurrentDelegates)
+	}
+	if podNetworksController.maxConcurrentDelegates > 0 {
+		podNetworksController.delegateSlots = make(chan struct{}, podNetworksController.maxConcurrentDelegates)
+	}
+	if podNetworksController.maxAttachmentsPerPod < 0 {
+		return nil, fmt.Errorf("the number of attachments per pod cannot be negative: %d", podNetworksController.maxAttachmentsPerPod)
+	}
+	if podNetworksController.shutdownTimeout < 0 {
+		return nil, fmt.Errorf("the shutdown timeout cannot be negative: %v", podNetworksController.shutdownTimeout)
+	}
+	if podNetworksController.cacheSyncTimeout < 0 {
+		return nil, fmt.Errorf("the cache sync timeout cannot be negative: %v", podNetworksController.cacheSyncTimeout)
+	}
+	if podNetworksController.eventDeduplicationWindow < 0 {
+		return nil, fmt.Errorf("the event deduplication window cannot be negative: %v", podNetworksController.eventDeduplicationWindow)
+	}
+	bookkeepingKeys, err := annotations.NewBookkeepingKeys(podNetworksController.bookkeepingPrefix)
+	if err != nil {
+		return nil, err
+	}
+	podNetworksController.bookkeepingKeys = bookkeepingKeys
+	podNetworksController.workqueue = workqueue.NewNamedRateLimitingQueue(podNetworksController.rateLimiter, AdvertisedName)
 
 	podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		UpdateFunc: podNetworksController.handlePodUpdate,
+		DeleteFunc: podNetworksController.handlePodDelete,
+	})
+	nadInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    podNetworksController.handleNetAttachDefAdd,
+		UpdateFunc: podNetworksController.handleNetAttachDefUpdate,
 	})
+	if podNetworksController.podNetworkAttachmentInformer != nil {
+		podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: podNetworksController.handlePodAdd,
+		})
+		podNetworksController.podNetworkAttachmentInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    podNetworksController.handlePodNetworkAttachmentAdd,
+			UpdateFunc: podNetworksController.handlePodNetworkAttachmentUpdate,
+		})
+	}
 
 	return podNetworksController, nil
 }
 
-// Start runs worker thread after performing cache synchronization
-func (pnc *PodNetworksController) Start(stopChan <-chan struct{}) {
-	klog.Infof("starting network controller")
-	defer pnc.workqueue.ShutDown()
+// NewPodInformerFactory returns the informer factory of the pods whose networks
+// the controller handles, scoped to `namespace` - thus letting tenants run a
+// controller instance watching just their own pods. The pods of every
+// namespace are watched when it is empty.
+func NewPodInformerFactory(
+	k8sClientSet kubernetes.Interface,
+	resyncPeriod time.Duration,
+	namespace string,
+	opts ...v1coreinformerfactory.SharedInformerOption,
+) v1coreinformerfactory.SharedInformerFactory {
+	if namespace != metav1.NamespaceAll {
+		opts = append(opts, v1coreinformerfactory.WithNamespace(namespace))
+	}
+	return v1coreinformerfactory.NewSharedInformerFactoryWithOptions(k8sClientSet, resyncPeriod, opts...)
+}
+
+// Start runs the worker threads after performing cache synchronization; it
+// returns once the stop channel is closed, the queued requests drained, and
+// the workers finished - or the shutdown timeout elapsed.
+// The workers are not run when the caches do not synchronize within the cache
+// sync timeout - e.g. lacking the RBAC permissions to list the pods - since
+// they would act on partial data: an error wrapping ErrCachesNotSynced is
+// returned instead.
+func (pnc *PodNetworksController) Start(stopChan <-chan struct{}) error {
+	klog.InfoS("starting network controller", "workers", pnc.workerCount)
 
-	if ok := cache.WaitForCacheSync(stopChan, pnc.arePodsSynched, pnc.areNetAttachDefsSynched); !ok {
-		klog.Infof("failed waiting for caches to sync")
+	pnc.reportContainerRuntime()
+
+	informersSynched := []cache.InformerSynced{pnc.arePodsSynched, pnc.areNetAttachDefsSynched}
+	if pnc.podNetworkAttachmentInformer != nil {
+		informersSynched = append(informersSynched, pnc.podNetworkAttachmentInformer.HasSynced)
+	}
+	if err := pnc.waitForCacheSync(stopChan, informersSynched...); err != nil {
+		pnc.workqueue.ShutDown()
+		return err
+	}
+	if pnc.standby.takeOver() {
+		pnc.catchUp()
 	}
 
-	go wait.Until(pnc.worker, time.Second, stopChan)
+	var workers sync.WaitGroup
+	for i := 0; i < pnc.workerCount; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			wait.Until(pnc.worker, time.Second, stopChan)
+		}()
+	}
+	if pnc.resyncPeriod > 0 {
+		go wait.Until(pnc.reconcilePods, pnc.resyncPeriod, stopChan)
+	}
 	<-stopChan
-	klog.Infof("shutting down network controller")
+	klog.InfoS("shutting down network controller")
+	pnc.drain()
+	pnc.awaitWorkers(&workers)
+	return nil
+}
+
+// waitForCacheSync waits - up to the cache sync timeout - for the caches to
+// synchronize; being stopped in the meantime is not an error.
+func (pnc *PodNetworksController) waitForCacheSync(stopChan <-chan struct{}, informersSynched ...cache.InformerSynced) error {
+	var deadline <-chan time.Time
+	if pnc.cacheSyncTimeout > 0 {
+		deadline = pnc.clock.After(pnc.cacheSyncTimeout)
+	}
+	syncStopChan := make(chan struct{})
+	synched := make(chan struct{})
+	timedOut := make(chan struct{})
+	go func() {
+		defer close(syncStopChan)
+		select {
+		case <-stopChan:
+		case <-synched:
+		case <-deadline:
+			close(timedOut)
+		}
+	}()
+
+	ok := cache.WaitForCacheSync(syncStopChan, informersSynched...)
+	close(synched)
+	if ok {
+		return nil
+	}
+	select {
+	case <-timedOut:
+		return fmt.Errorf("%w after %v", ErrCachesNotSynced, pnc.cacheSyncTimeout)
+	default:
+		klog.InfoS("stopped waiting for caches to sync")
+		return nil
+	}
+}
+
+// drain stops accepting requests, waiting - up to the drain timeout - for the
+// workers to process the queued requests; abandoning a request mid-operation
+// would leave the pod with half-applied attachments.
+func (pnc *PodNetworksController) drain() {
+	drained := make(chan struct{})
+	go func() {
+		pnc.workqueue.ShutDownWithDrain()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		klog.InfoS("drained the dynamic attachment requests")
+	case <-pnc.clock.After(pnc.drainTimeout):
+		klog.InfoS("timed out draining the dynamic attachment requests", "pending", pnc.workqueue.Len())
+		pnc.workqueue.ShutDown()
+	}
+}
+
+// awaitWorkers waits - up to the shutdown timeout - for the workers to finish
+// the requests they are processing; the ones still running - e.g. stuck
+// invoking a hung delegate - are abandoned.
+func (pnc *PodNetworksController) awaitWorkers(workers *sync.WaitGroup) {
+	finished := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(finished)
+	}()
+
+	var deadline <-chan time.Time
+	if pnc.shutdownTimeout > 0 {
+		deadline = pnc.clock.After(pnc.shutdownTimeout)
+	}
+	select {
+	case <-finished:
+		klog.InfoS("the workers finished")
+	case <-deadline:
+		klog.InfoS("timed out waiting for the workers to finish", "timeout", pnc.shutdownTimeout)
+	}
+}
+
+// reportContainerRuntime logs and exposes the name and version of the container
+// runtime, revealing a misconfigured CRI socket.
+func (pnc *PodNetworksController) reportContainerRuntime() {
+	name, version, err := pnc.containerRuntime.Version()
+	if err != nil {
+		klog.ErrorS(err, "failed to query the container runtime version")
+		return
+	}
+	klog.InfoS("connected to the container runtime", "runtime", name, "version", version)
+	metrics.CRIInfo.WithLabelValues(name, version).Set(1)
+}
+
+// HasSynced indicates if the controller's pod and network-attachment-definition
+// caches are synchronized.
+func (pnc *PodNetworksController) HasSynced() bool {
+	return pnc.arePodsSynched() && pnc.areNetAttachDefsSynched()
 }
 
 func (pnc *PodNetworksController) worker() {
@@ -139,217 +779,1197 @@ func (pnc *PodNetworksController) processNextWorkItem() bool {
 	}
 	defer pnc.workqueue.Done(queueItem)
 
-	dynAttachmentRequest := queueItem.(*DynamicAttachmentRequest)
-	klog.Infof("extracted request [%v] from the queue", dynAttachmentRequest)
-	err := pnc.handleDynamicInterfaceRequest(dynAttachmentRequest)
-	pnc.handleResult(err, dynAttachmentRequest)
+	podKey := queueItem.(string)
+	if pnc.pause.paused() {
+		klog.V(logging.Debug).InfoS("deferring the pod's requests: processing is paused", "pod", podKey)
+		pnc.workqueue.AddAfter(podKey, pausedRequeueDelay)
+		return true
+	}
+	for {
+		dynAttachmentRequest := pnc.pendingRequests.peek(podKey)
+		if dynAttachmentRequest == nil {
+			break
+		}
+		logger := dynAttachmentRequest.logger()
+		logger.Info("extracted request from the queue")
+		ctx, span := pnc.tracer.Start(
+			klog.NewContext(context.Background(), logger),
+			"ProcessDynamicAttachmentRequest",
+			trace.WithAttributes(dynAttachmentRequest.spanAttributes()...))
+		err := pnc.handleDynamicInterfaceRequest(ctx, dynAttachmentRequest)
+		endSpan(span, err)
+		pnc.recordRequestOutcome(dynAttachmentRequest, err)
+		if err != nil {
+			pnc.recordNetworksReadyCondition(ctx, dynAttachmentRequest, err)
+			pnc.handleResult(err, dynAttachmentRequest)
+			return true
+		}
+		pnc.recordLatency(dynAttachmentRequest)
+		if remainingRequests := pnc.pendingRequests.pop(podKey); remainingRequests == 0 {
+			pnc.recordNetworksReadyCondition(ctx, dynAttachmentRequest, nil)
+		}
+	}
+	pnc.workqueue.Forget(podKey)
 
 	return true
 }
 
-func (pnc *PodNetworksController) handleDynamicInterfaceRequest(dynamicAttachmentRequest *DynamicAttachmentRequest) error {
-	klog.Infof("handleDynamicInterfaceRequest: read from queue: %v", dynamicAttachmentRequest)
-	if dynamicAttachmentRequest.Type == "add" {
-		pod, err := pnc.podsLister.Pods(dynamicAttachmentRequest.PodNamespace).Get(dynamicAttachmentRequest.PodName)
-		if err != nil {
-			return err
-		}
-		return pnc.addNetworks(dynamicAttachmentRequest, pod)
-	} else if dynamicAttachmentRequest.Type == "remove" {
-		pod, err := pnc.podsLister.Pods(dynamicAttachmentRequest.PodNamespace).Get(dynamicAttachmentRequest.PodName)
+// enqueue schedules the request for processing, after any other request
+// pending for the same pod.
+func (pnc *PodNetworksController) enqueue(dynamicAttachmentRequest *DynamicAttachmentRequest) {
+	if pnc.standby.standingBy() {
+		klog.V(logging.Debug).InfoS("ignoring the request: the controller is standing by", "pod", dynamicAttachmentRequest.podKey(), "type", dynamicAttachmentRequest.Type)
+		return
+	}
+	if pnc.attachmentsDrain.draining() && !isRemoval(dynamicAttachmentRequest) {
+		klog.V(logging.Debug).InfoS("ignoring the request: the attachments are drained", "pod", dynamicAttachmentRequest.podKey(), "type", dynamicAttachmentRequest.Type)
+		return
+	}
+	if dynamicAttachmentRequest.ID == "" {
+		dynamicAttachmentRequest.ID = uuid.NewString()
+	}
+	pnc.workqueue.Add(pnc.pendingRequests.push(dynamicAttachmentRequest))
+}
+
+func (pnc *PodNetworksController) handleDynamicInterfaceRequest(
+	ctx context.Context,
+	dynamicAttachmentRequest *DynamicAttachmentRequest,
+) (err error) {
+	ctx, span := pnc.tracer.Start(
+		ctx,
+		"HandleDynamicAttachmentRequest",
+		trace.WithAttributes(attribute.Int("attachments", len(dynamicAttachmentRequest.AttachmentNames))))
+	defer func() { endSpan(span, err) }()
+
+	logger := klog.FromContext(ctx)
+	logger.V(logging.Debug).Info("handling request", "attachments", dynamicAttachmentRequest.AttachmentNames)
+	switch dynamicAttachmentRequest.Type {
+	case add, remove, replace:
+		return pnc.handleNetworksRequest(ctx, dynamicAttachmentRequest)
+	case finalize:
+		return pnc.handleFinalizeRequest(ctx, dynamicAttachmentRequest)
+	case apply:
+		return pnc.handleApplyRequest(ctx, dynamicAttachmentRequest)
+	case attach:
+		return pnc.handleAttachRequest(ctx, dynamicAttachmentRequest)
+	default:
+		logger.Info("ignoring request of unknown type")
+	}
+	return nil
+}
+
+// handleNetworksRequest adds, removes, or replaces the attachments of the
+// request, on the pod's network namespace.
+func (pnc *PodNetworksController) handleNetworksRequest(ctx context.Context, dynamicAttachmentRequest *DynamicAttachmentRequest) error {
+	if dynamicAttachmentRequest.deletedPod != nil {
+		return pnc.removeNetworks(ctx, dynamicAttachmentRequest, dynamicAttachmentRequest.deletedPod.DeepCopy())
+	}
+	logger := klog.FromContext(ctx)
+	pod, err := pnc.currentPod(dynamicAttachmentRequest)
+	if apierrors.IsNotFound(err) {
+		// retrying is pointless; the pod's deletion issues its own request,
+		// removing its dynamic attachments
+		logger.Info("discarding the request: the pod no longer exists")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if dynamicAttachmentRequest.PodNetNS == "" {
+		// the pod was not running when the request was issued
+		netnsPath, err := pnc.netnsPathWithRetries(pod)
 		if err != nil {
 			return err
 		}
-		return pnc.removeNetworks(dynamicAttachmentRequest, pod)
-	} else {
-		klog.Infof("very weird attachment request: %+v", dynamicAttachmentRequest)
+		dynamicAttachmentRequest.PodNetNS = netnsPath
 	}
-	klog.Infof("handleDynamicInterfaceRequest: exited & successfully processed: %v", dynamicAttachmentRequest)
-	return nil
+	if dynamicAttachmentRequest.Type == remove {
+		return pnc.removeNetworks(ctx, dynamicAttachmentRequest, pod.DeepCopy())
+	}
+	if isTerminating(pod) {
+		logger.Info("discarding the request: the pod is terminating", "type", dynamicAttachmentRequest.Type)
+		return nil
+	}
+	if dynamicAttachmentRequest.Type == replace {
+		return pnc.replaceNetworks(ctx, dynamicAttachmentRequest, pod.DeepCopy())
+	}
+	return pnc.addNetworks(ctx, dynamicAttachmentRequest, pod.DeepCopy())
+}
+
+func (pnc *PodNetworksController) handleFinalizeRequest(ctx context.Context, dynamicAttachmentRequest *DynamicAttachmentRequest) error {
+	pod, err := pnc.currentPod(dynamicAttachmentRequest)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return pnc.finalizePod(ctx, dynamicAttachmentRequest, pod.DeepCopy())
+}
+
+func (pnc *PodNetworksController) handleApplyRequest(ctx context.Context, dynamicAttachmentRequest *DynamicAttachmentRequest) error {
+	pod, err := pnc.currentPod(dynamicAttachmentRequest)
+	if apierrors.IsNotFound(err) {
+		// applied once the pod is created
+		klog.FromContext(ctx).Info("deferring the pod network attachment: the pod does not exist")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return pnc.applyPodNetworkAttachment(ctx, pod)
+}
+
+func (pnc *PodNetworksController) handleAttachRequest(ctx context.Context, dynamicAttachmentRequest *DynamicAttachmentRequest) error {
+	pod, err := pnc.currentPod(dynamicAttachmentRequest)
+	if apierrors.IsNotFound(err) {
+		klog.FromContext(ctx).Info("discarding the attach request: the pod no longer exists")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return pnc.attachNetworks(ctx, dynamicAttachmentRequest, pod)
 }
 
 func (pnc *PodNetworksController) handleResult(err error, dynamicAttachmentRequest *DynamicAttachmentRequest) {
+	podKey := dynamicAttachmentRequest.podKey()
 	if err == nil {
-		pnc.workqueue.Forget(dynamicAttachmentRequest)
+		pnc.workqueue.Forget(podKey)
+		return
+	}
+
+	logger := dynamicAttachmentRequest.logger()
+
+	if errors.Is(err, errNoRunningContainers) && pnc.waitsForPodToRun(dynamicAttachmentRequest) {
+		logger.Info("re-queued request, since the pod is not running yet")
+		pnc.workqueue.AddAfter(podKey, podNotRunningRequeueDelay)
 		return
 	}
 
-	currentRetries := pnc.workqueue.NumRequeues(dynamicAttachmentRequest)
-	if currentRetries <= maxRetries {
-		klog.Errorf("re-queued request for: %v. Error: %v", dynamicAttachmentRequest, err)
-		pnc.workqueue.AddRateLimited(dynamicAttachmentRequest)
+	currentRetries := pnc.workqueue.NumRequeues(podKey)
+	if errors.Is(err, errNoRunningContainers) || (isTerminal(err) && !isRemoval(dynamicAttachmentRequest)) {
+		logger.Error(err, "dropped request: retrying it is pointless")
+		metrics.DroppedRequests.WithLabelValues(string(dynamicAttachmentRequest.Type)).Inc()
+		if pod := pnc.requestPod(dynamicAttachmentRequest); pod != nil {
+			pnc.Eventf(pod, corev1.EventTypeWarning, "RequestFailed", failedRequestEventFormat(dynamicAttachmentRequest, err))
+		}
+	} else if currentRetries < pnc.maxRetries {
+		logger.Error(err, "re-queued request", "retries", currentRetries)
+		pnc.workqueue.AddRateLimited(podKey)
+		return
+	} else {
+		logger.Error(err, "dropped request", "retries", currentRetries)
+		metrics.DroppedRequests.WithLabelValues(string(dynamicAttachmentRequest.Type)).Inc()
+		if pod := pnc.requestPod(dynamicAttachmentRequest); pod != nil {
+			pnc.Eventf(pod, corev1.EventTypeWarning, "RequestDropped", droppedRequestEventFormat(dynamicAttachmentRequest, currentRetries, err))
+		}
+	}
+	if dynamicAttachmentRequest.Type == finalize {
+		pnc.abandonPodFinalization(klog.NewContext(context.Background(), logger), dynamicAttachmentRequest)
+	}
+	pnc.workqueue.Forget(podKey)
+	if remainingRequests := pnc.pendingRequests.pop(podKey); remainingRequests > 0 {
+		pnc.workqueue.Add(podKey)
+	}
+}
+
+// recordLatency observes the time elapsed since the networks annotation
+// update the successfully handled request stems from: it accounts for the
+// time the request spent queued - retries included - and for the delegate
+// invocations.
+func (pnc *PodNetworksController) recordLatency(dynamicAttachmentRequest *DynamicAttachmentRequest) {
+	if pnc.dryRun || dynamicAttachmentRequest.annotationUpdatedAt.IsZero() {
 		return
 	}
+	metrics.E2ELatency.WithLabelValues(string(dynamicAttachmentRequest.Type)).Observe(
+		pnc.clock.Since(dynamicAttachmentRequest.annotationUpdatedAt).Seconds())
+}
 
-	pnc.workqueue.Forget(dynamicAttachmentRequest)
+// waitsForPodToRun indicates if the request - which needs the network namespace
+// of a pod not running yet - is to be retried until the pod runs: the wait is
+// given up on once the pod terminates, or after podNotRunningTimeout.
+func (pnc *PodNetworksController) waitsForPodToRun(dynamicAttachmentRequest *DynamicAttachmentRequest) bool {
+	pod, err := pnc.podsLister.Pods(dynamicAttachmentRequest.PodNamespace).Get(dynamicAttachmentRequest.PodName)
+	if err != nil || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return false
+	}
+	if dynamicAttachmentRequest.waitingForPodSince.IsZero() {
+		dynamicAttachmentRequest.waitingForPodSince = pnc.clock.Now()
+	}
+	return pnc.clock.Since(dynamicAttachmentRequest.waitingForPodSince) < podNotRunningTimeout
+}
+
+// requestPod returns the pod the request refers to, or nil if it is gone.
+func (pnc *PodNetworksController) requestPod(dynamicAttachmentRequest *DynamicAttachmentRequest) *corev1.Pod {
+	if dynamicAttachmentRequest.deletedPod != nil {
+		return dynamicAttachmentRequest.deletedPod
+	}
+	pod, err := pnc.currentPod(dynamicAttachmentRequest)
+	if err != nil {
+		return nil
+	}
+	return pod
+}
+
+// currentPod returns the cached pod the request refers to; a NotFound error is
+// returned when the pod was recreated since the request was issued - i.e. its
+// UID changed -, thus the stale request is not acted upon the new pod - e.g.
+// on its network namespace.
+func (pnc *PodNetworksController) currentPod(dynamicAttachmentRequest *DynamicAttachmentRequest) (*corev1.Pod, error) {
+	pod, err := pnc.podsLister.Pods(dynamicAttachmentRequest.PodNamespace).Get(dynamicAttachmentRequest.PodName)
+	if err != nil {
+		return nil, err
+	}
+	if dynamicAttachmentRequest.PodUID != "" && pod.GetUID() != dynamicAttachmentRequest.PodUID {
+		dynamicAttachmentRequest.logger().Info(
+			"the pod was recreated since the request was issued",
+			"requestedUID", dynamicAttachmentRequest.PodUID,
+			"uid", pod.GetUID())
+		return nil, apierrors.NewNotFound(corev1.Resource("pods"), dynamicAttachmentRequest.PodName)
+	}
+	return pod, nil
 }
 
 func (pnc *PodNetworksController) handlePodUpdate(oldObj interface{}, newObj interface{}) {
 	oldPod := oldObj.(*corev1.Pod)
 	newPod := newObj.(*corev1.Pod)
 
-	const (
-		add    DynamicAttachmentRequestType = "add"
-		remove DynamicAttachmentRequestType = "remove"
-	)
-
-	if reflect.DeepEqual(oldPod.Annotations, newPod.Annotations) {
+	if !pnc.isPodSelected(newPod) {
+		return
+	}
+	if isTerminating(newPod) && hasPodFinalizer(newPod) {
+		// the finalizer is removed even if disabled in the meantime, since it
+		// would otherwise block the pod's deletion
+		pnc.enqueue(
+			&DynamicAttachmentRequest{
+				PodName:      newPod.GetName(),
+				PodUID:       newPod.GetUID(),
+				PodNamespace: newPod.GetNamespace(),
+				Type:         finalize,
+			})
+		return
+	}
+	if oldPod.Annotations[nadv1.NetworkAttachmentAnnot] == newPod.Annotations[nadv1.NetworkAttachmentAnnot] {
+		// only the networks annotation is relevant; skip parsing it
 		return
 	}
 	podNamespace := oldPod.GetNamespace()
 	podName := oldPod.GetName()
-	klog.V(logging.Debug).Infof("pod [%s] updated", annotations.NamespacedName(podNamespace, podName))
+	klog.V(logging.Debug).InfoS("pod updated", "pod", podName, "namespace", podNamespace)
 
-	oldNetworkSelectionElements, err := networkSelectionElements(oldPod.Annotations, podNamespace)
+	newNetworkSelectionElements, err := networkSelectionElements(newPod.Annotations, podNamespace)
 	if err != nil {
-		klog.Errorf("failed to compute the network selection elements from the *old* pod")
+		klog.ErrorS(err, "failed to compute the network selection elements from the *new* pod", "pod", podName, "namespace", podNamespace)
 		return
 	}
-
-	newNetworkSelectionElements, err := networkSelectionElements(newPod.Annotations, podNamespace)
-	if err != nil {
-		klog.Errorf("failed to compute the network selection elements from the *new* pod")
+	if reason := pnc.networksUpdateRejection(newPod, newNetworkSelectionElements); reason != "" {
+		klog.InfoS("rejecting the networks update", "pod", podName, "namespace", podNamespace, "reason", reason)
+		pnc.Eventf(newPod, corev1.EventTypeWarning, "NetworksUpdateRejected", rejectedNetworksUpdateEventFormat(newPod, reason))
 		return
 	}
 
+	oldNetworkSelectionElements, isOldAnnotationMalformed := pnc.previousNetworks(oldPod, newPod)
 	toAdd := exclusiveNetworks(newNetworkSelectionElements, oldNetworkSelectionElements)
-	klog.Infof("%d attachments to add to pod %s", len(toAdd), annotations.NamespacedName(podNamespace, podName))
+	if isOldAnnotationMalformed {
+		toAdd = unattachedNetworks(newPod, toAdd)
+	}
+	if isTerminating(newPod) && len(toAdd) > 0 {
+		// the pod's network namespace is being torn down
+		klog.InfoS("not adding attachments to a terminating pod", "pod", podName, "namespace", podNamespace, "attachments", len(toAdd))
+		toAdd = nil
+	}
+	klog.InfoS("computed the attachments to add", "pod", podName, "namespace", podNamespace, "attachments", len(toAdd))
 
-	netnsPath, err := pnc.netnsPath(newPod)
+	netnsPath, _, err := pnc.lookupNetNS(newPod)
 	if err != nil {
-		klog.Errorf("failed to figure out the pod's network namespace: %v", err)
+		// the network namespace will be resolved - retrying as needed, and
+		// reporting the failures - when the request is processed
+		klog.InfoS("deferring the network namespace resolution", "pod", podName, "namespace", podNamespace, "reason", err)
+	}
+
+	toRemove := exclusiveNetworks(oldNetworkSelectionElements, newNetworkSelectionElements)
+	if _, hasNetworks := newPod.Annotations[nadv1.NetworkAttachmentAnnot]; isOldAnnotationMalformed && !hasNetworks {
+		// the annotation was deleted wholesale: lacking the previous networks,
+		// the interfaces the controller added are removed
+		toRemove = pnc.dynamicAttachments(newPod, appliedNetworks(newPod.Annotations[nadv1.NetworkStatusAnnot]))
+	}
+	klog.InfoS("computed the attachments to remove", "pod", podName, "namespace", podNamespace, "attachments", len(toRemove))
+	if !pnc.isNetworksUpdateAllowed(newPod, toAdd, toRemove) {
 		return
 	}
-	if len(toAdd) > 0 {
-		pnc.workqueue.Add(
+	pnc.enqueueNetworksUpdate(newPod, netnsPath, toRemove, toAdd)
+}
+
+// enqueueNetworksUpdate enqueues the requests removing, replacing, and adding
+// the pod's attachments, in that order.
+func (pnc *PodNetworksController) enqueueNetworksUpdate(
+	pod *corev1.Pod,
+	netnsPath string,
+	toRemove []*nadv1.NetworkSelectionElement,
+	toAdd []*nadv1.NetworkSelectionElement,
+) {
+	updatedAt := pnc.clock.Now()
+	// the attachments swapped behind an interface - e.g. when its addresses or
+	// network change - are replaced in a single request, thus the interface is
+	// never missing from the network-status, nor listed twice
+	replaced, replacing, toRemove, toAdd := replacements(toRemove, toAdd)
+	// since the requests of a pod are processed in order, enqueueing the
+	// removals first frees the interface names the added attachments reuse
+	if len(toRemove) > 0 {
+		pnc.enqueue(
 			&DynamicAttachmentRequest{
-				PodName:         podName,
-				PodNamespace:    podNamespace,
-				AttachmentNames: toAdd,
-				Type:            add,
-				PodNetNS:        netnsPath,
+				PodName:             pod.GetName(),
+				PodUID:              pod.GetUID(),
+				PodNamespace:        pod.GetNamespace(),
+				AttachmentNames:     toRemove,
+				Type:                remove,
+				PodNetNS:            netnsPath,
+				annotationUpdatedAt: updatedAt,
 			})
 	}
-
-	toRemove := exclusiveNetworks(oldNetworkSelectionElements, newNetworkSelectionElements)
-	klog.Infof("%d attachments to remove from pod %s", len(toRemove), annotations.NamespacedName(podNamespace, podName))
-	if len(toRemove) > 0 {
-		pnc.workqueue.Add(
+	if len(replacing) > 0 {
+		pnc.enqueue(
+			&DynamicAttachmentRequest{
+				PodName:                 pod.GetName(),
+				PodUID:                  pod.GetUID(),
+				PodNamespace:            pod.GetNamespace(),
+				AttachmentNames:         replacing,
+				ReplacedAttachmentNames: replaced,
+				Type:                    replace,
+				PodNetNS:                netnsPath,
+				annotationUpdatedAt:     updatedAt,
+			})
+	}
+	if len(toAdd) > 0 {
+		pnc.enqueue(
 			&DynamicAttachmentRequest{
-				PodName:         podName,
-				PodNamespace:    podNamespace,
-				AttachmentNames: toRemove,
-				Type:            remove,
-				PodNetNS:        netnsPath,
+				PodName:             pod.GetName(),
+				PodUID:              pod.GetUID(),
+				PodNamespace:        pod.GetNamespace(),
+				AttachmentNames:     toAdd,
+				Type:                add,
+				PodNetNS:            netnsPath,
+				annotationUpdatedAt: updatedAt,
 			})
 	}
 }
 
-func (pnc *PodNetworksController) addNetworks(dynamicAttachmentRequest *DynamicAttachmentRequest, pod *corev1.Pod) error {
-	for i := range dynamicAttachmentRequest.AttachmentNames {
-		netToAdd := dynamicAttachmentRequest.AttachmentNames[i]
-		klog.Infof("network to add: %v", netToAdd)
-
-		netAttachDef, err := pnc.netAttachDefLister.NetworkAttachmentDefinitions(netToAdd.Namespace).Get(netToAdd.Name)
-		if err != nil {
-			klog.Errorf("failed to access the networkattachmentdefinition %s/%s: %v", netToAdd.Namespace, netToAdd.Name, err)
-			return err
-		}
-		response, err := pnc.multusClient.InvokeDelegate(
-			multusapi.CreateDelegateRequest(
-				multuscni.CmdAdd,
-				podContainerID(pod),
-				dynamicAttachmentRequest.PodNetNS,
-				netToAdd.InterfaceRequest,
-				pod.GetNamespace(),
-				pod.GetName(),
-				string(pod.UID),
-				[]byte(netAttachDef.Spec.Config),
-			))
-
-		if err != nil {
-			return fmt.Errorf("failed to ADD delegate: %v", err)
-		}
-		klog.Infof("response: %v", *response.Result)
-
-		newIfaceStatus, err := annotations.AddDynamicIfaceToStatus(pod, netToAdd, response)
-		if err != nil {
-			return fmt.Errorf("failed to compute the updated network status: %v", err)
-		}
+// previousNetworks returns the networks the pod requested before the update.
+// A malformed previous annotation - reported on the pod - is indicated, the pod
+// being handled as if it had no networks: the new networks are still honored,
+// but the interfaces the network-status already lists are not added again.
+func (pnc *PodNetworksController) previousNetworks(oldPod *corev1.Pod, newPod *corev1.Pod) ([]*nadv1.NetworkSelectionElement, bool) {
+	oldNetworkSelectionElements, err := networkSelectionElements(oldPod.Annotations, oldPod.GetNamespace())
+	if err != nil {
+		klog.ErrorS(err, "ignoring the malformed network selection elements of the *old* pod", "pod", oldPod.GetName(), "namespace", oldPod.GetNamespace())
+		pnc.Eventf(newPod, corev1.EventTypeWarning, "MalformedNetworksAnnotation", malformedOldNetworksEventFormat(newPod, err))
+		return nil, true
+	}
+	return oldNetworkSelectionElements, false
+}
+
+// networksUpdateRejection returns why the update of the pod's networks - to
+// the requested ones - is rejected, or an empty string when it is not.
+func (pnc *PodNetworksController) networksUpdateRejection(pod *corev1.Pod, networks []*nadv1.NetworkSelectionElement) string {
+	if pod.Spec.HostNetwork {
+		// the pod's network namespace is the host's
+		return "the pod uses the host network"
+	}
+	if isMirrorPod(pod) {
+		// the kubelet owns the static pods; their mirror's network-status
+		// cannot be written back
+		return "the pod mirrors a static pod, managed by the kubelet"
+	}
+	if pnc.maxAttachmentsPerPod > 0 && len(networks) > pnc.maxAttachmentsPerPod {
+		metrics.RejectedNetworksUpdates.WithLabelValues(tooManyAttachmentsReason).Inc()
+		return fmt.Sprintf("%d networks requested, at most %d are allowed", len(networks), pnc.maxAttachmentsPerPod)
+	}
+	if invalidIfaces := invalidInterfaceNames(networks); len(invalidIfaces) > 0 {
+		return strings.Join(invalidIfaces, "; ")
+	}
+	if duplicateIfaces := duplicateInterfaceNames(networks); len(duplicateIfaces) > 0 {
+		return fmt.Sprintf("interfaces %s are requested by multiple networks", strings.Join(duplicateIfaces, ","))
+	}
+	return ""
+}
+
+// handlePodDelete removes all the interfaces listed in the network-status of a
+// deleted pod, thus allowing the CNI plugins to release any external
+// resources - e.g. IPAM leases - they allocated for them. Since the pod's
+// network namespace may already be gone, the removal is attempted without it.
+func (pnc *PodNetworksController) handlePodDelete(obj interface{}) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			klog.InfoS("unexpected object deleted", "object", obj)
+			return
+		}
+		pod, ok = tombstone.Obj.(*corev1.Pod)
+		if !ok {
+			klog.InfoS("unexpected object in the deleted object tombstone", "object", tombstone.Obj)
+			return
+		}
+	}
+	if !pnc.isPodSelected(pod) {
+		return
+	}
+	logger := klog.LoggerWithValues(klog.Background(), "pod", pod.GetName(), "namespace", pod.GetNamespace())
+	logger.V(logging.Debug).Info("pod deleted")
+
+	currentNetworks, err := networkStatus(pod.Annotations)
+	if err != nil {
+		logger.V(logging.Debug).Info("nothing to clean up", "reason", err)
+		pnc.podStates.forget(annotations.NamespacedName(pod.GetNamespace(), pod.GetName()))
+		return
+	}
+	_, toRemove := networkDrift(nil, currentNetworks)
+	logger.Info("computed the attachments to remove from the deleted pod", "attachments", len(toRemove))
+	if len(toRemove) == 0 {
+		pnc.podStates.forget(annotations.NamespacedName(pod.GetNamespace(), pod.GetName()))
+		return
+	}
+
+	netnsPath, err := pnc.netnsPath(pod)
+	if err != nil {
+		logger.Info("removing the attachments of the deleted pod without its network namespace", "reason", err)
+	}
+	pnc.enqueue(
+		&DynamicAttachmentRequest{
+			PodName:         pod.GetName(),
+			PodUID:          pod.GetUID(),
+			PodNamespace:    pod.GetNamespace(),
+			AttachmentNames: toRemove,
+			Type:            remove,
+			PodNetNS:        netnsPath,
+			deletedPod:      pod,
+		})
+}
+
+// addNetworks adds the request's attachments to the pod, then records them
+// all in the pod's network-status at once. Requests are transactional: if
+// adding one of the attachments - or recording them - fails, the attachments
+// previously added by the request are removed before returning the error, thus
+// allowing the request to be retried from a clean slate.
+func (pnc *PodNetworksController) addNetworks(
+	ctx context.Context,
+	dynamicAttachmentRequest *DynamicAttachmentRequest,
+	pod *corev1.Pod,
+) error {
+	logger := klog.FromContext(ctx)
+	addedNetworks := make([]*nadv1.NetworkSelectionElement, 0, len(dynamicAttachmentRequest.AttachmentNames))
+	responses := make([]*multusapi.Response, 0, len(dynamicAttachmentRequest.AttachmentNames))
+	pickedNames := map[string]string{}
+	if err := pnc.ensurePodFinalizer(ctx, pod); err != nil {
+		return err
+	}
+	summary := &batchSummary{}
+	defer pnc.summarizeBatch(pod, dynamicAttachmentRequest, attachSummaryReason, "added", summary)
+	for i := range dynamicAttachmentRequest.AttachmentNames {
+		netToAdd := dynamicAttachmentRequest.AttachmentNames[i]
+		if err := validateAttachment(netToAdd); err != nil {
+			logger.Error(err, "skipping invalid attachment", "nad", annotations.NamespacedName(netToAdd.Namespace, netToAdd.Name), "interface", netToAdd.InterfaceRequest)
+			pnc.failAttachment(dynamicAttachmentRequest, pod, summary, netToAdd,
+				"InvalidInterfaceRequest", invalidIfaceEventFormat(pod, netToAdd, err), err)
+			continue
+		}
+		if err := pnc.checkNetworkReference(ctx, pod, netToAdd); errors.Is(err, errCrossNamespaceReferenceDenied) {
+			logger.Info("skipping attachment to a network of another namespace", "nad", annotations.NamespacedName(netToAdd.Namespace, netToAdd.Name), "reason", err)
+			pnc.failAttachment(dynamicAttachmentRequest, pod, summary, netToAdd,
+				"CrossNamespaceReferenceDenied", crossNamespaceReferenceDeniedEventFormat(pod, netToAdd), err)
+			continue
+		} else if err != nil {
+			return pnc.abortAttachment(ctx, dynamicAttachmentRequest, pod, summary, netToAdd, addedNetworks, err)
+		}
+		if netToAdd.InterfaceRequest == "" {
+			namedNetToAdd, err := pnc.namedAttachment(pod, netToAdd, append(addedNetworks, dynamicAttachmentRequest.AttachmentNames...), pickedNames)
+			if err != nil {
+				return pnc.abortAttachment(ctx, dynamicAttachmentRequest, pod, summary, netToAdd, addedNetworks, err)
+			}
+			netToAdd = namedNetToAdd
+		}
+		pnc.recordInterfaceState(ctx, pod, netToAdd, InterfaceAttaching, "")
+		response, err := pnc.addNetwork(ctx, dynamicAttachmentRequest, pod, netToAdd)
+		if errors.Is(err, errNetAttachDefNotFound) {
+			// retrying is pointless; once the network-attachment-definition is
+			// created, the pods requesting it are reconciled
+			logger.Info("skipping attachment to a missing network-attachment-definition", "nad", annotations.NamespacedName(netToAdd.Namespace, netToAdd.Name))
+			pnc.recordInterfaceState(ctx, pod, netToAdd, InterfaceFailed, "the network-attachment-definition does not exist")
+			pnc.failAttachment(dynamicAttachmentRequest, pod, summary, netToAdd,
+				"NetworkAttachmentDefinitionNotFound", netAttachDefNotFoundEventFormat(pod, netToAdd), err)
+			continue
+		}
+		if errors.Is(err, errInvalidNetAttachDefConfig) {
+			// retrying is pointless; once the network-attachment-definition is
+			// fixed, the pods requesting it are reconciled
+			logger.Info("skipping attachment to a misconfigured network-attachment-definition", "nad", annotations.NamespacedName(netToAdd.Namespace, netToAdd.Name), "reason", err)
+			pnc.recordInterfaceState(ctx, pod, netToAdd, InterfaceFailed, err.Error())
+			pnc.failAttachment(dynamicAttachmentRequest, pod, summary, netToAdd,
+				"InvalidNetworkAttachmentDefinition", invalidNetAttachDefEventFormat(pod, netToAdd, err), err)
+			continue
+		}
+		if errors.Is(err, errNoDelegateResult) {
+			// the delegate succeeded, thus the interface may exist
+			addedNetworks = append(addedNetworks, netToAdd)
+		}
+		if err == nil {
+			addedNetworks = append(addedNetworks, netToAdd)
+			responses = append(responses, response)
+			err = pnc.applySysctls(ctx, dynamicAttachmentRequest, pod, netToAdd)
+		}
+		if err != nil {
+			err = pnc.abortAttachment(ctx, dynamicAttachmentRequest, pod, summary, netToAdd, addedNetworks, err)
+			pnc.recordInterfaceState(ctx, pod, netToAdd, InterfaceFailed, err.Error())
+			return err
+		}
+	}
+	if pnc.dryRun || len(addedNetworks) == 0 {
+		return nil
+	}
+	return pnc.recordAddedNetworks(ctx, dynamicAttachmentRequest, pod, summary, addedNetworks, responses, pickedNames)
+}
+
+// namedAttachment returns the attachment requesting the interface name picked
+// for it - see implicitInterfaceName -; the picked name is recorded in
+// `pickedNames`, since it is persisted in the network-status, where removals
+// look it up.
+func (pnc *PodNetworksController) namedAttachment(
+	pod *corev1.Pod,
+	network *nadv1.NetworkSelectionElement,
+	requestedNetworks []*nadv1.NetworkSelectionElement,
+	pickedNames map[string]string,
+) (*nadv1.NetworkSelectionElement, error) {
+	ifaceName, err := pnc.implicitInterfaceName(pod, network, requestedNetworks)
+	if err != nil {
+		return nil, err
+	}
+	pickedNames[stickyInterfaceNameKey(network)] = ifaceName
+	namedNetwork := *network
+	namedNetwork.InterfaceRequest = ifaceName
+	return &namedNetwork, nil
+}
+
+// failAttachment reports the attachment could not be added: on the pod - as a
+// `reason` event -, in the request's summary, and in the audit log.
+func (pnc *PodNetworksController) failAttachment(
+	dynamicAttachmentRequest *DynamicAttachmentRequest,
+	pod *corev1.Pod,
+	summary *batchSummary,
+	network *nadv1.NetworkSelectionElement,
+	reason string,
+	message string,
+	err error,
+) {
+	pnc.Eventf(pod, corev1.EventTypeWarning, reason, message)
+	summary.fail(network, err)
+	pnc.audit(dynamicAttachmentRequest, pod, audit.OperationAdd, err, network)
+}
+
+// abortAttachment reports the attachment could not be added - see
+// failAttachment -, then rolls back the attachments the request added so far;
+// the error is returned, for the request to be retried from a clean slate.
+func (pnc *PodNetworksController) abortAttachment(
+	ctx context.Context,
+	dynamicAttachmentRequest *DynamicAttachmentRequest,
+	pod *corev1.Pod,
+	summary *batchSummary,
+	network *nadv1.NetworkSelectionElement,
+	addedNetworks []*nadv1.NetworkSelectionElement,
+	err error,
+) error {
+	pnc.failAttachment(dynamicAttachmentRequest, pod, summary, network, "AddInterfaceFailed", addIfaceFailedEventFormat(pod, network, err), err)
+	pnc.rollbackNetworks(ctx, dynamicAttachmentRequest, pod, addedNetworks)
+	return err
+}
+
+// recordAddedNetworks records the attachments the request added in the pod's
+// network-status - rolling them back when that fails -, along with their
+// interfaces' names and states.
+func (pnc *PodNetworksController) recordAddedNetworks(
+	ctx context.Context,
+	dynamicAttachmentRequest *DynamicAttachmentRequest,
+	pod *corev1.Pod,
+	summary *batchSummary,
+	addedNetworks []*nadv1.NetworkSelectionElement,
+	responses []*multusapi.Response,
+	pickedNames map[string]string,
+) error {
+	err := pnc.updatePodNetworkStatus(
+		ctx,
+		pod,
+		addIfacesToStatus(addedNetworks, responses),
+		addedNetworks,
+		dynamicAttachmentRequest.PodNetNS)
+	if err != nil {
+		for _, addedNetwork := range addedNetworks {
+			pnc.Eventf(pod, corev1.EventTypeWarning, "AddInterfaceFailed", addIfaceFailedEventFormat(pod, addedNetwork, err))
+			summary.fail(addedNetwork, err)
+		}
+		pnc.rollbackNetworks(ctx, dynamicAttachmentRequest, pod, addedNetworks)
+		pnc.recordInterfacesState(ctx, pod, addedNetworks, InterfaceFailed, err.Error())
+		pnc.audit(dynamicAttachmentRequest, pod, audit.OperationAdd, err, addedNetworks...)
+		return err
+	}
+	pnc.recordInterfacesState(ctx, pod, addedNetworks, InterfaceAttached, "")
+	pnc.audit(dynamicAttachmentRequest, pod, audit.OperationAdd, nil, addedNetworks...)
+	summary.succeeded = len(addedNetworks)
+	if err := pnc.recordStickyInterfaceNames(ctx, pod, pickedNames); err != nil {
+		// the interfaces are added; only their names may change when re-added
+		klog.FromContext(ctx).Error(err, "failed to record the picked interface names")
+	}
+	if pnc.verifyNetworkStatus {
+		if err := pnc.ensureInterfacesInNetworkStatus(ctx, pod, addedNetworks, responses); err != nil {
+			return err
+		}
+	}
+
+	for i := range addedNetworks {
+		pnc.Eventf(pod, corev1.EventTypeNormal, "AddedInterface", addIfaceEventFormat(pod, addedNetworks[i], responses[i].Result))
+	}
+	return nil
+}
+
+// addNetwork invokes the delegate adding the attachment to the pod, returning
+// its response; a nil response is returned in dry-run mode.
+func (pnc *PodNetworksController) addNetwork(
+	ctx context.Context,
+	dynamicAttachmentRequest *DynamicAttachmentRequest,
+	pod *corev1.Pod,
+	netToAdd *nadv1.NetworkSelectionElement,
+) (*multusapi.Response, error) {
+	logger := klog.LoggerWithValues(
+		klog.FromContext(ctx),
+		"nad", annotations.NamespacedName(netToAdd.Namespace, netToAdd.Name),
+		"interface", netToAdd.InterfaceRequest)
+	logger.Info("adding network")
+
+	netAttachDef, err := pnc.netAttachDefLister.NetworkAttachmentDefinitions(netToAdd.Namespace).Get(netToAdd.Name)
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("%w: %v", errNetAttachDefNotFound, err)
+	}
+	if err != nil {
+		logger.Error(err, "failed to access the network-attachment-definition")
+		return nil, err
+	}
+	if err := validateNetAttachDefConfig(netAttachDef); err != nil {
+		return nil, err
+	}
+	netConfig, err := delegateConfig([]byte(netAttachDef.Spec.Config), pod, netToAdd)
+	if err != nil {
+		return nil, newTerminalError(fmt.Errorf("failed to compute the delegate configuration: %v", err))
+	}
+	if pnc.dryRun {
+		logger.Info("dry-run: not adding network")
+		pnc.Eventf(pod, corev1.EventTypeNormal, "DryRunAddInterface", dryRunEventFormat(pod, "add", netToAdd))
+		return nil, nil
+	}
+	netnsPath, containerID, err := pnc.attachmentSandbox(dynamicAttachmentRequest, pod, netToAdd)
+	if err != nil {
+		return nil, err
+	}
+	response, err := pnc.invokeDelegate(
+		ctx,
+		netToAdd,
+		pnc.delegateRequest(ctx, multuscni.CmdAdd, containerID, netnsPath, netToAdd.InterfaceRequest, pod, netConfig))
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to ADD delegate: %v", err)
+	}
+	if response == nil || response.Result == nil {
+		return nil, fmt.Errorf("failed to ADD delegate: %w", errNoDelegateResult)
+	}
+	logger.V(logging.Debug).Info("delegate replied", "result", response.Result)
+	return response, nil
+}
+
+// ensureInterfacesInNetworkStatus reads the pod back from the API server,
+// confirming its network-status lists the added interfaces; when a concurrent
+// update dropped any of them, the network-status is written again, and
+// verified anew.
+func (pnc *PodNetworksController) ensureInterfacesInNetworkStatus(
+	ctx context.Context,
+	pod *corev1.Pod,
+	addedNetworks []*nadv1.NetworkSelectionElement,
+	responses []*multusapi.Response,
+) error {
+	logger := klog.FromContext(ctx)
+	for writes := 1; ; writes++ {
+		currentPod, err := pnc.k8sClientSet.CoreV1().Pods(pod.GetNamespace()).Get(ctx, pod.GetName(), metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to read back the network-status of pod %s: %v", pod.GetName(), err)
+		}
+		var missingNetworks []*nadv1.NetworkSelectionElement
+		var missingResponses []*multusapi.Response
+		for i := range addedNetworks {
+			if !isInterfaceInNetworkStatus(currentPod, addedNetworks[i]) {
+				missingNetworks = append(missingNetworks, addedNetworks[i])
+				missingResponses = append(missingResponses, responses[i])
+			}
+		}
+		if len(missingNetworks) == 0 {
+			setNetworkStatus(pod, currentPod.Annotations[nadv1.NetworkStatusAnnot])
+			return nil
+		}
+		if writes == maxStatusWrites {
+			return fmt.Errorf(
+				"the network-status of pod %s is missing %d of the added interfaces after %d writes",
+				pod.GetName(),
+				len(missingNetworks),
+				writes)
+		}
+
+		logger.Info("the network-status lost added interfaces; writing them again", "interfaces", len(missingNetworks), "writes", writes)
+		if err := pnc.updatePodNetworkStatus(ctx, currentPod, addIfacesToStatus(missingNetworks, missingResponses), missingNetworks, ""); err != nil {
+			return err
+		}
+	}
+}
+
+// unattachedNetworks returns the networks whose interface the pod's
+// network-status does not list.
+func unattachedNetworks(pod *corev1.Pod, networks []*nadv1.NetworkSelectionElement) []*nadv1.NetworkSelectionElement {
+	var unattached []*nadv1.NetworkSelectionElement
+	for _, network := range networks {
+		if !isInterfaceInNetworkStatus(pod, network) {
+			unattached = append(unattached, network)
+		}
+	}
+	return unattached
+}
+
+// isInterfaceInNetworkStatus indicates if the pod's network-status lists the
+// interface attached to the network.
+func isInterfaceInNetworkStatus(pod *corev1.Pod, network *nadv1.NetworkSelectionElement) bool {
+	currentNetworks, err := networkStatus(pod.Annotations)
+	if err != nil {
+		return false
+	}
+	netName := annotations.NamespacedName(network.Namespace, network.Name)
+	for _, currentNetwork := range currentNetworks {
+		if currentNetwork.Name == netName && currentNetwork.Interface == network.InterfaceRequest {
+			return true
+		}
+	}
+	return false
+}
+
+// rollbackNetworks removes - in reverse order - the attachments added by a
+// request that failed midway.
+func (pnc *PodNetworksController) rollbackNetworks(
+	ctx context.Context,
+	dynamicAttachmentRequest *DynamicAttachmentRequest,
+	pod *corev1.Pod,
+	addedNetworks []*nadv1.NetworkSelectionElement,
+) {
+	if len(addedNetworks) == 0 {
+		return
+	}
+
+	networksToRollback := make([]*nadv1.NetworkSelectionElement, 0, len(addedNetworks))
+	for i := len(addedNetworks) - 1; i >= 0; i-- {
+		networksToRollback = append(networksToRollback, addedNetworks[i])
+	}
+	logger := klog.FromContext(ctx)
+	logger.Info("rolling back the added attachments", "attachments", len(networksToRollback))
+	rollbackRequest := &DynamicAttachmentRequest{
+		ID:              dynamicAttachmentRequest.ID,
+		PodName:         dynamicAttachmentRequest.PodName,
+		PodNamespace:    dynamicAttachmentRequest.PodNamespace,
+		AttachmentNames: networksToRollback,
+		Type:            remove,
+		PodNetNS:        dynamicAttachmentRequest.PodNetNS,
+		rollback:        true,
+	}
+	if err := pnc.removeNetworks(ctx, rollbackRequest, pod); err != nil {
+		logger.Error(err, "failed to rollback the added attachments")
+	}
+}
+
+func (pnc *PodNetworksController) removeNetworks(
+	ctx context.Context,
+	dynamicAttachmentRequest *DynamicAttachmentRequest,
+	pod *corev1.Pod,
+) error {
+	// the deleted pods have no interface states left to update
+	recordsStates := dynamicAttachmentRequest.deletedPod == nil
+	summary := &batchSummary{}
+	defer pnc.summarizeBatch(pod, dynamicAttachmentRequest, detachSummaryReason, "removed", summary)
+	for _, netToRemove := range pnc.attachedInterfaces(ctx, pod, dynamicAttachmentRequest.AttachmentNames) {
+		if recordsStates {
+			pnc.recordInterfaceState(ctx, pod, netToRemove, InterfaceDetaching, "")
+		}
+		if err := pnc.removeNetwork(ctx, dynamicAttachmentRequest, pod, netToRemove); err != nil {
+			if errors.Is(err, errRemovedIfaceStatusNotUpdated) {
+				// the interface is gone - its removal being retried nonetheless
+				pnc.Eventf(pod, corev1.EventTypeWarning, "RemoveStatusUpdateFailed", removeStatusUpdateFailedEventFormat(pod, netToRemove, err))
+			} else {
+				pnc.Eventf(pod, corev1.EventTypeWarning, "RemoveInterfaceFailed", removeIfaceFailedEventFormat(pod, netToRemove, err))
+			}
+			if recordsStates {
+				pnc.recordInterfaceState(ctx, pod, netToRemove, InterfaceFailed, err.Error())
+			}
+			pnc.audit(dynamicAttachmentRequest, pod, audit.OperationRemove, err, netToRemove)
+			summary.fail(netToRemove, err)
+			return err
+		}
+		if recordsStates {
+			pnc.recordInterfaceState(ctx, pod, netToRemove, "", "")
+		}
+		pnc.audit(dynamicAttachmentRequest, pod, audit.OperationRemove, nil, netToRemove)
+		summary.succeeded++
+	}
+
+	return nil
+}
+
+// attachedInterfaces returns the networks to remove along with their interface
+// names; the implicitly named interfaces are looked up in the pod's
+// network-status, the networks not attached to the pod being left out. Each
+// interface is removed once: the implicitly named attachments never resolve to
+// an interface requested by name, nor to the one another implicitly named
+// attachment of the batch resolved to.
+func (pnc *PodNetworksController) attachedInterfaces(
+	ctx context.Context,
+	pod *corev1.Pod,
+	networks []*nadv1.NetworkSelectionElement,
+) []*nadv1.NetworkSelectionElement {
+	claimedIfaces := map[string]bool{}
+	for _, network := range networks {
+		if network.InterfaceRequest != "" {
+			claimedIfaces[network.InterfaceRequest] = true
+		}
+	}
+	if desiredNetworks, err := networkSelectionElements(pod.Annotations, pod.GetNamespace()); err == nil {
+		for _, network := range desiredNetworks {
+			if network.InterfaceRequest != "" {
+				claimedIfaces[network.InterfaceRequest] = true
+			}
+		}
+	}
+
+	netsToRemove := make([]*nadv1.NetworkSelectionElement, 0, len(networks))
+	for i := range networks {
+		netToRemove := networks[i]
+		if netToRemove.InterfaceRequest == "" {
+			ifaceName := pnc.implicitInterfaceNameInStatus(pod, netToRemove, claimedIfaces)
+			if ifaceName == "" {
+				klog.FromContext(ctx).Info("network is not attached to the pod", "nad", annotations.NamespacedName(netToRemove.Namespace, netToRemove.Name))
+				continue
+			}
+			claimedIfaces[ifaceName] = true
+			namedNetToRemove := *netToRemove
+			namedNetToRemove.InterfaceRequest = ifaceName
+			netToRemove = &namedNetToRemove
+		}
+		netsToRemove = append(netsToRemove, netToRemove)
+	}
+	pnc.sortByRemovalOrder(pod, netsToRemove)
+	return netsToRemove
+}
+
+// sortByRemovalOrder sorts the interfaces in the reverse order they were
+// added - as recorded in the pod's dynamic interfaces, since the network-status
+// lists them by name -, thus the plugins depending on a previously added
+// interface find it until they are done. The interfaces the controller did not
+// add are removed last, in the requested order.
+func (pnc *PodNetworksController) sortByRemovalOrder(pod *corev1.Pod, networks []*nadv1.NetworkSelectionElement) {
+	addOrder := map[string]int{}
+	for i, ifaceName := range pnc.dynamicInterfaces(pod) {
+		addOrder[ifaceName] = i
+	}
+	addedAt := func(network *nadv1.NetworkSelectionElement) int {
+		if i, wasFound := addOrder[network.InterfaceRequest]; wasFound {
+			return i
+		}
+		return -1
+	}
+	sort.SliceStable(networks, func(i, j int) bool {
+		return addedAt(networks[i]) > addedAt(networks[j])
+	})
+}
+
+func (pnc *PodNetworksController) removeNetwork(
+	ctx context.Context,
+	dynamicAttachmentRequest *DynamicAttachmentRequest,
+	pod *corev1.Pod,
+	netToRemove *nadv1.NetworkSelectionElement,
+) error {
+	if err := pnc.detachNetwork(ctx, dynamicAttachmentRequest, pod, netToRemove); err != nil {
+		return err
+	}
+	if pnc.dryRun {
+		return nil
+	}
+	return pnc.removedNetwork(ctx, dynamicAttachmentRequest, pod, netToRemove)
+}
+
+// detachNetwork removes the attachment's interface from the pod, leaving the
+// pod's network-status untouched.
+func (pnc *PodNetworksController) detachNetwork(
+	ctx context.Context,
+	dynamicAttachmentRequest *DynamicAttachmentRequest,
+	pod *corev1.Pod,
+	netToRemove *nadv1.NetworkSelectionElement,
+) error {
+	logger := klog.LoggerWithValues(
+		klog.FromContext(ctx),
+		"nad", annotations.NamespacedName(netToRemove.Namespace, netToRemove.Name),
+		"interface", netToRemove.InterfaceRequest)
+	logger.Info("removing network")
+
+	netAttachDef, err := pnc.netAttachDefLister.NetworkAttachmentDefinitions(netToRemove.Namespace).Get(netToRemove.Name)
+	if apierrors.IsNotFound(err) {
+		logger.Info("the network-attachment-definition does not exist: deleting the interface by name")
+		return pnc.detachOrphanedNetwork(ctx, dynamicAttachmentRequest, pod, netToRemove)
+	}
+	if err != nil {
+		logger.Error(err, "failed to access the network-attachment-definition")
+		return err
+	}
+
+	netConfig, err := delegateConfig([]byte(netAttachDef.Spec.Config), pod, netToRemove)
+	if err != nil {
+		logger.Info("failed to compute the delegate configuration: deleting the interface by name", "reason", err)
+		return pnc.detachOrphanedNetwork(ctx, dynamicAttachmentRequest, pod, netToRemove)
+	}
+	if pnc.dryRun {
+		logger.Info("dry-run: not removing network")
+		pnc.Eventf(pod, corev1.EventTypeNormal, "DryRunRemoveInterface", dryRunEventFormat(pod, "remove", netToRemove))
+		return nil
+	}
+	netnsPath, containerID, err := pnc.attachmentSandbox(dynamicAttachmentRequest, pod, netToRemove)
+	if err != nil {
+		return err
+	}
+	response, err := pnc.invokeDelegate(
+		ctx,
+		netToRemove,
+		pnc.delegateRequest(ctx, multuscni.CmdDel, containerID, netnsPath, netToRemove.InterfaceRequest, pod, netConfig))
+	if err != nil && isInterfaceNotFound(err) {
+		// removing an absent interface is no failure; its entry is dropped
+		logger.Info("the interface is already gone", "reason", err)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to remove delegate: %v", err)
+	}
+	if response != nil {
+		logger.V(logging.Debug).Info("delegate replied", "result", response.Result)
+	}
+	return nil
+}
+
+// detachOrphanedNetwork removes an attachment whose network-attachment-definition
+// no longer exists - e.g. it was deleted, or the networks annotation was
+// rewritten to reference another one for the same interface - or features an
+// unusable configuration. Without the network configuration the delegate
+// cannot be invoked, thus the interface is simply deleted by name from the
+// pod's network namespace; whatever the delegate allocated outside of it - e.g.
+// the IPAM leases - is left behind.
+func (pnc *PodNetworksController) detachOrphanedNetwork(
+	ctx context.Context,
+	dynamicAttachmentRequest *DynamicAttachmentRequest,
+	pod *corev1.Pod,
+	netToRemove *nadv1.NetworkSelectionElement,
+) error {
+	if pnc.dryRun {
+		klog.FromContext(ctx).Info("dry-run: not removing network")
+		pnc.Eventf(pod, corev1.EventTypeNormal, "DryRunRemoveInterface", dryRunEventFormat(pod, "remove", netToRemove))
+		return nil
+	}
+	netnsPath, _, err := pnc.attachmentSandbox(dynamicAttachmentRequest, pod, netToRemove)
+	if err != nil {
+		return err
+	}
+	if err := pnc.linkDeleter.Delete(netnsPath, netToRemove.InterfaceRequest); err != nil {
+		return fmt.Errorf("failed to delete the interface: %v", err)
+	}
+	return nil
+}
 
-		if err := pnc.updatePodNetworkStatus(pod, newIfaceStatus); err != nil {
-			return err
-		}
+// removedNetwork drops the removed attachment from the pod's network-status;
+// the removal is only reported once the network-status no longer lists it.
+func (pnc *PodNetworksController) removedNetwork(
+	ctx context.Context,
+	dynamicAttachmentRequest *DynamicAttachmentRequest,
+	pod *corev1.Pod,
+	netToRemove *nadv1.NetworkSelectionElement,
+) error {
+	if dynamicAttachmentRequest.deletedPod != nil {
+		// there is no network-status left to update
+		pnc.Eventf(pod, corev1.EventTypeNormal, "RemovedInterface", removeIfaceEventFormat(pod, netToRemove))
+		return nil
+	}
 
-		pnc.Eventf(pod, corev1.EventTypeNormal, "AddedInterface", addIfaceEventFormat(pod, netToAdd))
+	if err := pnc.updatePodNetworkStatus(ctx, pod, removeIfaceFromStatus(netToRemove), nil, ""); err != nil {
+		return fmt.Errorf("%w: %v", errRemovedIfaceStatusNotUpdated, err)
 	}
 
+	pnc.Eventf(pod, corev1.EventTypeNormal, "RemovedInterface", removeIfaceEventFormat(pod, netToRemove))
 	return nil
 }
 
-func (pnc *PodNetworksController) removeNetworks(dynamicAttachmentRequest *DynamicAttachmentRequest, pod *corev1.Pod) error {
-	for i := range dynamicAttachmentRequest.AttachmentNames {
-		netToRemove := dynamicAttachmentRequest.AttachmentNames[i]
-		klog.Infof("network to remove: %v", dynamicAttachmentRequest.AttachmentNames[i])
-
-		netAttachDef, err := pnc.netAttachDefLister.NetworkAttachmentDefinitions(netToRemove.Namespace).Get(netToRemove.Name)
-		if err != nil {
-			klog.Errorf("failed to access the network-attachment-definition %s/%s: %v", netToRemove.Namespace, netToRemove.Name, err)
-			return err
-		}
+// networkStatusUpdate computes the updated network-status of the pod.
+type networkStatusUpdate func(pod *corev1.Pod) (string, error)
 
-		response, err := pnc.multusClient.InvokeDelegate(
-			multusapi.CreateDelegateRequest(
-				multuscni.CmdDel,
-				podContainerID(pod),
-				dynamicAttachmentRequest.PodNetNS,
-				netToRemove.InterfaceRequest,
-				pod.GetNamespace(),
-				pod.GetName(),
-				string(pod.UID),
-				[]byte(netAttachDef.Spec.Config),
-			))
-		if err != nil {
-			return fmt.Errorf("failed to remove delegate: %v", err)
+// addIfacesToStatus appends the added interfaces - described by the delegate
+// responses - to the pod's network-status.
+func addIfacesToStatus(addedNetworks []*nadv1.NetworkSelectionElement, responses []*multusapi.Response) networkStatusUpdate {
+	return func(pod *corev1.Pod) (string, error) {
+		// the pod must not be mutated; only its network-status is relevant
+		statusPod := &corev1.Pod{ObjectMeta: *pod.ObjectMeta.DeepCopy()}
+		for i := range addedNetworks {
+			newIfaceStatus, err := annotations.AddDynamicIfaceToStatus(statusPod, addedNetworks[i], responses[i])
+			if err != nil {
+				return "", fmt.Errorf("failed to compute the updated network status: %v", err)
+			}
+			setNetworkStatus(statusPod, newIfaceStatus)
 		}
-		klog.Infof("response: %v", *response)
+		return statusPod.Annotations[nadv1.NetworkStatusAnnot], nil
+	}
+}
 
+func removeIfaceFromStatus(netToRemove *nadv1.NetworkSelectionElement) networkStatusUpdate {
+	return func(pod *corev1.Pod) (string, error) {
 		newIfaceStatus, err := annotations.DeleteDynamicIfaceFromStatus(pod, netToRemove)
 		if err != nil {
-			return fmt.Errorf(
+			return "", fmt.Errorf(
 				"failed to compute the dynamic network attachments after deleting network: %s, iface: %s: %v",
 				netToRemove.Name,
 				netToRemove.InterfaceRequest,
 				err,
 			)
 		}
-		if err := pnc.updatePodNetworkStatus(pod, newIfaceStatus); err != nil {
+		return newIfaceStatus, nil
+	}
+}
+
+// updatePodNetworkStatus writes the network-status computed by `updateStatus`,
+// conditioned on the pod's resource version. When the write conflicts with a
+// concurrent pod update, the pod is read again and its network-status
+// re-computed - thus never re-invoking the delegate merely because the write
+// lost a race. The dynamic interfaces - featuring the added networks' - are
+// recorded along, in the same write: being bookkeeping about the
+// network-status, they must never disagree with it; so is the network
+// namespace of the sandbox the networks were added to - when provided. The
+// written annotations, and the resulting resource version, are recorded on
+// the provided pod - thus never to be one of the pod lister's.
+func (pnc *PodNetworksController) updatePodNetworkStatus(
+	ctx context.Context,
+	pod *corev1.Pod,
+	updateStatus networkStatusUpdate,
+	addedNetworks []*nadv1.NetworkSelectionElement,
+	sandbox string,
+) error {
+	currentPod := pod
+	var updatedAnnotations map[string]string
+	var resourceVersion string
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		newIfaceStatus, err := updateStatus(currentPod)
+		if err != nil {
+			return err
+		}
+		dynamicIfaces, err := updatedDynamicInterfaces(pnc.dynamicInterfaces(currentPod), newIfaceStatus, addedNetworks)
+		if err != nil {
 			return err
 		}
+		updatedAnnotations = map[string]string{
+			nadv1.NetworkStatusAnnot:              newIfaceStatus,
+			pnc.bookkeepingKeys.DynamicInterfaces: dynamicIfaces,
+		}
+		if sandbox != "" {
+			updatedAnnotations[pnc.bookkeepingKeys.Sandbox] = sandbox
+		}
+		resourceVersion = currentPod.GetResourceVersion()
+		patch, err := podAnnotationsPatch(currentPod.GetResourceVersion(), currentPod.Annotations, updatedAnnotations)
+		if err != nil {
+			return fmt.Errorf("failed to compute the network-status patch for pod %s: %v", pod.GetName(), err)
+		}
+		if patch == nil {
+			klog.FromContext(ctx).V(logging.Debug).Info("network-status is up to date")
+			return nil
+		}
 
-		pnc.Eventf(pod, corev1.EventTypeNormal, "RemovedInterface", removeIfaceEventFormat(pod, netToRemove))
+		updatedPod, err := pnc.k8sClientSet.CoreV1().Pods(pod.GetNamespace()).Patch(
+			ctx,
+			pod.GetName(),
+			types.MergePatchType,
+			patch,
+			metav1.PatchOptions{})
+		if apierrors.IsConflict(err) {
+			klog.FromContext(ctx).Info("the network-status write conflicted with a pod update; retrying")
+			freshPod, getErr := pnc.k8sClientSet.CoreV1().Pods(pod.GetNamespace()).Get(ctx, pod.GetName(), metav1.GetOptions{})
+			if getErr != nil {
+				return getErr
+			}
+			currentPod = freshPod
+		}
+		if err != nil {
+			return err
+		}
+		resourceVersion = updatedPod.GetResourceVersion()
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update pod's network-status annotations for %s: %v", pod.GetName(), err)
 	}
-
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	for key, value := range updatedAnnotations {
+		pod.Annotations[key] = value
+	}
+	pod.SetResourceVersion(resourceVersion)
+	pnc.recordAppliedNetworks(pod, updatedAnnotations[nadv1.NetworkStatusAnnot])
 	return nil
 }
 
-func (pnc *PodNetworksController) updatePodNetworkStatus(pod *corev1.Pod, newIfaceStatus string) error {
-	pod.Annotations[nadv1.NetworkStatusAnnot] = newIfaceStatus
+// setNetworkStatus records the network-status on the provided pod; it must
+// *never* be invoked on the objects returned by the pod lister, since those
+// are shared by all the informer cache consumers.
+func setNetworkStatus(pod *corev1.Pod, networkStatus string) {
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	pod.Annotations[nadv1.NetworkStatusAnnot] = networkStatus
+}
 
-	if _, err := pnc.k8sClientSet.CoreV1().Pods(pod.GetNamespace()).Update(context.Background(), pod, metav1.UpdateOptions{}); err != nil {
-		return fmt.Errorf("failed to update pod's network-status annotations for %s: %v", pod.GetName(), err)
+// podAnnotationsPatch computes the JSON merge patch transitioning the pod's
+// annotations from `oldAnnotations` to `newAnnotations`; only the annotations
+// whose value changes are present in the patch, thus leaving every other pod
+// attribute untouched. The patch is conditioned on the provided resource
+// version - if any - thus failing with a conflict when the pod changed in the
+// meantime. A nil patch is returned when there is nothing to update.
+func podAnnotationsPatch(resourceVersion string, oldAnnotations map[string]string, newAnnotations map[string]string) ([]byte, error) {
+	updatedAnnotations := map[string]string{}
+	for key, value := range newAnnotations {
+		if oldValue, wasFound := oldAnnotations[key]; !wasFound || oldValue != value {
+			updatedAnnotations[key] = value
+		}
 	}
-	return nil
+	if len(updatedAnnotations) == 0 {
+		return nil, nil
+	}
+	metadata := map[string]interface{}{
+		"annotations": updatedAnnotations,
+	}
+	if resourceVersion != "" {
+		metadata["resourceVersion"] = resourceVersion
+	}
+	return json.Marshal(map[string]interface{}{"metadata": metadata})
 }
 
+// networkSelectionElements returns the pod's network selection elements; a pod
+// without the networks annotation does not request any network.
 func networkSelectionElements(podAnnotations map[string]string, podNamespace string) ([]*nadv1.NetworkSelectionElement, error) {
 	podNetworks, ok := podAnnotations[nadv1.NetworkAttachmentAnnot]
-	if !ok {
-		return nil, fmt.Errorf("the pod is missing the \"%s\" annotation on its annotations: %+v", nadv1.NetworkAttachmentAnnot, podAnnotations)
+	if !ok || podNetworks == "" {
+		return nil, nil
 	}
 	podNetworkSelectionElements, err := annotations.ParsePodNetworkAnnotations(podNetworks, podNamespace)
 	if err != nil {
-		klog.Errorf("failed to extract the network selection elements: %v", err)
+		klog.ErrorS(err, "failed to extract the network selection elements")
 		return nil, err
 	}
 	return podNetworkSelectionElements, nil
@@ -368,6 +1988,42 @@ func networkStatus(podAnnotations map[string]string) ([]nadv1.NetworkStatus, err
 	return netStatus, nil
 }
 
+// invalidInterfaceNames returns why the interface names requested by the
+// network selection elements which Linux would not accept are invalid; they
+// would otherwise fail deep in the delegates.
+func invalidInterfaceNames(networkSelectionElements []*nadv1.NetworkSelectionElement) []string {
+	var invalidIfaces []string
+	for _, netSelectionElement := range networkSelectionElements {
+		if netSelectionElement.InterfaceRequest == "" {
+			continue
+		}
+		if err := annotations.ValidateInterfaceName(netSelectionElement.InterfaceRequest); err != nil {
+			invalidIfaces = append(invalidIfaces, err.Error())
+		}
+	}
+	return invalidIfaces
+}
+
+// duplicateInterfaceNames returns the interface names requested by more than
+// one of the network selection elements.
+func duplicateInterfaceNames(networkSelectionElements []*nadv1.NetworkSelectionElement) []string {
+	requestedIfaces := map[string]int{}
+	var duplicateIfaces []string
+	for _, netSelectionElement := range networkSelectionElements {
+		if netSelectionElement.InterfaceRequest == "" {
+			continue
+		}
+		requestedIfaces[netSelectionElement.InterfaceRequest]++
+		if requestedIfaces[netSelectionElement.InterfaceRequest] == 2 {
+			duplicateIfaces = append(duplicateIfaces, netSelectionElement.InterfaceRequest)
+		}
+	}
+	return duplicateIfaces
+}
+
+// exclusiveNetworks returns the needles not found in the haystack; a needle
+// whose attachment is found in the haystack requesting different addresses is
+// returned as well, since its interface must be re-created to honor them.
 func exclusiveNetworks(
 	needles []*nadv1.NetworkSelectionElement,
 	haystack []*nadv1.NetworkSelectionElement) []*nadv1.NetworkSelectionElement {
@@ -376,21 +2032,142 @@ func exclusiveNetworks(
 
 	var unmatchedNetworks []*nadv1.NetworkSelectionElement
 	for needleNetName, needle := range setOfNeedles {
-		if _, ok := haystackSet[needleNetName]; !ok {
+		if match, ok := haystackSet[needleNetName]; !ok || !sameAddressRequests(needle, match) {
 			unmatchedNetworks = append(unmatchedNetworks, needle)
 		}
 	}
 	return unmatchedNetworks
 }
 
+// sameAddressRequests indicates if both network selection elements request
+// the same IPs - regardless of their order -, MAC address, and gateways.
+func sameAddressRequests(a *nadv1.NetworkSelectionElement, b *nadv1.NetworkSelectionElement) bool {
+	if !strings.EqualFold(a.MacRequest, b.MacRequest) {
+		return false
+	}
+	if !sameStrings(a.IPRequest, b.IPRequest) {
+		return false
+	}
+	if len(a.GatewayRequest) != len(b.GatewayRequest) {
+		return false
+	}
+	for i := range a.GatewayRequest {
+		if !a.GatewayRequest[i].Equal(b.GatewayRequest[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sortedA := append([]string{}, a...)
+	sortedB := append([]string{}, b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+	for i := range sortedA {
+		if sortedA[i] != sortedB[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// indexNetworkSelectionElements indexes the network selection elements by their
+// index key; the repeated attachments to a network not requesting an explicit
+// interface name are told apart by their ordinal.
 func indexNetworkSelectionElements(list []*nadv1.NetworkSelectionElement) map[string]*nadv1.NetworkSelectionElement {
 	indexedNetworkSelectionElements := make(map[string]*nadv1.NetworkSelectionElement)
+	occurrences := map[string]int{}
 	for k := range list {
-		indexedNetworkSelectionElements[networkSelectionElementIndexKey(*list[k])] = list[k]
+		indexKey := networkSelectionElementIndexKey(*list[k])
+		occurrences[indexKey]++
+		if occurrences[indexKey] > 1 {
+			indexKey = fmt.Sprintf("%s#%d", indexKey, occurrences[indexKey])
+		}
+		indexedNetworkSelectionElements[indexKey] = list[k]
 	}
 	return indexedNetworkSelectionElements
 }
 
+// implicitInterfaceName picks the interface name of an attachment not
+// requesting one: the name previously picked for its network - when still
+// available - or the lowest indexed `net<N>` name neither used by the pod's
+// interfaces, nor explicitly requested by the pod's networks or by the request's
+// attachments, nor previously picked for another network.
+func (pnc *PodNetworksController) implicitInterfaceName(
+	pod *corev1.Pod,
+	network *nadv1.NetworkSelectionElement,
+	requestedNetworks []*nadv1.NetworkSelectionElement,
+) (string, error) {
+	usedNames := map[string]struct{}{}
+	if _, hasStatus := pod.Annotations[nadv1.NetworkStatusAnnot]; hasStatus {
+		currentNetworks, err := networkStatus(pod.Annotations)
+		if err != nil {
+			return "", err
+		}
+		for _, currentNetwork := range currentNetworks {
+			usedNames[currentNetwork.Interface] = struct{}{}
+		}
+	}
+	desiredNetworks, err := networkSelectionElements(pod.Annotations, pod.GetNamespace())
+	if err != nil {
+		return "", err
+	}
+	for _, network := range append(desiredNetworks, requestedNetworks...) {
+		if network.InterfaceRequest != "" {
+			usedNames[network.InterfaceRequest] = struct{}{}
+		}
+	}
+
+	stickyNames := pnc.stickyInterfaceNames(pod)
+	if stickyName, wasPicked := stickyNames[stickyInterfaceNameKey(network)]; wasPicked {
+		if _, isUsed := usedNames[stickyName]; !isUsed {
+			return stickyName, nil
+		}
+	}
+	for _, stickyName := range stickyNames {
+		usedNames[stickyName] = struct{}{}
+	}
+
+	for i := 1; ; i++ {
+		ifaceName := fmt.Sprintf("%s%d", implicitInterfacePrefix, i)
+		if _, isUsed := usedNames[ifaceName]; !isUsed {
+			return ifaceName, nil
+		}
+	}
+}
+
+// implicitInterfaceNameInStatus returns the interface name the implicitly
+// named attachment of the network got: its sticky interface name, when listed
+// in the pod's network-status, or else the last network-status entry of the
+// network. The claimed interfaces are left out.
+func (pnc *PodNetworksController) implicitInterfaceNameInStatus(
+	pod *corev1.Pod,
+	network *nadv1.NetworkSelectionElement,
+	claimedIfaces map[string]bool,
+) string {
+	currentNetworks, err := networkStatus(pod.Annotations)
+	if err != nil {
+		return ""
+	}
+	netName := annotations.NamespacedName(network.Namespace, network.Name)
+	stickyName := pnc.stickyInterfaceNames(pod)[stickyInterfaceNameKey(network)]
+	ifaceName := ""
+	for i := range currentNetworks {
+		if currentNetworks[i].Name != netName || currentNetworks[i].Default || claimedIfaces[currentNetworks[i].Interface] {
+			continue
+		}
+		if currentNetworks[i].Interface == stickyName {
+			return stickyName
+		}
+		ifaceName = currentNetworks[i].Interface
+	}
+	return ifaceName
+}
+
 func networkSelectionElementIndexKey(netSelectionElement nadv1.NetworkSelectionElement) string {
 	if netSelectionElement.InterfaceRequest != "" {
 		return fmt.Sprintf(
@@ -406,43 +2183,199 @@ func networkSelectionElementIndexKey(netSelectionElement nadv1.NetworkSelectionE
 		netSelectionElement.Name)
 }
 
-// Eventf puts event into kubernetes events
+// invokeDelegate invokes the multus delegate, bounding the operation by the
+// configured delegate timeout; the time waiting for a delegate slot - when
+// the concurrent invocations are bounded - does not count against it.
+func (pnc *PodNetworksController) invokeDelegate(
+	ctx context.Context,
+	network *nadv1.NetworkSelectionElement,
+	request *multusapi.Request,
+) (*multusapi.Response, error) {
+	ctx, span := pnc.tracer.Start(
+		ctx,
+		"InvokeDelegate",
+		trace.WithAttributes(
+			attribute.String("command", request.Env["CNI_COMMAND"]),
+			attribute.String("nad", annotations.NamespacedName(network.Namespace, network.Name)),
+			attribute.String("interface", network.InterfaceRequest)))
+	if pnc.delegateSlots != nil {
+		select {
+		case pnc.delegateSlots <- struct{}{}:
+			defer func() { <-pnc.delegateSlots }()
+		case <-ctx.Done():
+			endSpan(span, ctx.Err())
+			return nil, ctx.Err()
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, pnc.delegateTimeout)
+	defer cancel()
+
+	response, err := pnc.multusClient.InvokeDelegateWithContext(ctx, request)
+	span.SetAttributes(attribute.String("result", operationResult(err)))
+	endSpan(span, err)
+	return response, err
+}
+
+// Eventf puts event into kubernetes events, unless an identical event was put
+// within the event deduplication window.
 func (pnc *PodNetworksController) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
-	if pnc != nil && pnc.recorder != nil {
-		pnc.recorder.Eventf(object, eventtype, reason, messageFmt, args...)
+	if pnc == nil || pnc.recorder == nil {
+		return
+	}
+	message := messageFmt
+	if len(args) > 0 {
+		message = fmt.Sprintf(messageFmt, args...)
+	}
+	if !pnc.events.shouldEmit(object, eventtype, reason, message, pnc.clock.Now(), pnc.eventDeduplicationWindow) {
+		klog.V(logging.Debug).InfoS("dropped a duplicate event", "reason", reason, "message", message)
+		return
 	}
+	pnc.recorder.Event(object, eventtype, reason, message)
+}
+
+// isPodSelected indicates if the controller manages the networks of the pod.
+func (pnc *PodNetworksController) isPodSelected(pod *corev1.Pod) bool {
+	return pnc.podSelector.Matches(labels.Set(pod.GetLabels()))
+}
+
+// isMirrorPod indicates if the pod is the API server's mirror of a static pod,
+// managed by the kubelet rather than through the API.
+func isMirrorPod(pod *corev1.Pod) bool {
+	_, isMirror := pod.Annotations[corev1.MirrorPodAnnotationKey]
+	return isMirror
 }
 
+// isTerminating indicates if the pod is being deleted; its interfaces can
+// still be removed - e.g. to release their IPAM leases - but not added.
+func isTerminating(pod *corev1.Pod) bool {
+	return pod.GetDeletionTimestamp() != nil
+}
+
+// netnsPath returns the path of the running pod's network namespace: the one
+// featured in the pod's netns annotation - when configured -, or the one the
+// container runtime resolves otherwise. The runtime is asked once, since the
+// informer handlers must not block.
 func (pnc *PodNetworksController) netnsPath(pod *corev1.Pod) (string, error) {
-	if containerID := podContainerID(pod); containerID != "" {
-		netns, err := pnc.containerRuntime.NetNS(containerID)
-		if err != nil {
-			return "", fmt.Errorf("failed to get netns for container [%s]: %w", containerID, err)
+	return pnc.resolveNetNS(pod, wait.Backoff{Steps: 1})
+}
+
+// netnsPathWithRetries returns the path of the running pod's network
+// namespace, asking the container runtime again - see netnsResolutionBackoff -
+// when the resolution fails transiently. Meant for the workers.
+func (pnc *PodNetworksController) netnsPathWithRetries(pod *corev1.Pod) (string, error) {
+	return pnc.resolveNetNS(pod, netnsResolutionBackoff)
+}
+
+func (pnc *PodNetworksController) resolveNetNS(pod *corev1.Pod, backoff wait.Backoff) (string, error) {
+	var netns, containerID string
+	err := retry.OnError(backoff, isTransientNetNSResolutionError, func() error {
+		var err error
+		netns, containerID, err = pnc.lookupNetNS(pod)
+		return err
+	})
+	if isTransientNetNSResolutionError(err) {
+		runtimeName, _, _ := strings.Cut(runningContainerIDURI(pod), containerIDSchemeSeparator)
+		metrics.NetNSResolutionErrors.WithLabelValues(runtimeName).Inc()
+		pnc.Eventf(pod, corev1.EventTypeWarning, "NetworkNamespaceResolutionFailed", netnsResolutionFailedEventFormat(pod, containerID, err))
+		return "", fmt.Errorf("failed to get netns for container [%s]: %w", containerID, err)
+	}
+	return netns, err
+}
+
+// lookupNetNS returns the path of the pod's network namespace, along with the
+// ID of the container it is resolved through.
+func (pnc *PodNetworksController) lookupNetNS(pod *corev1.Pod) (string, string, error) {
+	containerID, err := podContainerID(pod)
+	if err != nil {
+		return "", "", fmt.Errorf("pod %s: %w", annotations.NamespacedName(pod.GetNamespace(), pod.GetName()), err)
+	}
+	if containerID == "" {
+		return "", "", fmt.Errorf("pod %s: %w", annotations.NamespacedName(pod.GetNamespace(), pod.GetName()), errNoRunningContainers)
+	}
+	if netns := pod.Annotations[pnc.netnsAnnotation]; pnc.netnsAnnotation != "" && netns != "" {
+		return netns, containerID, nil
+	}
+	netns, err := pnc.containerRuntime.NetNS(containerID)
+	return netns, containerID, err
+}
+
+// isTransientNetNSResolutionError indicates if resolving the network namespace
+// again may succeed: the container runtime may not know the container yet, or
+// be momentarily unreachable; a pod without running containers, or whose
+// container ID is malformed, is not worth asking about again.
+func isTransientNetNSResolutionError(err error) bool {
+	return err != nil && !errors.Is(err, errNoRunningContainers) && !isTerminal(err)
+}
+
+// podContainerID returns the ID of the first running container of the pod;
+// since all the pod's containers share the same network namespace, any running
+// container can be used to resolve it. An empty string is returned when none
+// of the pod's containers is running.
+func podContainerID(pod *corev1.Pod) (string, error) {
+	containerIDURI := runningContainerIDURI(pod)
+	if containerIDURI == "" {
+		return "", nil
+	}
+	return parseContainerID(containerIDURI)
+}
+
+// runningContainerIDURI returns the container ID URI of the first running
+// container of the pod, as reported in its status. The ephemeral containers -
+// e.g. debug containers - share the pod's network namespace too; they are
+// consulted last, for the pods whose regular containers are all down.
+func runningContainerIDURI(pod *corev1.Pod) string {
+	for _, containerStatuses := range [][]corev1.ContainerStatus{
+		pod.Status.ContainerStatuses,
+		pod.Status.EphemeralContainerStatuses,
+	} {
+		for _, containerStatus := range containerStatuses {
+			if containerStatus.State.Running == nil || containerStatus.ContainerID == "" {
+				continue
+			}
+			return containerStatus.ContainerID
 		}
-		return netns, nil
 	}
-	return "", nil
+	return ""
 }
 
-func podContainerID(pod *corev1.Pod) string {
-	cidURI := pod.Status.ContainerStatuses[0].ContainerID
-	// format is docker://<cid>
-	parts := strings.Split(cidURI, "//")
-	if len(parts) > 1 {
-		return parts[1]
+// parseContainerID extracts the container ID from the `<runtime>://<id>` URI
+// reported in the container statuses - e.g. `containerd://<id>`,
+// `cri-o://<id>` or `docker://<id>`. The runtime is not interpreted, since the
+// container runtime interface the controller uses is configured independently.
+func parseContainerID(containerIDURI string) (string, error) {
+	runtimeName, containerID, found := strings.Cut(containerIDURI, containerIDSchemeSeparator)
+	if !found || runtimeName == "" || containerID == "" || strings.ContainsAny(containerID, "/ ") {
+		return "", newTerminalError(fmt.Errorf("malformed container ID %q: expected the <runtime>://<id> format", containerIDURI))
 	}
-	return cidURI
+	return containerID, nil
 }
 
-func addIfaceEventFormat(pod *corev1.Pod, network *nadv1.NetworkSelectionElement) string {
+// addIfaceEventFormat describes the added interface, along with the IPs - if
+// any - the delegate assigned to it.
+func addIfaceEventFormat(pod *corev1.Pod, network *nadv1.NetworkSelectionElement, result *cni100.Result) string {
+	ifaceName := network.InterfaceRequest
+	if ips := resultIPs(result); len(ips) > 0 {
+		ifaceName = fmt.Sprintf("%s (%s)", ifaceName, strings.Join(ips, ","))
+	}
 	return fmt.Sprintf(
 		"pod [%s]: added interface %s to network: %s",
 		annotations.NamespacedName(pod.GetNamespace(), pod.GetName()),
-		network.InterfaceRequest,
+		ifaceName,
 		network.Name,
 	)
 }
 
+func resultIPs(result *cni100.Result) []string {
+	if result == nil {
+		return nil
+	}
+	ips := make([]string, 0, len(result.IPs))
+	for _, ipConfig := range result.IPs {
+		ips = append(ips, ipConfig.Address.String())
+	}
+	return ips
+}
+
 func removeIfaceEventFormat(pod *corev1.Pod, network *nadv1.NetworkSelectionElement) string {
 	return fmt.Sprintf(
 		"pod [%s]: removed interface %s from network: %s",
@@ -451,3 +2384,153 @@ func removeIfaceEventFormat(pod *corev1.Pod, network *nadv1.NetworkSelectionElem
 		network.Name,
 	)
 }
+
+func dryRunEventFormat(pod *corev1.Pod, operation string, network *nadv1.NetworkSelectionElement) string {
+	return fmt.Sprintf(
+		"pod [%s]: dry-run: would %s interface %s of network: %s",
+		annotations.NamespacedName(pod.GetNamespace(), pod.GetName()),
+		operation,
+		network.InterfaceRequest,
+		network.Name,
+	)
+}
+
+func netAttachDefNotFoundEventFormat(pod *corev1.Pod, network *nadv1.NetworkSelectionElement) string {
+	return fmt.Sprintf(
+		"pod [%s]: skipped adding interface %s to network: %s: the network-attachment-definition %s does not exist",
+		annotations.NamespacedName(pod.GetNamespace(), pod.GetName()),
+		network.InterfaceRequest,
+		network.Name,
+		annotations.NamespacedName(network.Namespace, network.Name),
+	)
+}
+
+func invalidNetAttachDefEventFormat(pod *corev1.Pod, network *nadv1.NetworkSelectionElement, err error) string {
+	return fmt.Sprintf(
+		"pod [%s]: skipped adding interface %s to network: %s: %v",
+		annotations.NamespacedName(pod.GetNamespace(), pod.GetName()),
+		network.InterfaceRequest,
+		network.Name,
+		err,
+	)
+}
+
+func crossNamespaceReferenceDeniedEventFormat(pod *corev1.Pod, network *nadv1.NetworkSelectionElement) string {
+	return fmt.Sprintf(
+		"pod [%s]: skipped adding interface %s to network: %s: namespace %s does not allow references to its network-attachment-definitions from other namespaces",
+		annotations.NamespacedName(pod.GetNamespace(), pod.GetName()),
+		network.InterfaceRequest,
+		network.Name,
+		network.Namespace,
+	)
+}
+
+func invalidIfaceEventFormat(pod *corev1.Pod, network *nadv1.NetworkSelectionElement, err error) string {
+	return fmt.Sprintf(
+		"pod [%s]: skipped adding interface %s to network: %s: %v",
+		annotations.NamespacedName(pod.GetNamespace(), pod.GetName()),
+		network.InterfaceRequest,
+		network.Name,
+		err,
+	)
+}
+
+func addIfaceFailedEventFormat(pod *corev1.Pod, network *nadv1.NetworkSelectionElement, err error) string {
+	return fmt.Sprintf(
+		"pod [%s]: failed adding interface %s to network: %s: %v",
+		annotations.NamespacedName(pod.GetNamespace(), pod.GetName()),
+		network.InterfaceRequest,
+		network.Name,
+		err,
+	)
+}
+
+func removeStatusUpdateFailedEventFormat(pod *corev1.Pod, network *nadv1.NetworkSelectionElement, err error) string {
+	return fmt.Sprintf(
+		"pod [%s]: removed interface %s from network: %s, but %v",
+		annotations.NamespacedName(pod.GetNamespace(), pod.GetName()),
+		network.InterfaceRequest,
+		network.Name,
+		err,
+	)
+}
+
+func removeIfaceFailedEventFormat(pod *corev1.Pod, network *nadv1.NetworkSelectionElement, err error) string {
+	return fmt.Sprintf(
+		"pod [%s]: failed removing interface %s from network: %s: %v",
+		annotations.NamespacedName(pod.GetNamespace(), pod.GetName()),
+		network.InterfaceRequest,
+		network.Name,
+		err,
+	)
+}
+
+func rejectedNetworksUpdateEventFormat(pod *corev1.Pod, reason string) string {
+	return fmt.Sprintf(
+		"pod [%s]: rejected the networks update: %s",
+		annotations.NamespacedName(pod.GetNamespace(), pod.GetName()),
+		reason,
+	)
+}
+
+func netnsResolutionFailedEventFormat(pod *corev1.Pod, containerID string, err error) string {
+	return fmt.Sprintf(
+		"pod [%s]: failed to resolve the network namespace of container %s: %v",
+		annotations.NamespacedName(pod.GetNamespace(), pod.GetName()),
+		containerID,
+		err,
+	)
+}
+
+func networksUpdateDeniedEventFormat(pod *corev1.Pod, reason string) string {
+	return fmt.Sprintf(
+		"pod [%s]: the networks update was denied: %s",
+		annotations.NamespacedName(pod.GetNamespace(), pod.GetName()),
+		reason,
+	)
+}
+
+func networksUpdateValidationFailedEventFormat(pod *corev1.Pod, err error) string {
+	return fmt.Sprintf(
+		"pod [%s]: the networks update was denied: failed to validate it: %v",
+		annotations.NamespacedName(pod.GetNamespace(), pod.GetName()),
+		err,
+	)
+}
+
+func malformedOldNetworksEventFormat(pod *corev1.Pod, err error) string {
+	return fmt.Sprintf(
+		"pod [%s]: ignored the malformed previous networks annotation, handling the pod as if it had no networks: %v",
+		annotations.NamespacedName(pod.GetNamespace(), pod.GetName()),
+		err,
+	)
+}
+
+func failedRequestEventFormat(dynamicAttachmentRequest *DynamicAttachmentRequest, err error) string {
+	return fmt.Sprintf(
+		"pod [%s]: dropped the %s request for networks %s without retrying it: %v",
+		dynamicAttachmentRequest.podKey(),
+		dynamicAttachmentRequest.Type,
+		strings.Join(requestNetworkNames(dynamicAttachmentRequest), ","),
+		err,
+	)
+}
+
+func droppedRequestEventFormat(dynamicAttachmentRequest *DynamicAttachmentRequest, retries int, err error) string {
+	return fmt.Sprintf(
+		"pod [%s]: dropped the %s request for networks %s after %d retries: %v",
+		dynamicAttachmentRequest.podKey(),
+		dynamicAttachmentRequest.Type,
+		strings.Join(requestNetworkNames(dynamicAttachmentRequest), ","),
+		retries,
+		err,
+	)
+}
+
+func requestNetworkNames(dynamicAttachmentRequest *DynamicAttachmentRequest) []string {
+	networkNames := make([]string, 0, len(dynamicAttachmentRequest.AttachmentNames))
+	for _, network := range dynamicAttachmentRequest.AttachmentNames {
+		networkNames = append(networkNames, network.Name)
+	}
+	return networkNames
+}
@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+// DynamicNetworksReady is the pod condition telling if the last batch of the
+// pod's requests fully applied its networks annotation; see
+// WithNetworksReadyCondition.
+const DynamicNetworksReady corev1.PodConditionType = "DynamicNetworksReady"
+
+const (
+	// NetworksAppliedReason explains a true DynamicNetworksReady condition
+	NetworksAppliedReason = "NetworksApplied"
+	// RequestFailedReason explains a false DynamicNetworksReady condition; the
+	// condition's message features the error
+	RequestFailedReason = "RequestFailed"
+)
+
+// recordNetworksReadyCondition mirrors the outcome of the pod's request in its
+// DynamicNetworksReady condition: false when the request failed, true once the
+// request - being the last one pending for the pod - succeeded. Only the
+// requests adding, removing, or replacing attachments of live pods are
+// mirrored. Being bookkeeping, failing to write the condition is logged, not
+// returned.
+func (pnc *PodNetworksController) recordNetworksReadyCondition(
+	ctx context.Context,
+	dynamicAttachmentRequest *DynamicAttachmentRequest,
+	err error,
+) {
+	if !pnc.networksReadyCondition || pnc.dryRun || dynamicAttachmentRequest.deletedPod != nil {
+		return
+	}
+	if dynamicAttachmentRequest.Type != add && dynamicAttachmentRequest.Type != remove && dynamicAttachmentRequest.Type != replace {
+		return
+	}
+
+	condition := corev1.PodCondition{
+		Type:   DynamicNetworksReady,
+		Status: corev1.ConditionTrue,
+		Reason: NetworksAppliedReason,
+	}
+	if err != nil {
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = RequestFailedReason
+		condition.Message = err.Error()
+	}
+
+	logger := klog.FromContext(ctx)
+	pods := pnc.k8sClientSet.CoreV1().Pods(dynamicAttachmentRequest.PodNamespace)
+	updateErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		pod, err := pods.Get(ctx, dynamicAttachmentRequest.PodName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if dynamicAttachmentRequest.PodUID != "" && pod.GetUID() != dynamicAttachmentRequest.PodUID {
+			return nil
+		}
+		if !setPodCondition(pod, condition, metav1.NewTime(pnc.clock.Now())) {
+			return nil
+		}
+		_, err = pods.UpdateStatus(ctx, pod, metav1.UpdateOptions{})
+		return err
+	})
+	if apierrors.IsNotFound(updateErr) {
+		return
+	}
+	if updateErr != nil {
+		logger.Error(updateErr, "failed to record the networks ready condition", "status", condition.Status)
+	}
+}
+
+// setPodCondition sets the condition in the pod's status, returning if it
+// changed; the transition time only changes along with the condition's status.
+func setPodCondition(pod *corev1.Pod, condition corev1.PodCondition, now metav1.Time) bool {
+	condition.LastTransitionTime = now
+	for i := range pod.Status.Conditions {
+		currentCondition := &pod.Status.Conditions[i]
+		if currentCondition.Type != condition.Type {
+			continue
+		}
+		if currentCondition.Status == condition.Status &&
+			currentCondition.Reason == condition.Reason &&
+			currentCondition.Message == condition.Message {
+			return false
+		}
+		if currentCondition.Status == condition.Status {
+			condition.LastTransitionTime = currentCondition.LastTransitionTime
+		}
+		*currentCondition = condition
+		return true
+	}
+	pod.Status.Conditions = append(pod.Status.Conditions, condition)
+	return true
+}
@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	nad "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni"
+	fakemultusclient "github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni/fake"
+)
+
+var _ = Describe("The networks ready condition", func() {
+	const ifaceName = "net1"
+
+	var podController *PodNetworksController
+
+	networksReadyCondition := func() *corev1.PodCondition {
+		pod, err := podController.k8sClientSet.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		for i := range pod.Status.Conditions {
+			if pod.Status.Conditions[i].Type == DynamicNetworksReady {
+				return &pod.Status.Conditions[i]
+			}
+		}
+		return nil
+	}
+
+	BeforeEach(func() {
+		podController = newSynchedPodController(podSpec(podName, namespace), fakemultusclient.NewFakeClient(), tinyNetAttachDef())
+		WithNetworksReadyCondition(true)(podController)
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:         podName,
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{{Name: networkName, Namespace: namespace, InterfaceRequest: ifaceName}},
+			Type:            add,
+			PodNetNS:        netnsPath,
+		})
+	})
+
+	It("flips to false when the request fails, then to true once it succeeds", func() {
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		condition := networksReadyCondition()
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(RequestFailedReason))
+		Expect(condition.Message).To(ContainSubstring("failed to ADD delegate"))
+
+		podController.multusClient = fakemultusclient.NewFakeClient(networkConfig(multuscni.CmdAdd, ifaceName, ifaceName, macAddr))
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		condition = networksReadyCondition()
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(corev1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(NetworksAppliedReason))
+		Expect(condition.Message).To(BeEmpty())
+	})
+
+	It("is not set unless enabled", func() {
+		WithNetworksReadyCondition(false)(podController)
+
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		Expect(networksReadyCondition()).To(BeNil())
+	})
+})
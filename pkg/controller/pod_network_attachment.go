@@ -0,0 +1,197 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/logging"
+)
+
+// PodNetworkAttachmentsResource is the custom resource declaring the networks
+// of the pod it is named after; the controller reconciles the pod's networks
+// annotation - thus its interfaces - into the declared networks. Deleting the
+// resource stops driving the pod's networks, leaving them as they are.
+var PodNetworkAttachmentsResource = schema.GroupVersionResource{
+	Group:    "dynamic-networks-controller.k8s.cni.cncf.io",
+	Version:  "v1alpha1",
+	Resource: "podnetworkattachments",
+}
+
+// PodNetworkAttachment declares the networks of the pod it is named after.
+type PodNetworkAttachment struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec PodNetworkAttachmentSpec `json:"spec"`
+}
+
+// PodNetworkAttachmentSpec lists the network selection elements of the pod,
+// in the networks annotation's format.
+type PodNetworkAttachmentSpec struct {
+	Networks []nadv1.NetworkSelectionElement `json:"networks"`
+}
+
+// WithPodNetworkAttachmentInformer has the controller reconcile the pods'
+// networks with the PodNetworkAttachments - see
+// PodNetworkAttachmentsResource - the informer watches, as unstructured
+// objects.
+func WithPodNetworkAttachmentInformer(informer cache.SharedIndexInformer) Option {
+	return func(pnc *PodNetworksController) {
+		pnc.podNetworkAttachmentInformer = informer
+	}
+}
+
+func (pnc *PodNetworksController) handlePodNetworkAttachmentAdd(obj interface{}) {
+	attachment, ok := obj.(metav1.Object)
+	if !ok {
+		klog.InfoS("unexpected pod network attachment added", "object", obj)
+		return
+	}
+	pnc.enqueue(
+		&DynamicAttachmentRequest{
+			PodName:      attachment.GetName(),
+			PodNamespace: attachment.GetNamespace(),
+			Type:         apply,
+		})
+}
+
+func (pnc *PodNetworksController) handlePodNetworkAttachmentUpdate(oldObj interface{}, newObj interface{}) {
+	oldAttachment, oldOK := oldObj.(metav1.Object)
+	newAttachment, newOK := newObj.(metav1.Object)
+	if oldOK && newOK && oldAttachment.GetResourceVersion() == newAttachment.GetResourceVersion() {
+		// a resync; the pod's networks are reconciled on their own
+		return
+	}
+	pnc.handlePodNetworkAttachmentAdd(newObj)
+}
+
+// handlePodAdd applies the PodNetworkAttachment declared ahead of the pod's
+// creation.
+func (pnc *PodNetworksController) handlePodAdd(obj interface{}) {
+	pod := obj.(*corev1.Pod)
+	if !pnc.isPodSelected(pod) {
+		return
+	}
+	if _, wasFound, _ := pnc.podNetworkAttachmentInformer.GetIndexer().GetByKey(
+		annotations.NamespacedName(pod.GetNamespace(), pod.GetName())); !wasFound {
+		return
+	}
+	pnc.handlePodNetworkAttachmentAdd(pod)
+}
+
+// podNetworkAttachment returns the PodNetworkAttachment of the pod; nil when
+// the pod does not have one.
+func (pnc *PodNetworksController) podNetworkAttachment(pod *corev1.Pod) (*PodNetworkAttachment, error) {
+	if pnc.podNetworkAttachmentInformer == nil {
+		return nil, nil
+	}
+	obj, wasFound, err := pnc.podNetworkAttachmentInformer.GetIndexer().GetByKey(
+		annotations.NamespacedName(pod.GetNamespace(), pod.GetName()))
+	if err != nil || !wasFound {
+		return nil, err
+	}
+	unstructuredAttachment, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected pod network attachment: %T", obj)
+	}
+	attachment := &PodNetworkAttachment{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(unstructuredAttachment.UnstructuredContent(), attachment); err != nil {
+		return nil, fmt.Errorf("failed to parse the pod network attachment: %v", err)
+	}
+	return attachment, nil
+}
+
+// applyPodNetworkAttachment writes the networks declared by the pod's
+// PodNetworkAttachment in its networks annotation.
+func (pnc *PodNetworksController) applyPodNetworkAttachment(ctx context.Context, pod *corev1.Pod) error {
+	logger := klog.FromContext(ctx)
+	attachment, err := pnc.podNetworkAttachment(pod)
+	if err != nil {
+		return newTerminalError(err)
+	}
+	if attachment == nil {
+		logger.Info("the pod network attachment no longer exists")
+		return nil
+	}
+	if isTerminating(pod) {
+		logger.Info("discarding the pod network attachment: the pod is terminating")
+		return nil
+	}
+
+	networks := attachment.Spec.Networks
+	if networks == nil {
+		networks = []nadv1.NetworkSelectionElement{}
+	}
+	return pnc.updateNetworksAnnotation(ctx, pod, func(*corev1.Pod) ([]nadv1.NetworkSelectionElement, error) {
+		return networks, nil
+	})
+}
+
+// updateNetworksAnnotation writes the networks computed from the pod in its
+// networks annotation; the networks are computed again from the current pod
+// whenever the write conflicts. The resulting pod update adds, and removes,
+// the interfaces - just like when the annotation is edited by hand.
+func (pnc *PodNetworksController) updateNetworksAnnotation(
+	ctx context.Context,
+	pod *corev1.Pod,
+	desiredNetworks func(pod *corev1.Pod) ([]nadv1.NetworkSelectionElement, error),
+) error {
+	logger := klog.FromContext(ctx)
+	currentPod := pod
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		networks, err := desiredNetworks(currentPod)
+		if err != nil {
+			return newTerminalError(err)
+		}
+		serializedNetworks, err := json.Marshal(networks)
+		if err != nil {
+			return newTerminalError(fmt.Errorf("failed to marshal the networks: %v", err))
+		}
+		patch, err := podAnnotationsPatch(
+			currentPod.GetResourceVersion(),
+			currentPod.Annotations,
+			map[string]string{nadv1.NetworkAttachmentAnnot: string(serializedNetworks)})
+		if err != nil {
+			return fmt.Errorf("failed to compute the networks annotation patch: %v", err)
+		}
+		if patch == nil {
+			logger.V(logging.Debug).Info("the pod's networks annotation already features the networks")
+			return nil
+		}
+		if pnc.dryRun {
+			logger.Info("dry-run: would update the pod's networks annotation", "networks", string(serializedNetworks))
+			return nil
+		}
+
+		logger.Info("updating the pod's networks annotation", "networks", string(serializedNetworks))
+		_, err = pnc.k8sClientSet.CoreV1().Pods(pod.GetNamespace()).Patch(
+			ctx,
+			pod.GetName(),
+			types.MergePatchType,
+			patch,
+			metav1.PatchOptions{})
+		if apierrors.IsConflict(err) {
+			freshPod, getErr := pnc.k8sClientSet.CoreV1().Pods(pod.GetNamespace()).Get(ctx, pod.GetName(), metav1.GetOptions{})
+			if getErr != nil {
+				return getErr
+			}
+			currentPod = freshPod
+		}
+		return err
+	})
+}
@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	nad "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni"
+	fakemultusclient "github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni/fake"
+)
+
+var _ = Describe("The pod network attachments", func() {
+	const ifaceName = "net1"
+
+	var (
+		attachmentInformer cache.SharedIndexInformer
+		multusClient       *fakemultusclient.Client
+		podController      *PodNetworksController
+	)
+
+	attachment := &nad.NetworkSelectionElement{Name: networkName, Namespace: namespace, InterfaceRequest: ifaceName}
+
+	// declare stores the pod network attachment declaring the networks, along
+	// with a new resource version
+	declare := func(resourceVersion string, networks ...nad.NetworkSelectionElement) *unstructured.Unstructured {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&PodNetworkAttachment{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: PodNetworkAttachmentsResource.GroupVersion().String(),
+				Kind:       "PodNetworkAttachment",
+			},
+			ObjectMeta: metav1.ObjectMeta{Name: podName, Namespace: namespace, ResourceVersion: resourceVersion},
+			Spec:       PodNetworkAttachmentSpec{Networks: networks},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		podNetworkAttachment := &unstructured.Unstructured{Object: content}
+		Expect(attachmentInformer.GetIndexer().Update(podNetworkAttachment)).To(Succeed())
+		return podNetworkAttachment
+	}
+
+	currentPod := func() *corev1.Pod {
+		pod, err := podController.k8sClientSet.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		return pod
+	}
+
+	// applyAndObserve processes the apply request, then has the controller
+	// observe the resulting pod update - processing the requests it issues
+	applyAndObserve := func() *corev1.Pod {
+		oldPod := currentPod()
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+		updatedPod := currentPod()
+		Expect(podController.podsInformer.GetStore().Update(updatedPod)).To(Succeed())
+		podController.handlePodUpdate(oldPod, updatedPod)
+		for podController.workqueue.Len() > 0 {
+			Expect(podController.processNextWorkItem()).To(BeTrue())
+		}
+		return currentPod()
+	}
+
+	BeforeEach(func() {
+		addConfig := networkConfig(multuscni.CmdAdd, ifaceName, ifaceName, macAddr)
+		addConfig.Response.Result.Interfaces[0].Sandbox = netnsPath
+		multusClient = fakemultusclient.NewFakeClient(addConfig, networkConfig(multuscni.CmdDel, ifaceName, "", ""))
+		podController = newSynchedPodController(podSpec(podName, namespace), multusClient, tinyNetAttachDef())
+		attachmentInformer = cache.NewSharedIndexInformer(
+			&cache.ListWatch{}, &unstructured.Unstructured{}, 0, cache.Indexers{})
+		WithPodNetworkAttachmentInformer(attachmentInformer)(podController)
+	})
+
+	It("attach the pod to the declared networks", func() {
+		podController.handlePodNetworkAttachmentAdd(declare("1", *attachment))
+
+		pod := applyAndObserve()
+
+		Expect(networkSelectionElements(pod.Annotations, namespace)).To(ConsistOf(attachment))
+		Expect(isInterfaceInNetworkStatus(pod, attachment)).To(BeTrue())
+	})
+
+	It("detach the pod from the networks no longer declared", func() {
+		oldAttachment := declare("1", *attachment)
+		podController.handlePodNetworkAttachmentAdd(oldAttachment)
+		Expect(isInterfaceInNetworkStatus(applyAndObserve(), attachment)).To(BeTrue())
+
+		podController.handlePodNetworkAttachmentUpdate(oldAttachment, declare("2"))
+		pod := applyAndObserve()
+
+		Expect(networkSelectionElements(pod.Annotations, namespace)).To(BeEmpty())
+		Expect(isInterfaceInNetworkStatus(pod, attachment)).To(BeFalse())
+		Expect(multusClient.Requests()).To(HaveLen(2))
+		Expect(multusClient.Requests()[1].Env).To(HaveKeyWithValue("CNI_COMMAND", multuscni.CmdDel))
+	})
+
+	It("are applied to the pods created after them", func() {
+		declare("1", *attachment)
+
+		podController.handlePodAdd(currentPod())
+
+		Expect(podController.workqueue.Len()).To(Equal(1))
+		Expect(networkSelectionElements(applyAndObserve().Annotations, namespace)).To(ConsistOf(attachment))
+	})
+
+	It("are not applied again on resync", func() {
+		podNetworkAttachment := declare("1", *attachment)
+
+		podController.handlePodNetworkAttachmentUpdate(podNetworkAttachment, podNetworkAttachment)
+
+		Expect(podController.workqueue.Len()).To(BeZero())
+	})
+
+	It("leave the pod's networks untouched once deleted", func() {
+		pod := currentPod()
+		podController.enqueue(&DynamicAttachmentRequest{PodName: podName, PodNamespace: namespace, Type: apply})
+
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		Expect(currentPod().Annotations).To(Equal(pod.Annotations))
+	})
+})
@@ -0,0 +1,238 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/audit"
+)
+
+// DebugPodsEndpoint serves the state of a pod's attachments, under
+// `/debug/pods/<namespace>/<name>`.
+const DebugPodsEndpoint = "/debug/pods/"
+
+// DebugInterfacesEndpoint serves the inventory of the interfaces the controller
+// added to the pods it handles.
+const DebugInterfacesEndpoint = "/debug/interfaces"
+
+// PodInterfaces lists the network-status entries of the interfaces the
+// controller added to a pod.
+type PodInterfaces struct {
+	Pod        string                `json:"pod"`
+	Interfaces []nadv1.NetworkStatus `json:"interfaces"`
+}
+
+const (
+	// historyLength bounds how many operations the pods' history retains
+	historyLength = 10
+	// historyTTL is how long the pods' history retains the operations
+	historyTTL = time.Hour
+)
+
+// PodState is what the controller knows about a pod's attachments: the desired
+// ones - i.e. the pod's network selection elements - versus the applied ones -
+// i.e. the pod's network-status - along with the outcome of the pod's last
+// processed request. The history lists the outcome of the pod's most recent
+// attachment additions and removals, oldest first.
+type PodState struct {
+	Desired       []*nadv1.NetworkSelectionElement `json:"desired"`
+	Applied       []nadv1.NetworkStatus            `json:"applied"`
+	LastRequestID string                           `json:"lastRequestID,omitempty"`
+	LastError     string                           `json:"lastError,omitempty"`
+	LastErrorTime *time.Time                       `json:"lastErrorTime,omitempty"`
+	History       []audit.Event                    `json:"history,omitempty"`
+}
+
+// podStates indexes the state of the pods' attachments by pod key.
+type podStates struct {
+	lock   sync.RWMutex
+	states map[string]PodState
+}
+
+func newPodStates() *podStates {
+	return &podStates{states: map[string]PodState{}}
+}
+
+func (ps *podStates) get(podKey string) (PodState, bool) {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	state, wasFound := ps.states[podKey]
+	return state, wasFound
+}
+
+func (ps *podStates) update(podKey string, update func(state *PodState)) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	state := ps.states[podKey]
+	update(&state)
+	ps.states[podKey] = state
+}
+
+// recordOperation appends the operation to the pod's history, evicting the
+// operations older than historyTTL, along with the oldest ones past
+// historyLength.
+func (ps *podStates) recordOperation(podKey string, operation audit.Event, now time.Time) {
+	ps.update(podKey, func(state *PodState) {
+		history := append(recentHistory(state.History, now), operation)
+		if len(history) > historyLength {
+			history = history[len(history)-historyLength:]
+		}
+		state.History = history
+	})
+}
+
+// recentHistory returns the operations of the history not older than historyTTL.
+func recentHistory(history []audit.Event, now time.Time) []audit.Event {
+	var recentOperations []audit.Event
+	for _, operation := range history {
+		if now.Sub(operation.Time) < historyTTL {
+			recentOperations = append(recentOperations, operation)
+		}
+	}
+	return recentOperations
+}
+
+func (ps *podStates) forget(podKey string) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	delete(ps.states, podKey)
+}
+
+// recordRequestOutcome records the pod's desired attachments, and the outcome
+// of the processed request; the applied attachments are recorded whenever the
+// network-status is written.
+func (pnc *PodNetworksController) recordRequestOutcome(dynamicAttachmentRequest *DynamicAttachmentRequest, err error) {
+	podKey := dynamicAttachmentRequest.podKey()
+	if dynamicAttachmentRequest.deletedPod != nil && err == nil {
+		pnc.podStates.forget(podKey)
+		return
+	}
+	pod, getErr := pnc.podsLister.Pods(dynamicAttachmentRequest.PodNamespace).Get(dynamicAttachmentRequest.PodName)
+	if getErr != nil {
+		pod = dynamicAttachmentRequest.deletedPod
+	}
+
+	pnc.podStates.update(podKey, func(state *PodState) {
+		state.LastRequestID = dynamicAttachmentRequest.ID
+		state.LastError = ""
+		state.LastErrorTime = nil
+		if err != nil {
+			now := pnc.clock.Now()
+			state.LastError = err.Error()
+			state.LastErrorTime = &now
+		}
+		if pod == nil {
+			return
+		}
+		if desiredNetworks, err := networkSelectionElements(pod.Annotations, pod.GetNamespace()); err == nil {
+			state.Desired = desiredNetworks
+		}
+		if state.Applied == nil {
+			state.Applied = appliedNetworks(pod.Annotations[nadv1.NetworkStatusAnnot])
+		}
+	})
+}
+
+// recordAppliedNetworks records the pod's network-status, as written by the controller.
+func (pnc *PodNetworksController) recordAppliedNetworks(pod *corev1.Pod, networkStatus string) {
+	pnc.podStates.update(annotations.NamespacedName(pod.GetNamespace(), pod.GetName()), func(state *PodState) {
+		state.Applied = appliedNetworks(networkStatus)
+	})
+}
+
+func appliedNetworks(networkStatus string) []nadv1.NetworkStatus {
+	applied := []nadv1.NetworkStatus{}
+	if networkStatus == "" {
+		return applied
+	}
+	if err := json.Unmarshal([]byte(networkStatus), &applied); err != nil {
+		klog.ErrorS(err, "failed to parse the network-status")
+	}
+	return applied
+}
+
+// DebugHandler returns the handler serving the state of the pods' attachments.
+func (pnc *PodNetworksController) DebugHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(DebugPodsEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		podKey := strings.TrimPrefix(r.URL.Path, DebugPodsEndpoint)
+		if strings.Count(podKey, "/") != 1 {
+			http.Error(w, "expected "+DebugPodsEndpoint+"<namespace>/<name>", http.StatusBadRequest)
+			return
+		}
+		state, wasFound := pnc.podStates.get(podKey)
+		if !wasFound {
+			http.Error(w, "no state recorded for pod "+podKey, http.StatusNotFound)
+			return
+		}
+		state.History = recentHistory(state.History, pnc.clock.Now())
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(state); err != nil {
+			klog.ErrorS(err, "failed to reply the pod state", "pod", podKey)
+		}
+	})
+	mux.HandleFunc(DebugInterfacesEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		inventory, err := pnc.dynamicInterfacesInventory()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(inventory); err != nil {
+			klog.ErrorS(err, "failed to reply the dynamic interfaces inventory")
+		}
+	})
+	return mux
+}
+
+// dynamicInterfacesInventory returns - sorted by pod - the interfaces the
+// controller added to the pods it handles, as listed in their network-status;
+// the pods without dynamic interfaces are left out.
+func (pnc *PodNetworksController) dynamicInterfacesInventory() ([]PodInterfaces, error) {
+	pods, err := pnc.podsLister.List(pnc.podSelector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the pods: %v", err)
+	}
+
+	inventory := []PodInterfaces{}
+	for _, pod := range pods {
+		dynamicIfaces := map[string]bool{}
+		for _, ifaceName := range pnc.dynamicInterfaces(pod) {
+			dynamicIfaces[ifaceName] = true
+		}
+		if len(dynamicIfaces) == 0 {
+			continue
+		}
+		var interfaces []nadv1.NetworkStatus
+		for _, network := range appliedNetworks(pod.Annotations[nadv1.NetworkStatusAnnot]) {
+			if !network.Default && dynamicIfaces[network.Interface] {
+				interfaces = append(interfaces, network)
+			}
+		}
+		if len(interfaces) == 0 {
+			continue
+		}
+		inventory = append(inventory, PodInterfaces{
+			Pod:        annotations.NamespacedName(pod.GetNamespace(), pod.GetName()),
+			Interfaces: interfaces,
+		})
+	}
+	sort.Slice(inventory, func(i, j int) bool {
+		return inventory[i].Pod < inventory[j].Pod
+	})
+	return inventory, nil
+}
@@ -0,0 +1,181 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	nad "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/audit"
+	fakecri "github.com/maiqueb/multus-dynamic-networks-controller/pkg/cri/fake"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni"
+	fakemultusclient "github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni/fake"
+)
+
+var _ = Describe("The pod state debug endpoint", func() {
+	var (
+		podController *PodNetworksController
+		server        *httptest.Server
+		fakeClock     *clocktesting.FakeClock
+	)
+
+	BeforeEach(func() {
+		addInterfaceConfig := networkConfig(multuscni.CmdAdd, "net1", "net1", macAddr)
+		addInterfaceConfig.Response.Result.Interfaces[0].Sandbox = netnsPath
+		podController = newSynchedPodController(
+			podSpec(podName, namespace, networkName),
+			fakemultusclient.NewFakeClient(addInterfaceConfig),
+			tinyNetAttachDef())
+		podController.recorder = record.NewFakeRecorder(5)
+		fakeClock = clocktesting.NewFakeClock(time.Now())
+		WithClock(fakeClock)(podController)
+		server = httptest.NewServer(podController.DebugHandler())
+
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:         podName,
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"}},
+			Type:            add,
+			PodNetNS:        netnsPath,
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("reports the desired and applied attachments of the pod", func() {
+		response, err := http.Get(server.URL + DebugPodsEndpoint + namespace + "/" + podName)
+		Expect(err).NotTo(HaveOccurred())
+		defer response.Body.Close()
+		Expect(response.StatusCode).To(Equal(http.StatusOK))
+
+		var state PodState
+		Expect(json.NewDecoder(response.Body).Decode(&state)).To(Succeed())
+		Expect(state.Desired).To(ConsistOf(&nad.NetworkSelectionElement{Name: networkName, Namespace: namespace, InterfaceRequest: "net0"}))
+		var appliedIfaces []string
+		for _, appliedNetwork := range state.Applied {
+			appliedIfaces = append(appliedIfaces, appliedNetwork.Interface)
+		}
+		Expect(appliedIfaces).To(Equal([]string{"net0", "net1"}))
+		Expect(state.LastRequestID).NotTo(BeEmpty())
+		Expect(state.LastError).To(BeEmpty())
+	})
+
+	podState := func() PodState {
+		response, err := http.Get(server.URL + DebugPodsEndpoint + namespace + "/" + podName)
+		Expect(err).NotTo(HaveOccurred())
+		defer response.Body.Close()
+		Expect(response.StatusCode).To(Equal(http.StatusOK))
+
+		var state PodState
+		Expect(json.NewDecoder(response.Body).Decode(&state)).To(Succeed())
+		return state
+	}
+
+	It("reports the pod's recent operations, until they expire", func() {
+		history := podState().History
+		Expect(history).To(HaveLen(1))
+		Expect(history[0].Operation).To(Equal(audit.OperationAdd))
+		Expect(history[0].Interface).To(Equal("net1"))
+		Expect(history[0].Result).To(Equal(audit.ResultSuccess))
+
+		fakeClock.Step(historyTTL)
+		Expect(podState().History).To(BeEmpty())
+	})
+
+	It("reports a bounded number of operations", func() {
+		podKey := annotations.NamespacedName(namespace, podName)
+		for i := 0; i < historyLength; i++ {
+			podController.podStates.recordOperation(
+				podKey,
+				audit.Event{Time: fakeClock.Now(), Operation: audit.OperationRemove, Interface: fmt.Sprintf("net%d", i)},
+				fakeClock.Now())
+		}
+
+		history := podState().History
+		Expect(history).To(HaveLen(historyLength))
+		Expect(history[0].Interface).To(Equal("net0"))
+		Expect(history[historyLength-1].Interface).To(Equal(fmt.Sprintf("net%d", historyLength-1)))
+	})
+
+	It("forgets the deleted pods", func() {
+		podKey := annotations.NamespacedName(namespace, podName)
+		_, isKnown := podController.podStates.get(podKey)
+		Expect(isKnown).To(BeTrue())
+
+		podController.handlePodDelete(podSpec(podName, namespace))
+		_, isKnown = podController.podStates.get(podKey)
+		Expect(isKnown).To(BeFalse())
+	})
+
+	It("does not know about the pods it did not process requests of", func() {
+		response, err := http.Get(server.URL + DebugPodsEndpoint + namespace + "/unknown-pod")
+		Expect(err).NotTo(HaveOccurred())
+		defer response.Body.Close()
+		Expect(response.StatusCode).To(Equal(http.StatusNotFound))
+	})
+})
+
+var _ = Describe("The dynamic interfaces inventory debug endpoint", func() {
+	podWithInterfaces := func(name string, networkStatus string, dynamicIfaces string) *corev1.Pod {
+		pod := podSpec(name, namespace)
+		pod.Annotations[nad.NetworkStatusAnnot] = networkStatus
+		if dynamicIfaces != "" {
+			pod.Annotations[defaultBookkeepingKeys.DynamicInterfaces] = dynamicIfaces
+		}
+		return pod
+	}
+
+	It("lists the dynamic interfaces of each pod", func() {
+		podController := newUnstartedPodController(fakecri.NewFakeRuntime(), fakemultusclient.NewFakeClient())
+		for _, pod := range []*corev1.Pod{
+			podWithInterfaces(
+				"pod-b",
+				`[{"name":"cluster-net","interface":"eth0","default":true},`+
+					`{"name":"default/tiny-net","interface":"net0"},`+
+					`{"name":"default/tiny-net","interface":"net1","ips":["fd10::10"]}]`,
+				`["net1"]`),
+			podWithInterfaces(
+				"pod-a",
+				`[{"name":"cluster-net","interface":"eth0","default":true},{"name":"default/tiny-net","interface":"net2"}]`,
+				`["net2"]`),
+			// the interfaces multus added when creating the pod are not dynamic
+			podWithInterfaces("pod-c", `[{"name":"cluster-net","interface":"eth0","default":true},{"name":"default/tiny-net","interface":"net0"}]`, ""),
+		} {
+			Expect(podController.podsInformer.GetStore().Add(pod)).To(Succeed())
+		}
+		server := httptest.NewServer(podController.DebugHandler())
+		defer server.Close()
+
+		response, err := http.Get(server.URL + DebugInterfacesEndpoint)
+		Expect(err).NotTo(HaveOccurred())
+		defer response.Body.Close()
+		Expect(response.StatusCode).To(Equal(http.StatusOK))
+
+		var inventory []PodInterfaces
+		Expect(json.NewDecoder(response.Body).Decode(&inventory)).To(Succeed())
+		Expect(inventory).To(Equal([]PodInterfaces{
+			{
+				Pod:        annotations.NamespacedName(namespace, "pod-a"),
+				Interfaces: []nad.NetworkStatus{{Name: "default/tiny-net", Interface: "net2"}},
+			},
+			{
+				Pod:        annotations.NamespacedName(namespace, "pod-b"),
+				Interfaces: []nad.NetworkStatus{{Name: "default/tiny-net", Interface: "net1", IPs: []string{"fd10::10"}}},
+			},
+		}))
+	})
+})
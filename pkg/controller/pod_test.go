@@ -3,11 +3,15 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
 	"path"
+	"sync"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -15,23 +19,33 @@ import (
 	cni100 "github.com/containernetworking/cni/pkg/types/100"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	v1coreinformerfactory "k8s.io/client-go/informers"
 	k8sclient "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
 
 	nad "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 	nadclient "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/client/clientset/versioned"
 	fakenadclient "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/client/clientset/versioned/fake"
 	nadinformers "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/client/informers/externalversions"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	multusapi "gopkg.in/k8snetworkplumbingwg/multus-cni.v3/pkg/server/api"
 
 	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
 	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/cri"
 	fakecri "github.com/maiqueb/multus-dynamic-networks-controller/pkg/cri/fake"
+	fakelink "github.com/maiqueb/multus-dynamic-networks-controller/pkg/link/fake"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/metrics"
 	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni"
 	fakemultusclient "github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni/fake"
 )
@@ -41,6 +55,20 @@ func TestController(t *testing.T) {
 	RunSpecs(t, "Dynamic network attachment controller suite")
 }
 
+// The pods and networks most tests attach.
+const (
+	cniVersion  = "0.3.0"
+	macAddr     = "02:03:04:05:06:07"
+	namespace   = "default"
+	networkName = "tiny-net"
+	podName     = "tiny-winy-pod"
+	netnsPath   = "/var/run/netns/" + podName
+)
+
+// defaultBookkeepingKeys are the keys of the controller's bookkeeping
+// annotations, unless their prefix is overridden.
+var defaultBookkeepingKeys = annotations.DefaultBookkeepingKeys()
+
 var _ = Describe("Dynamic Attachment controller", func() {
 	Context("with access to a proper multus configuration", func() {
 		var cniConfigDir string
@@ -60,119 +88,2327 @@ var _ = Describe("Dynamic Attachment controller", func() {
 				[]byte(dummyMultusConfig()), configFilePermissions)).To(Succeed())
 		})
 
-		AfterEach(func() {
-			Expect(os.RemoveAll(cniConfigDir)).To(Succeed())
+		AfterEach(func() {
+			Expect(os.RemoveAll(cniConfigDir)).To(Succeed())
+		})
+
+		Context("with an existing running pod", func() {
+			var (
+				eventRecorder *record.FakeRecorder
+				k8sClient     k8sclient.Interface
+				pod           *corev1.Pod
+				podController *dummyPodController
+				networkToAdd  string
+				stopChannel   chan struct{}
+			)
+
+			networkStatusNames := func(statuses []nad.NetworkStatus) []string {
+				var names []string
+				for _, status := range statuses {
+					names = append(names, status.Name)
+				}
+				return names
+			}
+
+			BeforeEach(func() {
+				pod = podSpec(podName, namespace, networkName)
+				k8sClient = fake.NewSimpleClientset(pod)
+				networkToAdd = fmt.Sprintf("%s-2", networkName)
+				nadClient, err := newFakeNetAttachDefClient(
+					tinyNetAttachDef(),
+					netAttachDef(networkToAdd, namespace, dummyNetSpec(networkToAdd, cniVersion)))
+				Expect(err).NotTo(HaveOccurred())
+				stopChannel = make(chan struct{})
+				const maxEvents = 5
+				eventRecorder = record.NewFakeRecorder(maxEvents)
+				podController, err = newDummyPodController(
+					k8sClient,
+					nadClient,
+					stopChannel,
+					eventRecorder,
+					fakecri.NewFakeRuntime(*pod),
+					fakemultusclient.NewFakeClient(
+						networkConfig(multuscni.CmdAdd, "net1", networkName, macAddr),
+						networkConfig(multuscni.CmdDel, "net0", "", "")),
+				)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(podController).NotTo(BeNil())
+				Expect(func() []nad.NetworkStatus {
+					updatedPod, err := k8sClient.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+					if err != nil {
+						return nil
+					}
+					status, err := networkStatus(updatedPod.Annotations)
+					if err != nil {
+						return nil
+					}
+					return status
+				}()).Should(
+					And(
+						WithTransform(networkStatusNames, ContainElements(annotations.NamespacedName(namespace, networkName))),
+						Not(WithTransform(networkStatusNames, ContainElements(annotations.NamespacedName(namespace, networkToAdd))))),
+				)
+			})
+
+			AfterEach(func() {
+				close(stopChannel)
+			})
+
+			It("updating the network-status records it on the updated pod, rather than the pod informer cache", func() {
+				cachedPod, err := podController.podsLister.Pods(namespace).Get(podName)
+				Expect(err).NotTo(HaveOccurred())
+				podBeforeUpdate, err := json.Marshal(cachedPod)
+				Expect(err).NotTo(HaveOccurred())
+
+				updatedPod := cachedPod.DeepCopy()
+				err = podController.updatePodNetworkStatus(
+					context.Background(),
+					updatedPod,
+					func(*corev1.Pod) (string, error) { return "[]", nil },
+					nil,
+					"")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(json.Marshal(cachedPod)).To(Equal(podBeforeUpdate))
+				Expect(updatedPod.Annotations).To(HaveKeyWithValue(nad.NetworkStatusAnnot, "[]"))
+				Expect(updatedPod.Annotations).To(HaveKeyWithValue(defaultBookkeepingKeys.DynamicInterfaces, "[]"))
+				writtenPod, err := k8sClient.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(updatedPod.GetResourceVersion()).To(Equal(writtenPod.GetResourceVersion()))
+			})
+
+			When("an attachment is added to the pod's network annotations", func() {
+				BeforeEach(func() {
+					var err error
+					_, err = k8sClient.CoreV1().Pods(namespace).UpdateStatus(
+						context.TODO(),
+						updatePodSpec(pod, networkName, networkToAdd),
+						metav1.UpdateOptions{})
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("an `AddedInterface` event is seen in the event recorded ", func() {
+					expectedEventPayload := fmt.Sprintf(
+						"Normal AddedInterface pod [%s]: added interface %s to network: %s",
+						annotations.NamespacedName(namespace, podName),
+						"net1",
+						networkToAdd,
+					)
+					Eventually(<-eventRecorder.Events).Should(Equal(expectedEventPayload))
+				})
+			})
+
+			When("the pod is deleted", func() {
+				BeforeEach(func() {
+					Expect(k8sClient.CoreV1().Pods(namespace).Delete(context.TODO(), podName, metav1.DeleteOptions{})).To(Succeed())
+				})
+
+				It("an `RemovedInterface` event is seen in the event recorded ", func() {
+					expectedEventPayload := fmt.Sprintf(
+						"Normal RemovedInterface pod [%s]: removed interface %s from network: %s",
+						annotations.NamespacedName(namespace, podName),
+						"net0",
+						networkName,
+					)
+					Eventually(<-eventRecorder.Events).Should(Equal(expectedEventPayload))
+				})
+			})
+
+			When("an attachment is removed from the pod's network annotations", func() {
+				BeforeEach(func() {
+					var err error
+					_, err = k8sClient.CoreV1().Pods(namespace).UpdateStatus(
+						context.TODO(),
+						updatePodSpec(pod),
+						metav1.UpdateOptions{})
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("an `RemovedInterface` event is seen in the event recorded ", func() {
+					expectedEventPayload := fmt.Sprintf(
+						"Normal RemovedInterface pod [%s]: removed interface %s from network: %s",
+						annotations.NamespacedName(namespace, podName),
+						"net0",
+						networkName,
+					)
+					Eventually(<-eventRecorder.Events).Should(Equal(expectedEventPayload))
+				})
+			})
+		})
+
+		Context("with a pod whose containers are not running yet", func() {
+			var (
+				eventRecorder *record.FakeRecorder
+				k8sClient     k8sclient.Interface
+				pod           *corev1.Pod
+				networkToAdd  string
+				stopChannel   chan struct{}
+			)
+
+			BeforeEach(func() {
+				runningPod := podSpec(podName, namespace, networkName)
+				pod = runningPod.DeepCopy()
+				pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+					State: corev1.ContainerState{
+						Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"},
+					},
+				}}
+				k8sClient = fake.NewSimpleClientset(pod)
+				networkToAdd = fmt.Sprintf("%s-2", networkName)
+				nadClient, err := newFakeNetAttachDefClient(
+					tinyNetAttachDef(),
+					netAttachDef(networkToAdd, namespace, dummyNetSpec(networkToAdd, cniVersion)))
+				Expect(err).NotTo(HaveOccurred())
+				stopChannel = make(chan struct{})
+				const maxEvents = 5
+				eventRecorder = record.NewFakeRecorder(maxEvents)
+				_, err = newDummyPodController(
+					k8sClient,
+					nadClient,
+					stopChannel,
+					eventRecorder,
+					fakecri.NewFakeRuntime(*runningPod),
+					fakemultusclient.NewFakeClient(
+						networkConfig(multuscni.CmdAdd, "net1", networkName, macAddr)))
+				Expect(err).NotTo(HaveOccurred())
+
+				pod, err = k8sClient.CoreV1().Pods(namespace).UpdateStatus(
+					context.TODO(),
+					updatePodSpec(pod, networkName, networkToAdd),
+					metav1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				close(stopChannel)
+			})
+
+			It("the attachment is added once the pod starts running", func() {
+				Consistently(eventRecorder.Events).ShouldNot(Receive())
+
+				pod.Status.ContainerStatuses = []corev1.ContainerStatus{runningContainer("containerd://" + podName)}
+				_, err := k8sClient.CoreV1().Pods(namespace).UpdateStatus(context.TODO(), pod, metav1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+
+				expectedEventPayload := fmt.Sprintf(
+					"Normal AddedInterface pod [%s]: added interface %s to network: %s",
+					annotations.NamespacedName(namespace, podName),
+					"net1",
+					networkToAdd,
+				)
+				Eventually(eventRecorder.Events, 2*podNotRunningRequeueDelay).Should(Receive(Equal(expectedEventPayload)))
+			})
+		})
+
+		Context("with a running pod whose interfaces drifted from its network selection elements", func() {
+			var (
+				eventRecorder *record.FakeRecorder
+				networkToAdd  string
+				stopChannel   chan struct{}
+			)
+
+			BeforeEach(func() {
+				networkToAdd = fmt.Sprintf("%s-2", networkName)
+				pod := podSpec(podName, namespace, networkName)
+				pod.Annotations[nad.NetworkAttachmentAnnot] = generateNetworkSelectionAnnotation(
+					namespace, networkName, networkToAdd)
+				k8sClient := fake.NewSimpleClientset(pod)
+				nadClient, err := newFakeNetAttachDefClient(
+					tinyNetAttachDef(),
+					netAttachDef(networkToAdd, namespace, dummyNetSpec(networkToAdd, cniVersion)))
+				Expect(err).NotTo(HaveOccurred())
+				stopChannel = make(chan struct{})
+				const maxEvents = 5
+				eventRecorder = record.NewFakeRecorder(maxEvents)
+				_, err = newDummyPodController(
+					k8sClient,
+					nadClient,
+					stopChannel,
+					eventRecorder,
+					fakecri.NewFakeRuntime(*pod),
+					fakemultusclient.NewFakeClient(
+						networkConfig(multuscni.CmdAdd, "net1", networkName, macAddr)),
+					WithResyncPeriod(time.Hour))
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				close(stopChannel)
+			})
+
+			It("the missing attachment is added when the controller starts", func() {
+				expectedEventPayload := fmt.Sprintf(
+					"Normal AddedInterface pod [%s]: added interface %s to network: %s",
+					annotations.NamespacedName(namespace, podName),
+					"net1",
+					networkToAdd,
+				)
+				Eventually(eventRecorder.Events).Should(Receive(Equal(expectedEventPayload)))
+			})
+		})
+
+		Context("with a running pod", func() {
+			var (
+				eventRecorder *record.FakeRecorder
+				multusClient  *fakemultusclient.Client
+				podController *dummyPodController
+				stopChannel   chan struct{}
+			)
+
+			BeforeEach(func() {
+				pod := podSpec(podName, namespace, networkName)
+				k8sClient := fake.NewSimpleClientset(pod)
+				nadClient, err := newFakeNetAttachDefClient(
+					tinyNetAttachDef())
+				Expect(err).NotTo(HaveOccurred())
+				multusClient = fakemultusclient.NewFakeClient(
+					networkConfig(multuscni.CmdAdd, "net1", networkName, macAddr),
+					networkConfig(multuscni.CmdDel, "net1", "", ""),
+					networkConfig(multuscni.CmdAdd, "net3", networkName, macAddr))
+				stopChannel = make(chan struct{})
+				const maxEvents = 10
+				eventRecorder = record.NewFakeRecorder(maxEvents)
+				podController, err = newDummyPodController(
+					k8sClient,
+					nadClient,
+					stopChannel,
+					eventRecorder,
+					fakecri.NewFakeRuntime(*pod),
+					multusClient)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				close(stopChannel)
+			})
+
+			When("adding one of the attachments of a request fails", func() {
+				BeforeEach(func() {
+					podController.enqueue(&DynamicAttachmentRequest{
+						PodName:      podName,
+						PodNamespace: namespace,
+						AttachmentNames: []*nad.NetworkSelectionElement{
+							{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"},
+							{Name: networkName, Namespace: namespace, InterfaceRequest: "net2"},
+							{Name: networkName, Namespace: namespace, InterfaceRequest: "net3"},
+						},
+						Type:     add,
+						PodNetNS: netnsPath,
+					})
+				})
+
+				It("the attachments previously added by the request are removed", func() {
+					issuedCommands := func() []string {
+						var commands []string
+						for _, request := range multusClient.Requests() {
+							commands = append(commands, request.Env["CNI_COMMAND"]+"_"+request.Env["CNI_IFNAME"])
+						}
+						return commands
+					}
+					firstIssuedCommands := func() []string {
+						const numCommands = 3
+						commands := issuedCommands()
+						if len(commands) > numCommands {
+							return commands[:numCommands]
+						}
+						return commands
+					}
+					Eventually(firstIssuedCommands).Should(Equal([]string{"ADD_net1", "ADD_net2", "DEL_net1"}))
+					Consistently(issuedCommands).ShouldNot(ContainElement("ADD_net3"))
+				})
+
+				It("an `AddInterfaceFailed` warning event is seen in the event recorded", func() {
+					expectedEventPayload := fmt.Sprintf(
+						"Warning AddInterfaceFailed pod [%s]: failed adding interface %s to network: %s: %s",
+						annotations.NamespacedName(namespace, podName),
+						"net2",
+						networkName,
+						"failed to ADD delegate: not found",
+					)
+					Eventually(eventRecorder.Events).Should(Receive(Equal(expectedEventPayload)))
+				})
+			})
+		})
+
+		Context("with multiple running pods", func() {
+			const (
+				numPods     = 5
+				workerCount = 3
+			)
+			var (
+				multusClient *fakemultusclient.Client
+				pods         []*corev1.Pod
+				stopChannel  chan struct{}
+			)
+
+			BeforeEach(func() {
+				pods = nil
+				var runtimeObjects []runtime.Object
+				for i := 0; i < numPods; i++ {
+					pod := podSpec(fmt.Sprintf("pod%d", i), namespace)
+					pods = append(pods, pod)
+					runtimeObjects = append(runtimeObjects, pod)
+				}
+				k8sClient := fake.NewSimpleClientset(runtimeObjects...)
+				nadClient, err := newFakeNetAttachDefClient(
+					tinyNetAttachDef())
+				Expect(err).NotTo(HaveOccurred())
+				multusClient = fakemultusclient.NewFakeClient(
+					networkConfig(multuscni.CmdAdd, "net1", networkName, "02:03:04:05:06:07"),
+					networkConfig(multuscni.CmdDel, "net1", "", ""))
+
+				stopChannel = make(chan struct{})
+				podController, err := newDummyPodController(
+					k8sClient,
+					nadClient,
+					stopChannel,
+					nil,
+					fakecri.NewFakeRuntime(),
+					multusClient,
+					WithWorkers(workerCount))
+				Expect(err).NotTo(HaveOccurred())
+
+				attachment := &nad.NetworkSelectionElement{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"}
+				for _, pod := range pods {
+					for _, requestType := range []DynamicAttachmentRequestType{"add", "remove"} {
+						podController.enqueue(&DynamicAttachmentRequest{
+							PodName:         pod.GetName(),
+							PodNamespace:    pod.GetNamespace(),
+							AttachmentNames: []*nad.NetworkSelectionElement{attachment},
+							Type:            requestType,
+							PodNetNS:        "/var/run/netns/" + pod.GetName(),
+						})
+					}
+				}
+			})
+
+			AfterEach(func() {
+				close(stopChannel)
+			})
+
+			It("processes the requests of each pod in order", func() {
+				commandsPerPod := func() map[string][]string {
+					commands := map[string][]string{}
+					for _, request := range multusClient.Requests() {
+						netnsPath := request.Env["CNI_NETNS"]
+						commands[netnsPath] = append(commands[netnsPath], request.Env["CNI_COMMAND"])
+					}
+					return commands
+				}
+
+				expectedCommands := map[string][]string{}
+				for _, pod := range pods {
+					expectedCommands["/var/run/netns/"+pod.GetName()] = []string{multuscni.CmdAdd, multuscni.CmdDel}
+				}
+				Eventually(commandsPerPod).Should(Equal(expectedCommands))
+			})
+		})
+	})
+})
+
+var _ = Describe("The pod's container ID", func() {
+	podWithContainers := func(containerStatuses ...corev1.ContainerStatus) *corev1.Pod {
+		pod := podSpec(podName, namespace)
+		pod.Status.ContainerStatuses = containerStatuses
+		return pod
+	}
+
+	It("is read from the first running container", func() {
+		Expect(podContainerID(podWithContainers(
+			runningContainer("containerd://1234"),
+			runningContainer("containerd://5678")))).To(Equal("1234"))
+	})
+
+	It("skips crashed containers without a container ID", func() {
+		crashedContainer := corev1.ContainerStatus{
+			State: corev1.ContainerState{
+				Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"},
+			},
+		}
+		Expect(podContainerID(podWithContainers(
+			crashedContainer,
+			runningContainer("containerd://5678")))).To(Equal("5678"))
+	})
+
+	It("skips terminated containers", func() {
+		terminatedContainer := corev1.ContainerStatus{
+			ContainerID: "containerd://1234",
+			State: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{ExitCode: 1},
+			},
+		}
+		Expect(podContainerID(podWithContainers(
+			terminatedContainer,
+			runningContainer("containerd://5678")))).To(Equal("5678"))
+	})
+
+	It("is empty when none of the containers is running", func() {
+		waitingContainer := corev1.ContainerStatus{
+			ContainerID: "containerd://1234",
+			State: corev1.ContainerState{
+				Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"},
+			},
+		}
+		Expect(podContainerID(podWithContainers(waitingContainer))).To(BeEmpty())
+	})
+
+	It("is read from a running ephemeral container when no other container runs", func() {
+		terminatedContainer := corev1.ContainerStatus{
+			ContainerID: "containerd://1234",
+			State: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{ExitCode: 1},
+			},
+		}
+		pod := podWithContainers(terminatedContainer)
+		pod.Status.EphemeralContainerStatuses = []corev1.ContainerStatus{runningContainer("containerd://5678")}
+
+		Expect(podContainerID(pod)).To(Equal("5678"))
+	})
+
+	It("prefers the regular containers over the ephemeral ones", func() {
+		pod := podWithContainers(runningContainer("containerd://1234"))
+		pod.Status.EphemeralContainerStatuses = []corev1.ContainerStatus{runningContainer("containerd://5678")}
+
+		Expect(podContainerID(pod)).To(Equal("1234"))
+	})
+
+	It("is parsed from the URIs of any container runtime", func() {
+		for _, containerIDURI := range []string{"docker://1234", "containerd://1234", "cri-o://1234"} {
+			Expect(podContainerID(podWithContainers(runningContainer(containerIDURI)))).To(Equal("1234"))
+		}
+	})
+
+	It("resolves the network namespace of the pods running on CRI-O", func() {
+		containerRuntime := fakecri.NewFakeRuntime(*podSpec(podName, namespace))
+		podController := newUnstartedPodController(containerRuntime, fakemultusclient.NewFakeClient())
+		expectedNetnsPath, err := containerRuntime.NetNS(podName)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(podController.netnsPath(podWithContainers(runningContainer("cri-o://" + podName)))).To(Equal(expectedNetnsPath))
+	})
+
+	It("cannot be parsed from malformed URIs", func() {
+		for _, containerIDURI := range []string{"1234", "://1234", "containerd://", "containerd:/1234", "containerd://12/34"} {
+			_, err := podContainerID(podWithContainers(runningContainer(containerIDURI)))
+			Expect(err).To(HaveOccurred())
+		}
+	})
+})
+
+var _ = Describe("Requests waiting for the pod to run", func() {
+	var (
+		multusClient  *fakemultusclient.Client
+		pod           *corev1.Pod
+		podController *PodNetworksController
+	)
+
+	podKey := annotations.NamespacedName(namespace, podName)
+
+	BeforeEach(func() {
+		pod = podSpec(podName, namespace)
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+			State: corev1.ContainerState{
+				Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"},
+			},
+		}}
+		multusClient = fakemultusclient.NewFakeClient()
+	})
+
+	processRequest := func(opts ...Option) {
+		podController = newUnstartedPodController(fakecri.NewFakeRuntime(), multusClient, opts...)
+		Expect(podController.podsInformer.GetStore().Add(pod)).To(Succeed())
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:         podName,
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"}},
+			Type:            "add",
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+	}
+
+	It("are re-queued while the pod starts", func() {
+		processRequest()
+
+		Expect(multusClient.Requests()).To(BeEmpty())
+		Expect(podController.pendingRequests.peek(podKey)).NotTo(BeNil())
+	})
+
+	It("are dropped once the pod terminated", func() {
+		pod.Status.Phase = corev1.PodSucceeded
+		processRequest()
+
+		Expect(multusClient.Requests()).To(BeEmpty())
+		Expect(podController.pendingRequests.peek(podKey)).To(BeNil())
+		Expect(podController.workqueue.NumRequeues(podKey)).To(BeZero())
+	})
+
+	It("are dropped when the pod does not run in time", func() {
+		fakeClock := clocktesting.NewFakeClock(time.Now())
+		processRequest(WithClock(fakeClock))
+		Expect(podController.pendingRequests.peek(podKey)).NotTo(BeNil())
+
+		// the re-queued request fails anew once the pod has not run in time
+		fakeClock.Step(podNotRunningTimeout)
+		podController.handleResult(errNoRunningContainers, podController.pendingRequests.peek(podKey))
+
+		Expect(multusClient.Requests()).To(BeEmpty())
+		Expect(podController.pendingRequests.peek(podKey)).To(BeNil())
+		Expect(podController.workqueue.NumRequeues(podKey)).To(BeZero())
+	})
+})
+
+var _ = Describe("Pod updates", func() {
+	var podController *PodNetworksController
+
+	BeforeEach(func() {
+		podController = newUnstartedPodController(fakecri.NewFakeRuntime(), fakemultusclient.NewFakeClient())
+	})
+
+	Context("transitioning the networks annotation", func() {
+		var pod *corev1.Pod
+
+		BeforeEach(func() {
+			pod = podSpec(podName, namespace)
+			delete(pod.Annotations, nad.NetworkAttachmentAnnot)
+			podController = newUnstartedPodController(fakecri.NewFakeRuntime(*pod), fakemultusclient.NewFakeClient())
+		})
+
+		pendingRequest := func() *DynamicAttachmentRequest {
+			return podController.pendingRequests.peek(annotations.NamespacedName(namespace, podName))
+		}
+
+		It("from none to one network adds it", func() {
+			podController.handlePodUpdate(pod, updatePodSpec(pod, networkName))
+
+			Expect(pendingRequest()).NotTo(BeNil())
+			Expect(pendingRequest().Type).To(Equal(add))
+			Expect(pendingRequest().AttachmentNames).To(ConsistOf(
+				&nad.NetworkSelectionElement{Name: networkName, Namespace: namespace, InterfaceRequest: "net0"}))
+		})
+
+		It("from one network to none removes it", func() {
+			podController.handlePodUpdate(updatePodSpec(pod, networkName), pod)
+
+			Expect(pendingRequest()).NotTo(BeNil())
+			Expect(pendingRequest().Type).To(Equal(remove))
+			Expect(pendingRequest().AttachmentNames).To(ConsistOf(
+				&nad.NetworkSelectionElement{Name: networkName, Namespace: namespace, InterfaceRequest: "net0"}))
+		})
+
+		It("from none to none does nothing", func() {
+			updatedPod := pod.DeepCopy()
+			updatedPod.Annotations["unrelated"] = "annotation"
+			podController.handlePodUpdate(pod, updatedPod)
+
+			Expect(pendingRequest()).To(BeNil())
+		})
+
+		withAttachments := func(attachments ...nad.NetworkSelectionElement) *corev1.Pod {
+			serializedAttachments, err := json.Marshal(attachments)
+			Expect(err).NotTo(HaveOccurred())
+			updatedPod := pod.DeepCopy()
+			updatedPod.Annotations[nad.NetworkAttachmentAnnot] = string(serializedAttachments)
+			return updatedPod
+		}
+
+		It("changing only the requested IPs of an interface re-creates it", func() {
+			oldAttachment := nad.NetworkSelectionElement{
+				Name: networkName, Namespace: namespace, InterfaceRequest: "net1", IPRequest: []string{"10.10.10.10/24"},
+			}
+			newAttachment := oldAttachment
+			newAttachment.IPRequest = []string{"10.10.10.11/24", "fd10::11/64"}
+			podController.handlePodUpdate(withAttachments(oldAttachment), withAttachments(newAttachment))
+
+			podKey := annotations.NamespacedName(namespace, podName)
+			Expect(pendingRequest()).NotTo(BeNil())
+			Expect(pendingRequest().Type).To(Equal(replace))
+			Expect(pendingRequest().ReplacedAttachmentNames).To(ConsistOf(&oldAttachment))
+			Expect(pendingRequest().AttachmentNames).To(ConsistOf(&newAttachment))
+			Expect(podController.pendingRequests.pop(podKey)).To(BeZero())
+		})
+
+		It("reordering the requested IPs of an interface does nothing", func() {
+			oldAttachment := nad.NetworkSelectionElement{
+				Name: networkName, Namespace: namespace, InterfaceRequest: "net1", IPRequest: []string{"10.10.10.10/24", "fd10::10/64"},
+			}
+			newAttachment := oldAttachment
+			newAttachment.IPRequest = []string{"fd10::10/64", "10.10.10.10/24"}
+			podController.handlePodUpdate(withAttachments(oldAttachment), withAttachments(newAttachment))
+
+			Expect(pendingRequest()).To(BeNil())
+		})
+
+		It("rewriting the networks in the JSON syntax does nothing", func() {
+			shortSyntaxPod := pod.DeepCopy()
+			shortSyntaxPod.Annotations[nad.NetworkAttachmentAnnot] = fmt.Sprintf("%s@net1, %s/other-net", networkName, namespace)
+			jsonSyntaxPod := withAttachments(
+				nad.NetworkSelectionElement{Name: networkName, InterfaceRequest: "net1"},
+				nad.NetworkSelectionElement{Name: "other-net", Namespace: namespace})
+			podController.handlePodUpdate(shortSyntaxPod, jsonSyntaxPod)
+
+			Expect(pendingRequest()).To(BeNil())
+			Expect(podController.workqueue.Len()).To(BeZero())
+		})
+
+		It("from a malformed annotation adds the networks not yet attached, reporting the malformed annotation", func() {
+			const maxEvents = 1
+			eventRecorder := record.NewFakeRecorder(maxEvents)
+			podController.recorder = eventRecorder
+			// net0 is attached to the network, as listed in the network-status
+			attachedPod := podSpec(podName, namespace, networkName)
+			malformedPod := attachedPod.DeepCopy()
+			malformedPod.Annotations[nad.NetworkAttachmentAnnot] = `[{"name": "tiny-net",`
+
+			podController.handlePodUpdate(malformedPod, updatePodSpec(attachedPod, networkName, "other-net"))
+
+			Expect(pendingRequest()).NotTo(BeNil())
+			Expect(pendingRequest().Type).To(Equal(add))
+			Expect(pendingRequest().AttachmentNames).To(ConsistOf(
+				&nad.NetworkSelectionElement{Name: "other-net", Namespace: namespace, InterfaceRequest: "net1"}))
+			Expect(eventRecorder.Events).To(Receive(HavePrefix(fmt.Sprintf(
+				"Warning MalformedNetworksAnnotation pod [%s]: ignored the malformed previous networks annotation, handling the pod as if it had no networks: ",
+				annotations.NamespacedName(namespace, podName)))))
+		})
+
+		It("deleted wholesale removes all the networks", func() {
+			attachedPod := updatePodSpec(pod, networkName, "other-net")
+
+			podController.handlePodUpdate(attachedPod, pod)
+
+			Expect(pendingRequest()).NotTo(BeNil())
+			Expect(pendingRequest().Type).To(Equal(remove))
+			Expect(pendingRequest().AttachmentNames).To(ConsistOf(
+				&nad.NetworkSelectionElement{Name: networkName, Namespace: namespace, InterfaceRequest: "net0"},
+				&nad.NetworkSelectionElement{Name: "other-net", Namespace: namespace, InterfaceRequest: "net1"}))
+		})
+
+		It("deleted wholesale after a malformed annotation removes the dynamic interfaces", func() {
+			podController.recorder = record.NewFakeRecorder(1)
+			attachedPod := podSpec(podName, namespace, networkName, "other-net")
+			attachedPod.Annotations[nad.NetworkStatusAnnot] = podNetworkStatusAnnotations(namespace, networkName, "other-net")
+			attachedPod.Annotations[defaultBookkeepingKeys.DynamicInterfaces] = `["net1"]`
+			malformedPod := attachedPod.DeepCopy()
+			malformedPod.Annotations[nad.NetworkAttachmentAnnot] = `[{"name": "tiny-net",`
+			updatedPod := attachedPod.DeepCopy()
+			delete(updatedPod.Annotations, nad.NetworkAttachmentAnnot)
+
+			podController.handlePodUpdate(malformedPod, updatedPod)
+
+			Expect(pendingRequest()).NotTo(BeNil())
+			Expect(pendingRequest().Type).To(Equal(remove))
+			Expect(pendingRequest().AttachmentNames).To(ConsistOf(
+				&nad.NetworkSelectionElement{Name: "other-net", Namespace: namespace, InterfaceRequest: "net1"}))
+		})
+
+		It("of a pod with networks ignores the changes of unrelated annotations", func() {
+			attachedPod := updatePodSpec(pod, networkName)
+			updatedPod := attachedPod.DeepCopy()
+			updatedPod.Annotations["sidecar.istio.io/status"] = "injected"
+			podController.handlePodUpdate(attachedPod, updatedPod)
+
+			Expect(pendingRequest()).To(BeNil())
+			Expect(podController.workqueue.Len()).To(BeZero())
+		})
+
+		When("the pod is terminating", func() {
+			BeforeEach(func() {
+				deletionTimestamp := metav1.Now()
+				pod.DeletionTimestamp = &deletionTimestamp
+			})
+
+			It("from none to one network does not add it", func() {
+				podController.handlePodUpdate(pod, updatePodSpec(pod, networkName))
+
+				Expect(pendingRequest()).To(BeNil())
+				Expect(podController.workqueue.Len()).To(BeZero())
+			})
+
+			It("from one network to none removes it", func() {
+				podController.handlePodUpdate(updatePodSpec(pod, networkName), pod)
+
+				Expect(pendingRequest()).NotTo(BeNil())
+				Expect(pendingRequest().Type).To(Equal(remove))
+			})
+		})
+	})
+
+	Context("with a pod selector", func() {
+		const selectorLabel = "dynamic-networks"
+
+		BeforeEach(func() {
+			pod := podSpec(podName, namespace, networkName)
+			podController = newUnstartedPodController(
+				fakecri.NewFakeRuntime(*pod),
+				fakemultusclient.NewFakeClient(),
+				WithPodSelector(labels.SelectorFromSet(labels.Set{selectorLabel: "enabled"})))
+		})
+
+		It("ignores the pods not matching it", func() {
+			pod := podSpec(podName, namespace, networkName)
+			podController.handlePodUpdate(pod, updatePodSpec(pod, networkName, "new-net"))
+
+			Expect(podController.workqueue.Len()).To(BeZero())
+			Expect(podController.pendingRequests.has(annotations.NamespacedName(namespace, podName))).To(BeFalse())
+		})
+
+		It("handles the pods matching it", func() {
+			pod := podSpec(podName, namespace, networkName)
+			pod.Labels = map[string]string{selectorLabel: "enabled"}
+			podController.handlePodUpdate(pod, updatePodSpec(pod, networkName, "new-net"))
+
+			Expect(podController.workqueue.Len()).To(Equal(1))
+			Expect(podController.pendingRequests.has(annotations.NamespacedName(namespace, podName))).To(BeTrue())
+		})
+	})
+
+	It("requesting the same interface name for different networks are rejected", func() {
+		const (
+			maxEvents     = 1
+			duplicateName = "eth1"
+		)
+		eventRecorder := record.NewFakeRecorder(maxEvents)
+		podController.recorder = eventRecorder
+
+		pod := podSpec(podName, namespace, networkName)
+		updatedPod := pod.DeepCopy()
+		updatedPod.Annotations[nad.NetworkAttachmentAnnot] = fmt.Sprintf(
+			`[{"name":"net-a","namespace":"%[1]s","interface":"%[2]s"},{"name":"net-b","namespace":"%[1]s","interface":"%[2]s"}]`,
+			namespace,
+			duplicateName)
+		podController.handlePodUpdate(pod, updatedPod)
+
+		Expect(podController.workqueue.Len()).To(BeZero())
+		Expect(eventRecorder.Events).To(Receive(Equal(fmt.Sprintf(
+			"Warning NetworksUpdateRejected pod [%s]: rejected the networks update: interfaces %s are requested by multiple networks",
+			annotations.NamespacedName(namespace, podName),
+			duplicateName))))
+	})
+
+	Context("requesting interface names Linux would not accept", func() {
+		const maxEvents = 1
+
+		var eventRecorder *record.FakeRecorder
+
+		BeforeEach(func() {
+			podController = newUnstartedPodController(fakecri.NewFakeRuntime(*podSpec(podName, namespace)), fakemultusclient.NewFakeClient())
+			eventRecorder = record.NewFakeRecorder(maxEvents)
+			podController.recorder = eventRecorder
+		})
+
+		requestInterface := func(ifaceName string) {
+			pod := podSpec(podName, namespace, networkName)
+			updatedPod := pod.DeepCopy()
+			updatedPod.Annotations[nad.NetworkAttachmentAnnot] = fmt.Sprintf(
+				`[{"name":%q,"namespace":%q,"interface":%q}]`, "net-a", namespace, ifaceName)
+			podController.handlePodUpdate(pod, updatedPod)
+		}
+
+		It("rejects a name longer than 15 characters", func() {
+			const tooLongName = "net-abcdefghijkl"
+
+			requestInterface(tooLongName)
+
+			Expect(podController.workqueue.Len()).To(BeZero())
+			Expect(eventRecorder.Events).To(Receive(Equal(fmt.Sprintf(
+				"Warning NetworksUpdateRejected pod [%s]: rejected the networks update: interface name %q is longer than 15 characters",
+				annotations.NamespacedName(namespace, podName),
+				tooLongName))))
+		})
+
+		It("rejects a name featuring invalid characters", func() {
+			for _, invalidName := range []string{"net/1", "net:1", "net 1"} {
+				requestInterface(invalidName)
+
+				Expect(podController.workqueue.Len()).To(BeZero())
+				Expect(eventRecorder.Events).To(Receive(Equal(fmt.Sprintf(
+					"Warning NetworksUpdateRejected pod [%s]: rejected the networks update: interface name %q features invalid characters: '/', ':', or whitespace",
+					annotations.NamespacedName(namespace, podName),
+					invalidName))))
+			}
+		})
+
+		It("accepts a 15 characters name", func() {
+			requestInterface("net-abcdefghijk")
+
+			Expect(podController.workqueue.Len()).To(Equal(1))
+			Expect(eventRecorder.Events).NotTo(Receive())
+		})
+	})
+
+	It("requesting more networks than allowed are rejected", func() {
+		const (
+			maxEvents      = 1
+			maxAttachments = 2
+		)
+		eventRecorder := record.NewFakeRecorder(maxEvents)
+		podController.recorder = eventRecorder
+		podController.maxAttachmentsPerPod = maxAttachments
+		rejectedUpdates := &dto.Metric{}
+		Expect(metrics.RejectedNetworksUpdates.WithLabelValues(tooManyAttachmentsReason).Write(rejectedUpdates)).To(Succeed())
+
+		pod := podSpec(podName, namespace, networkName)
+		podController.handlePodUpdate(pod, updatePodSpec(pod, networkName, "net-a", "net-b"))
+
+		Expect(podController.workqueue.Len()).To(BeZero())
+		Expect(podController.pendingRequests.has(annotations.NamespacedName(namespace, podName))).To(BeFalse())
+		Expect(eventRecorder.Events).To(Receive(Equal(fmt.Sprintf(
+			"Warning NetworksUpdateRejected pod [%s]: rejected the networks update: 3 networks requested, at most %d are allowed",
+			annotations.NamespacedName(namespace, podName),
+			maxAttachments))))
+		metric := &dto.Metric{}
+		Expect(metrics.RejectedNetworksUpdates.WithLabelValues(tooManyAttachmentsReason).Write(metric)).To(Succeed())
+		Expect(metric.GetCounter().GetValue()).To(Equal(rejectedUpdates.GetCounter().GetValue() + 1))
+	})
+
+	It("of pods using the host network are rejected", func() {
+		const maxEvents = 1
+		eventRecorder := record.NewFakeRecorder(maxEvents)
+		podController.recorder = eventRecorder
+
+		pod := podSpec(podName, namespace, networkName)
+		pod.Spec.HostNetwork = true
+		podController.handlePodUpdate(pod, updatePodSpec(pod, networkName, "new-net"))
+
+		Expect(podController.workqueue.Len()).To(BeZero())
+		Expect(podController.pendingRequests.has(annotations.NamespacedName(namespace, podName))).To(BeFalse())
+		Expect(eventRecorder.Events).To(Receive(Equal(fmt.Sprintf(
+			"Warning NetworksUpdateRejected pod [%s]: rejected the networks update: the pod uses the host network",
+			annotations.NamespacedName(namespace, podName)))))
+	})
+
+	It("of mirror pods are rejected", func() {
+		const maxEvents = 1
+		eventRecorder := record.NewFakeRecorder(maxEvents)
+		podController.recorder = eventRecorder
+
+		pod := podSpec(podName, namespace, networkName)
+		pod.Annotations[corev1.MirrorPodAnnotationKey] = "0123456789abcdef"
+		podController.handlePodUpdate(pod, updatePodSpec(pod, networkName, "new-net"))
+
+		Expect(podController.workqueue.Len()).To(BeZero())
+		Expect(podController.pendingRequests.has(annotations.NamespacedName(namespace, podName))).To(BeFalse())
+		Expect(eventRecorder.Events).To(Receive(Equal(fmt.Sprintf(
+			"Warning NetworksUpdateRejected pod [%s]: rejected the networks update: the pod mirrors a static pod, managed by the kubelet",
+			annotations.NamespacedName(namespace, podName)))))
+	})
+
+	It("are deferred for pods without any container statuses", func() {
+		pod := podSpec(podName, namespace, networkName)
+		pod.Status.ContainerStatuses = nil
+
+		Expect(func() {
+			podController.handlePodUpdate(pod, updatePodSpec(pod, networkName, "new-net"))
+		}).NotTo(Panic())
+		Expect(podController.workqueue.Len()).To(Equal(1))
+		pendingRequest := podController.pendingRequests.peek(annotations.NamespacedName(namespace, podName))
+		Expect(pendingRequest).NotTo(BeNil())
+		Expect(pendingRequest.PodNetNS).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Duplicate requests", func() {
+	var (
+		multusClient  *fakemultusclient.Client
+		pod           *corev1.Pod
+		podController *PodNetworksController
+	)
+
+	BeforeEach(func() {
+		pod = podSpec(podName, namespace, networkName)
+		multusClient = fakemultusclient.NewFakeClient(
+			networkConfig(multuscni.CmdDel, "net0", "", ""),
+			networkConfig(multuscni.CmdDel, "net1", "", ""))
+		podController = newUnstartedPodController(fakecri.NewFakeRuntime(*pod), multusClient)
+		networkAttachment := tinyNetAttachDef()
+		Expect(podController.netAttachDefInformer.GetStore().Add(&networkAttachment)).To(Succeed())
+	})
+
+	removeRequest := func(interfaceNames ...string) *DynamicAttachmentRequest {
+		var attachments []*nad.NetworkSelectionElement
+		for _, interfaceName := range interfaceNames {
+			attachments = append(
+				attachments,
+				&nad.NetworkSelectionElement{Name: networkName, Namespace: namespace, InterfaceRequest: interfaceName})
+		}
+		return &DynamicAttachmentRequest{
+			PodName:         podName,
+			PodNamespace:    namespace,
+			AttachmentNames: attachments,
+			Type:            remove,
+			PodNetNS:        netnsPath,
+			deletedPod:      pod,
+		}
+	}
+
+	It("are only processed once", func() {
+		podController.enqueue(removeRequest("net0", "net1"))
+		podController.enqueue(removeRequest("net1", "net0"))
+
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+		Expect(multusClient.Requests()).To(HaveLen(2))
+		Expect(podController.workqueue.Len()).To(BeZero())
+	})
+
+	It("are processed again when an opposite request is pending between them", func() {
+		addRequest := removeRequest("net0")
+		addRequest.Type = add
+		podController.enqueue(removeRequest("net0"))
+		podController.enqueue(addRequest)
+		podController.enqueue(removeRequest("net0"))
+
+		Expect(podController.pendingRequests.pop(annotations.NamespacedName(namespace, podName))).To(Equal(2))
+	})
+
+	It("are assigned distinct correlation IDs when enqueued", func() {
+		firstRequest := removeRequest("net0")
+		secondRequest := removeRequest("net1")
+		podController.enqueue(firstRequest)
+		podController.enqueue(secondRequest)
+
+		Expect(firstRequest.ID).NotTo(BeEmpty())
+		Expect(secondRequest.ID).NotTo(BeEmpty())
+		Expect(firstRequest.ID).NotTo(Equal(secondRequest.ID))
+	})
+})
+
+var _ = Describe("Implicit interface names", func() {
+	It("are the lowest indexed names not used by the pod", func() {
+		addInterfaceConfig := func(ifaceName string) fakemultusclient.NetworkConfig {
+			config := networkConfig(multuscni.CmdAdd, ifaceName, ifaceName, macAddr)
+			config.Response.Result.Interfaces[0].Sandbox = netnsPath
+			return config
+		}
+
+		pod := podSpec(podName, namespace, networkName)
+		multusClient := fakemultusclient.NewFakeClient(
+			addInterfaceConfig("net1"),
+			addInterfaceConfig("net2"),
+			addInterfaceConfig("net3"))
+		podController := newSynchedPodController(
+			pod,
+			multusClient,
+			tinyNetAttachDef())
+
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:      podName,
+			PodNamespace: namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{
+				{Name: networkName, Namespace: namespace},
+				{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"},
+				{Name: networkName, Namespace: namespace},
+			},
+			Type:     add,
+			PodNetNS: netnsPath,
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		var ifaceNames []string
+		for _, request := range multusClient.Requests() {
+			ifaceNames = append(ifaceNames, request.Env["CNI_IFNAME"])
+		}
+		Expect(ifaceNames).To(Equal([]string{"net2", "net1", "net3"}))
+
+		updatedPod, err := podController.k8sClientSet.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		currentNetworks, err := networkStatus(updatedPod.Annotations)
+		Expect(err).NotTo(HaveOccurred())
+		var statusIfaceNames []string
+		for _, currentNetwork := range currentNetworks {
+			statusIfaceNames = append(statusIfaceNames, currentNetwork.Interface)
+		}
+		Expect(statusIfaceNames).To(Equal([]string{"net0", "net1", "net2", "net3"}))
+	})
+
+	It("are removed without resolving to the interfaces requested by name", func() {
+		pod := podSpec(podName, namespace)
+		// net9 is requested by name, net2 was picked for the implicitly named attachment
+		pod.Annotations[nad.NetworkAttachmentAnnot] = fmt.Sprintf(`[{"name":%q,"namespace":%q,"interface":"net9"}]`, networkName, namespace)
+		pod.Annotations[nad.NetworkStatusAnnot] = fmt.Sprintf(
+			`[{"name":"%[1]s/%[2]s","interface":"net2"},{"name":"%[1]s/%[2]s","interface":"net9"}]`, namespace, networkName)
+		podController := newUnstartedPodController(fakecri.NewFakeRuntime(*pod), fakemultusclient.NewFakeClient())
+
+		netsToRemove := podController.attachedInterfaces(
+			context.Background(),
+			pod,
+			[]*nad.NetworkSelectionElement{{Name: networkName, Namespace: namespace}})
+		Expect(netsToRemove).To(ConsistOf(&nad.NetworkSelectionElement{Name: networkName, Namespace: namespace, InterfaceRequest: "net2"}))
+	})
+
+	It("are each removed from their own interface when removed together", func() {
+		pod := podSpec(podName, namespace)
+		pod.Annotations[nad.NetworkStatusAnnot] = fmt.Sprintf(
+			`[{"name":"%[1]s/%[2]s","interface":"net1"},{"name":"%[1]s/%[2]s","interface":"net2"}]`, namespace, networkName)
+		podController := newUnstartedPodController(fakecri.NewFakeRuntime(*pod), fakemultusclient.NewFakeClient())
+
+		netsToRemove := podController.attachedInterfaces(
+			context.Background(),
+			pod,
+			[]*nad.NetworkSelectionElement{{Name: networkName, Namespace: namespace}, {Name: networkName, Namespace: namespace}})
+		Expect(netsToRemove).To(ConsistOf(
+			&nad.NetworkSelectionElement{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"},
+			&nad.NetworkSelectionElement{Name: networkName, Namespace: namespace, InterfaceRequest: "net2"}))
+	})
+
+	It("are preserved when their attachments are removed then added back", func() {
+		const otherNetwork = "other-net"
+		addInterfaceConfig := func(ifaceName string) fakemultusclient.NetworkConfig {
+			config := networkConfig(multuscni.CmdAdd, ifaceName, ifaceName, macAddr)
+			config.Response.Result.Interfaces[0].Sandbox = netnsPath
+			return config
+		}
+
+		pod := podSpec(podName, namespace)
+		multusClient := fakemultusclient.NewFakeClient(
+			addInterfaceConfig("net1"),
+			addInterfaceConfig("net2"),
+			networkConfig(multuscni.CmdDel, "net1", "", ""))
+		podController := newSynchedPodController(
+			pod,
+			multusClient,
+			tinyNetAttachDef(),
+			netAttachDef(otherNetwork, namespace, dummyNetSpec(otherNetwork, cniVersion)))
+
+		handleRequest := func(requestType DynamicAttachmentRequestType, network string) {
+			Expect(podController.handleDynamicInterfaceRequest(context.Background(), &DynamicAttachmentRequest{
+				PodName:         podName,
+				PodNamespace:    namespace,
+				AttachmentNames: []*nad.NetworkSelectionElement{{Name: network, Namespace: namespace}},
+				Type:            requestType,
+				PodNetNS:        netnsPath,
+			})).To(Succeed())
+			updatedPod, err := podController.k8sClientSet.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(podController.podsInformer.GetStore().Update(updatedPod)).To(Succeed())
+		}
+
+		handleRequest(add, networkName)
+		handleRequest(remove, networkName)
+		handleRequest(add, otherNetwork)
+		handleRequest(add, networkName)
+
+		var ifaceNames []string
+		for _, request := range multusClient.Requests() {
+			ifaceNames = append(ifaceNames, request.Env["CNI_IFNAME"])
+		}
+		Expect(ifaceNames).To(Equal([]string{"net1", "net1", "net2", "net1"}))
+	})
+
+	It("are removed from their sticky interface", func() {
+		pod := podSpec(podName, namespace)
+		pod.Annotations[nad.NetworkStatusAnnot] = fmt.Sprintf(
+			`[{"name":"%[1]s/%[2]s","interface":"net1"},{"name":"%[1]s/%[2]s","interface":"net2"}]`, namespace, networkName)
+		pod.Annotations[defaultBookkeepingKeys.InterfaceNames] = fmt.Sprintf(`{"%s/%s":"net1"}`, namespace, networkName)
+		podController := newUnstartedPodController(fakecri.NewFakeRuntime(*pod), fakemultusclient.NewFakeClient())
+
+		netsToRemove := podController.attachedInterfaces(
+			context.Background(),
+			pod,
+			[]*nad.NetworkSelectionElement{{Name: networkName, Namespace: namespace}})
+		Expect(netsToRemove).To(ConsistOf(&nad.NetworkSelectionElement{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"}))
+	})
+
+	It("are told apart when computing the networks to add", func() {
+		implicitlyNamedNetwork := func() *nad.NetworkSelectionElement {
+			return &nad.NetworkSelectionElement{Name: networkName, Namespace: namespace}
+		}
+		oldNetworks := []*nad.NetworkSelectionElement{implicitlyNamedNetwork()}
+		newNetworks := []*nad.NetworkSelectionElement{
+			implicitlyNamedNetwork(),
+			{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"},
+			implicitlyNamedNetwork(),
+		}
+
+		Expect(exclusiveNetworks(newNetworks, oldNetworks)).To(ConsistOf(newNetworks[1], newNetworks[2]))
+	})
+
+	It("each match a single network-status entry", func() {
+		desiredNetworks := []*nad.NetworkSelectionElement{
+			{Name: networkName, Namespace: namespace},
+			{Name: networkName, Namespace: namespace},
+		}
+		currentNetworks := []nad.NetworkStatus{{Name: "default/tiny-net", Interface: "net1"}}
+
+		toAdd, toRemove := networkDrift(desiredNetworks, currentNetworks)
+		Expect(toAdd).To(HaveLen(1))
+		Expect(toRemove).To(BeEmpty())
+	})
+})
+
+var _ = Describe("The added interface event", func() {
+	It("features the IPs assigned to the interface", func() {
+		const maxEvents = 5
+		addInterfaceConfig := networkConfig(multuscni.CmdAdd, "net1", networkName, macAddr)
+		addInterfaceConfig.Response.Result.IPs = []*cni100.IPConfig{
+			{Address: net.IPNet{IP: net.ParseIP("10.1.2.3"), Mask: net.CIDRMask(24, 32)}},
+			{Address: net.IPNet{IP: net.ParseIP("fd10::3"), Mask: net.CIDRMask(64, 128)}},
+		}
+		pod := podSpec(podName, namespace)
+		podController := newSynchedPodController(
+			pod,
+			fakemultusclient.NewFakeClient(addInterfaceConfig),
+			tinyNetAttachDef())
+		eventRecorder := record.NewFakeRecorder(maxEvents)
+		podController.recorder = eventRecorder
+
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:         podName,
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"}},
+			Type:            add,
+			PodNetNS:        netnsPath,
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		Expect(eventRecorder.Events).To(Receive(Equal(fmt.Sprintf(
+			"Normal AddedInterface pod [%s]: added interface net1 (10.1.2.3/24,fd10::3/64) to network: %s",
+			annotations.NamespacedName(namespace, podName),
+			networkName))))
+	})
+
+	It("features the IPv6 addresses assigned to the interface in full", func() {
+		const maxEvents = 5
+		addInterfaceConfig := networkConfig(multuscni.CmdAdd, "net1", "net1", macAddr)
+		addInterfaceConfig.Response.Result.Interfaces[0].Sandbox = netnsPath
+		addInterfaceConfig.Response.Result.IPs = []*cni100.IPConfig{
+			{Address: net.IPNet{IP: net.ParseIP("2001:db8:abcd:12:1:2:3:4"), Mask: net.CIDRMask(64, 128)}},
+		}
+		pod := podSpec(podName, namespace)
+		podController := newSynchedPodController(
+			pod,
+			fakemultusclient.NewFakeClient(addInterfaceConfig),
+			tinyNetAttachDef())
+		eventRecorder := record.NewFakeRecorder(maxEvents)
+		podController.recorder = eventRecorder
+
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:         podName,
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"}},
+			Type:            add,
+			PodNetNS:        netnsPath,
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		Expect(eventRecorder.Events).To(Receive(Equal(fmt.Sprintf(
+			"Normal AddedInterface pod [%s]: added interface net1 (2001:db8:abcd:12:1:2:3:4/64) to network: %s",
+			annotations.NamespacedName(namespace, podName),
+			networkName))))
+		updatedPod, err := podController.k8sClientSet.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(networkStatus(updatedPod.Annotations)).To(ConsistOf(nad.NetworkStatus{
+			Name:      annotations.NamespacedName(namespace, networkName),
+			Interface: "net1",
+			IPs:       []string{"2001:db8:abcd:12:1:2:3:4"},
+			Mac:       macAddr,
+			DNS:       nad.DNS{},
+		}))
+	})
+})
+
+var _ = Describe("The network-status verification", func() {
+	It("writes the network-status again when the first write was lost", func() {
+		addInterfaceConfig := networkConfig(multuscni.CmdAdd, "net1", "net1", macAddr)
+		addInterfaceConfig.Response.Result.Interfaces[0].Sandbox = netnsPath
+		pod := podSpec(podName, namespace)
+		podController := newSynchedPodController(
+			pod,
+			fakemultusclient.NewFakeClient(addInterfaceConfig),
+			tinyNetAttachDef())
+		podController.verifyNetworkStatus = true
+
+		k8sClient := podController.k8sClientSet.(*fake.Clientset)
+		patches := 0
+		k8sClient.PrependReactor("patch", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			patches++
+			// a concurrent update overwrites the first network-status write
+			return patches == 1, pod, nil
+		})
+
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:         podName,
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"}},
+			Type:            add,
+			PodNetNS:        netnsPath,
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		Expect(patches).To(Equal(2))
+		updatedPod, err := k8sClient.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(isInterfaceInNetworkStatus(
+			updatedPod,
+			&nad.NetworkSelectionElement{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"})).To(BeTrue())
+	})
+})
+
+var _ = Describe("The network-status write", func() {
+	It("is retried on conflict, without invoking the delegate again", func() {
+		addInterfaceConfig := networkConfig(multuscni.CmdAdd, "net1", "net1", macAddr)
+		addInterfaceConfig.Response.Result.Interfaces[0].Sandbox = netnsPath
+		multusClient := fakemultusclient.NewFakeClient(addInterfaceConfig)
+		pod := podSpec(podName, namespace)
+		podController := newSynchedPodController(
+			pod,
+			multusClient,
+			tinyNetAttachDef())
+
+		k8sClient := podController.k8sClientSet.(*fake.Clientset)
+		patches := 0
+		k8sClient.PrependReactor("patch", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			patches++
+			if patches == 1 {
+				return true, nil, apierrors.NewConflict(corev1.Resource("pods"), podName, errors.New("the object has been modified"))
+			}
+			return false, nil, nil
+		})
+
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:         podName,
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"}},
+			Type:            add,
+			PodNetNS:        netnsPath,
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		Expect(patches).To(Equal(2))
+		Expect(multusClient.Requests()).To(HaveLen(1))
+		Expect(podController.workqueue.NumRequeues(annotations.NamespacedName(namespace, podName))).To(BeZero())
+		updatedPod, err := k8sClient.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(isInterfaceInNetworkStatus(
+			updatedPod,
+			&nad.NetworkSelectionElement{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"})).To(BeTrue())
+	})
+
+	It("features the network-status, and the bookkeeping annotations describing it", func() {
+		addInterfaceConfig := networkConfig(multuscni.CmdAdd, "net1", "net1", macAddr)
+		addInterfaceConfig.Response.Result.Interfaces[0].Sandbox = netnsPath
+		pod := podSpec(podName, namespace)
+		pod.ResourceVersion = "42"
+		podController := newSynchedPodController(
+			pod,
+			fakemultusclient.NewFakeClient(addInterfaceConfig),
+			tinyNetAttachDef())
+
+		k8sClient := podController.k8sClientSet.(*fake.Clientset)
+		var patches [][]byte
+		k8sClient.PrependReactor("patch", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			patches = append(patches, action.(k8stesting.PatchAction).GetPatch())
+			return false, nil, nil
+		})
+
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:         podName,
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"}},
+			Type:            add,
+			PodNetNS:        netnsPath,
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		updatedPod, err := k8sClient.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(patches).To(HaveLen(1))
+		var patchBody map[string]interface{}
+		Expect(json.Unmarshal(patches[0], &patchBody)).To(Succeed())
+		Expect(patchBody).To(Equal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"resourceVersion": "42",
+				"annotations": map[string]interface{}{
+					nad.NetworkStatusAnnot:                   updatedPod.Annotations[nad.NetworkStatusAnnot],
+					defaultBookkeepingKeys.DynamicInterfaces: updatedPod.Annotations[defaultBookkeepingKeys.DynamicInterfaces],
+					defaultBookkeepingKeys.Sandbox:           netnsPath,
+				},
+			},
+		}))
+	})
+})
+
+var _ = Describe("Network namespace resolution failures", func() {
+	resolutionErrors := func() float64 {
+		metric := &dto.Metric{}
+		Expect(metrics.NetNSResolutionErrors.WithLabelValues("containerd").Write(metric)).To(Succeed())
+		return metric.GetCounter().GetValue()
+	}
+
+	It("are counted, and reported on the pod once the request is processed", func() {
+		const maxEvents = 1
+		pod := podSpec(podName, namespace, networkName)
+		podController := newSynchedPodController(pod, fakemultusclient.NewFakeClient())
+		podController.containerRuntime = fakecri.NewFakeRuntime()
+		eventRecorder := record.NewFakeRecorder(maxEvents)
+		podController.recorder = eventRecorder
+		previousResolutionErrors := resolutionErrors()
+
+		podController.handlePodUpdate(pod, updatePodSpec(pod, networkName, "new-net"))
+		Expect(podController.workqueue.Len()).To(Equal(1))
+		Expect(resolutionErrors()).To(Equal(previousResolutionErrors))
+
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+		Expect(resolutionErrors()).To(Equal(previousResolutionErrors + 1))
+		Expect(eventRecorder.Events).To(Receive(HavePrefix(fmt.Sprintf(
+			"Warning NetworkNamespaceResolutionFailed pod [%s]: failed to resolve the network namespace of container %s",
+			annotations.NamespacedName(namespace, podName),
+			podName))))
+		Expect(podController.workqueue.NumRequeues(annotations.NamespacedName(namespace, podName))).To(Equal(1))
+	})
+
+	It("do not fail the networks update, resolved when the request is processed", func() {
+		const maxEvents = 1
+		addConfig := networkConfig(multuscni.CmdAdd, "net0", "net0", macAddr)
+		addConfig.Response.Result.Interfaces[0].Sandbox = netnsPath
+		pod := podSpec(podName, namespace)
+		podController := newSynchedPodController(pod, fakemultusclient.NewFakeClient(addConfig), tinyNetAttachDef())
+		containerRuntime := &flakyRuntime{consultedRuntime: consultedRuntime{Runtime: fakecri.NewFakeRuntime(*pod)}, failures: 1}
+		podController.containerRuntime = containerRuntime
+		eventRecorder := record.NewFakeRecorder(maxEvents)
+		podController.recorder = eventRecorder
+		previousResolutionErrors := resolutionErrors()
+
+		podController.handlePodUpdate(pod, updatePodSpec(pod, networkName))
+		Expect(containerRuntime.consulted).To(Equal(1))
+		Expect(podController.workqueue.Len()).To(Equal(1))
+
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+		Expect(containerRuntime.consulted).To(Equal(2))
+		Expect(resolutionErrors()).To(Equal(previousResolutionErrors))
+		Expect(eventRecorder.Events).To(Receive(HavePrefix("Normal AddedInterface")))
+	})
+
+	It("are retried by the workers before being reported", func() {
+		const maxEvents = 1
+		addConfig := networkConfig(multuscni.CmdAdd, "net1", "net1", macAddr)
+		addConfig.Response.Result.Interfaces[0].Sandbox = netnsPath
+		pod := podSpec(podName, namespace)
+		podController := newSynchedPodController(pod, fakemultusclient.NewFakeClient(addConfig), tinyNetAttachDef())
+		containerRuntime := &flakyRuntime{consultedRuntime: consultedRuntime{Runtime: fakecri.NewFakeRuntime(*pod)}, failures: 1}
+		podController.containerRuntime = containerRuntime
+		eventRecorder := record.NewFakeRecorder(maxEvents)
+		podController.recorder = eventRecorder
+		previousResolutionErrors := resolutionErrors()
+
+		// the pod was not running when the request was issued
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:         podName,
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"}},
+			Type:            add,
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		Expect(containerRuntime.consulted).To(Equal(2))
+		Expect(resolutionErrors()).To(Equal(previousResolutionErrors))
+		Expect(eventRecorder.Events).To(Receive(HavePrefix("Normal AddedInterface")))
+	})
+})
+
+var _ = Describe("The network namespace annotation", func() {
+	const (
+		netnsAnnotation = "networks.cncf.io/netns"
+		annotatedNetNS  = "/var/run/netns/annotated"
+	)
+
+	var containerRuntime *consultedRuntime
+
+	BeforeEach(func() {
+		containerRuntime = &consultedRuntime{Runtime: fakecri.NewFakeRuntime(*podSpec(podName, namespace))}
+	})
+
+	It("provides the pod's network namespace, without consulting the container runtime", func() {
+		podController := newUnstartedPodController(containerRuntime, fakemultusclient.NewFakeClient(), WithNetNSAnnotation(netnsAnnotation))
+		pod := podSpec(podName, namespace)
+		pod.Annotations[netnsAnnotation] = annotatedNetNS
+
+		Expect(podController.netnsPath(pod)).To(Equal(annotatedNetNS))
+		Expect(containerRuntime.consulted).To(BeZero())
+	})
+
+	It("falls back to the container runtime when the pod does not feature it", func() {
+		podController := newUnstartedPodController(containerRuntime, fakemultusclient.NewFakeClient(), WithNetNSAnnotation(netnsAnnotation))
+
+		Expect(podController.netnsPath(podSpec(podName, namespace))).NotTo(Equal(annotatedNetNS))
+		Expect(containerRuntime.consulted).To(Equal(1))
+	})
+
+	It("is ignored unless configured", func() {
+		podController := newUnstartedPodController(containerRuntime, fakemultusclient.NewFakeClient())
+		pod := podSpec(podName, namespace)
+		pod.Annotations[netnsAnnotation] = annotatedNetNS
+
+		Expect(podController.netnsPath(pod)).NotTo(Equal(annotatedNetNS))
+		Expect(containerRuntime.consulted).To(Equal(1))
+	})
+})
+
+var _ = Describe("The end-to-end latency", func() {
+	observedLatencies := func(operation string) (uint64, float64) {
+		metric := &dto.Metric{}
+		Expect(metrics.E2ELatency.WithLabelValues(operation).(prometheus.Histogram).Write(metric)).To(Succeed())
+		return metric.GetHistogram().GetSampleCount(), metric.GetHistogram().GetSampleSum()
+	}
+
+	It("is observed once the added interface is in the network-status", func() {
+		const queuedFor = 3 * time.Second
+		pod := podSpec(podName, namespace)
+		delete(pod.Annotations, nad.NetworkAttachmentAnnot)
+		podController := newSynchedPodController(
+			pod,
+			fakemultusclient.NewFakeClient(networkConfig(multuscni.CmdAdd, "net0", "net0", macAddr)),
+			tinyNetAttachDef())
+		fakeClock := clocktesting.NewFakeClock(time.Now())
+		WithClock(fakeClock)(podController)
+		previousLatencies, previousLatenciesSum := observedLatencies(string(add))
+
+		podController.handlePodUpdate(pod, updatePodSpec(pod, networkName))
+		latencies, _ := observedLatencies(string(add))
+		Expect(latencies).To(Equal(previousLatencies))
+		fakeClock.Step(queuedFor)
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		updatedPod, err := podController.k8sClientSet.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updatedPod.Annotations[nad.NetworkStatusAnnot]).To(ContainSubstring(annotations.NamespacedName(namespace, networkName)))
+		latencies, latenciesSum := observedLatencies(string(add))
+		Expect(latencies).To(Equal(previousLatencies + 1))
+		Expect(latenciesSum - previousLatenciesSum).To(BeNumerically("~", queuedFor.Seconds()))
+	})
+})
+
+var _ = Describe("Bounding the concurrent delegate invocations", func() {
+	const (
+		maxConcurrentDelegates = 2
+		invocations            = 5
+	)
+
+	It("never runs more delegates at once than allowed", func() {
+		multusClient := newConcurrencyTrackingMultusClient()
+		podController := newUnstartedPodController(
+			fakecri.NewFakeRuntime(),
+			multusClient,
+			WithMaxConcurrentDelegates(maxConcurrentDelegates))
+
+		var wg sync.WaitGroup
+		for i := 0; i < invocations; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				_, _ = podController.invokeDelegate(
+					context.Background(),
+					&nad.NetworkSelectionElement{Name: networkName, Namespace: namespace},
+					&multusapi.Request{Env: map[string]string{"CNI_COMMAND": multuscni.CmdAdd}})
+			}()
+		}
+		for i := 0; i < maxConcurrentDelegates; i++ {
+			Eventually(multusClient.invoked).Should(Receive())
+		}
+		Consistently(multusClient.invoked, 100*time.Millisecond).ShouldNot(Receive())
+
+		close(multusClient.release)
+		wg.Wait()
+		Expect(multusClient.maxConcurrent()).To(Equal(maxConcurrentDelegates))
+	})
+
+	It("rejects a negative bound", func() {
+		_, err := NewPodNetworksController(
+			v1coreinformerfactory.NewSharedInformerFactory(fake.NewSimpleClientset(), 0),
+			nadinformers.NewSharedInformerFactory(fakenadclient.NewSimpleClientset(), 0),
+			nil,
+			nil,
+			fake.NewSimpleClientset(),
+			fakenadclient.NewSimpleClientset(),
+			fakecri.NewFakeRuntime(),
+			fakemultusclient.NewFakeClient(),
+			WithMaxConcurrentDelegates(-1))
+		Expect(err).To(MatchError("the number of concurrent delegates cannot be negative: -1"))
+	})
+})
+
+var _ = Describe("Stopping the controller", func() {
+	var (
+		multusClient  *blockingMultusClient
+		podController *PodNetworksController
+		eventRecorder *record.FakeRecorder
+		stopChannel   chan struct{}
+		stopped       chan struct{}
+	)
+
+	BeforeEach(func() {
+		const maxEvents = 5
+		multusClient = newBlockingMultusClient(networkConfig(multuscni.CmdAdd, "net1", networkName, macAddr))
+		podController = newSynchedPodController(
+			podSpec(podName, namespace),
+			multusClient,
+			tinyNetAttachDef())
+		alwaysReady := func() bool { return true }
+		podController.arePodsSynched = alwaysReady
+		podController.areNetAttachDefsSynched = alwaysReady
+		eventRecorder = record.NewFakeRecorder(maxEvents)
+		podController.recorder = eventRecorder
+
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:         podName,
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"}},
+			Type:            add,
+			PodNetNS:        netnsPath,
+		})
+		stopChannel = make(chan struct{})
+		stopped = make(chan struct{})
+	})
+
+	startController := func() {
+		go func() {
+			defer GinkgoRecover()
+			Expect(podController.Start(stopChannel)).To(Succeed())
+			close(stopped)
+		}()
+		Eventually(multusClient.invoked).Should(Receive())
+	}
+
+	It("lets the request being processed complete", func() {
+		startController()
+
+		close(stopChannel)
+		Consistently(stopped, 100*time.Millisecond).ShouldNot(BeClosed())
+		close(multusClient.release)
+
+		Eventually(stopped).Should(BeClosed())
+		Expect(eventRecorder.Events).To(Receive(HavePrefix("Normal AddedInterface")))
+	})
+
+	It("gives up on the request being processed after the drain and shutdown timeouts", func() {
+		WithDrainTimeout(10 * time.Millisecond)(podController)
+		WithShutdownTimeout(10 * time.Millisecond)(podController)
+		startController()
+		defer close(multusClient.release)
+
+		close(stopChannel)
+		Eventually(stopped).Should(BeClosed())
+		Expect(eventRecorder.Events).NotTo(Receive())
+	})
+
+	It("waits for the request being processed beyond the drain timeout, up to the shutdown timeout", func() {
+		WithDrainTimeout(10 * time.Millisecond)(podController)
+		WithShutdownTimeout(time.Hour)(podController)
+		startController()
+
+		close(stopChannel)
+		Consistently(stopped, 100*time.Millisecond).ShouldNot(BeClosed())
+		close(multusClient.release)
+
+		Eventually(stopped).Should(BeClosed())
+		Expect(eventRecorder.Events).To(Receive(HavePrefix("Normal AddedInterface")))
+	})
+})
+
+var _ = Describe("Starting the controller", func() {
+	const cacheSyncTimeout = 10 * time.Millisecond
+
+	var (
+		multusClient  *fakemultusclient.Client
+		podController *PodNetworksController
+		stopChannel   chan struct{}
+	)
+
+	BeforeEach(func() {
+		multusClient = fakemultusclient.NewFakeClient(networkConfig(multuscni.CmdAdd, "net1", networkName, macAddr))
+		podController = newSynchedPodController(podSpec(podName, namespace), multusClient, tinyNetAttachDef())
+		WithCacheSyncTimeout(cacheSyncTimeout)(podController)
+		// the pods cache never synchronizes - e.g. lacking the RBAC permissions to list them
+		podController.arePodsSynched = func() bool { return false }
+		podController.areNetAttachDefsSynched = func() bool { return true }
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:         podName,
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"}},
+			Type:            add,
+			PodNetNS:        netnsPath,
+		})
+		stopChannel = make(chan struct{})
+	})
+
+	It("fails when the caches do not synchronize in time, without processing the requests", func() {
+		defer close(stopChannel)
+
+		Expect(podController.Start(stopChannel)).To(MatchError(ErrCachesNotSynced))
+		Expect(multusClient.Requests()).To(BeEmpty())
+	})
+
+	It("does not fail when stopped while waiting for the caches", func() {
+		WithCacheSyncTimeout(0)(podController)
+		started := make(chan error)
+		go func() {
+			started <- podController.Start(stopChannel)
+		}()
+
+		Consistently(started, 100*time.Millisecond).ShouldNot(Receive())
+		close(stopChannel)
+		Eventually(started).Should(Receive(BeNil()))
+	})
+})
+
+var _ = Describe("The requests of a recreated pod", func() {
+	const (
+		originalUID = types.UID("original-uid")
+		ifaceName   = "net1"
+	)
+
+	var (
+		multusClient  *fakemultusclient.Client
+		podController *PodNetworksController
+	)
+
+	BeforeEach(func() {
+		addConfig := networkConfig(multuscni.CmdAdd, ifaceName, ifaceName, macAddr)
+		addConfig.Response.Result.Interfaces[0].Sandbox = netnsPath
+		multusClient = fakemultusclient.NewFakeClient(addConfig)
+		pod := podSpec(podName, namespace)
+		pod.UID = "recreated-uid"
+		podController = newSynchedPodController(pod, multusClient, tinyNetAttachDef())
+	})
+
+	processAddRequest := func(podUID types.UID) {
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:         podName,
+			PodUID:          podUID,
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{{Name: networkName, Namespace: namespace, InterfaceRequest: ifaceName}},
+			Type:            add,
+			PodNetNS:        netnsPath,
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+	}
+
+	It("are discarded, rather than acted upon the new pod", func() {
+		processAddRequest(originalUID)
+
+		podKey := annotations.NamespacedName(namespace, podName)
+		Expect(multusClient.Requests()).To(BeEmpty())
+		Expect(podController.pendingRequests.has(podKey)).To(BeFalse())
+		Expect(podController.workqueue.NumRequeues(podKey)).To(BeZero())
+	})
+
+	It("are processed when they do not identify the pod", func() {
+		processAddRequest("")
+
+		Expect(multusClient.Requests()).To(HaveLen(1))
+	})
+})
+
+var _ = Describe("The container runtime report", func() {
+	criInfo := func(name, version string) float64 {
+		metric := &dto.Metric{}
+		Expect(metrics.CRIInfo.WithLabelValues(name, version).Write(metric)).To(Succeed())
+		return metric.GetGauge().GetValue()
+	}
+
+	It("exposes the runtime's name and version", func() {
+		podController := newUnstartedPodController(fakecri.NewFakeRuntime(), fakemultusclient.NewFakeClient())
+
+		podController.reportContainerRuntime()
+
+		Expect(criInfo(fakecri.RuntimeName, fakecri.RuntimeVersion)).To(Equal(float64(1)))
+	})
+})
+
+var _ = Describe("The network-status of multi-attachment requests", func() {
+	It("is written once, featuring all the added interfaces", func() {
+		ifaceNames := []string{"net1", "net2", "net3"}
+		var networkConfigs []fakemultusclient.NetworkConfig
+		var attachments []*nad.NetworkSelectionElement
+		for _, ifaceName := range ifaceNames {
+			config := networkConfig(multuscni.CmdAdd, ifaceName, ifaceName, macAddr)
+			config.Response.Result.Interfaces[0].Sandbox = netnsPath
+			networkConfigs = append(networkConfigs, config)
+			attachments = append(attachments, &nad.NetworkSelectionElement{Name: networkName, Namespace: namespace, InterfaceRequest: ifaceName})
+		}
+		pod := podSpec(podName, namespace)
+		podController := newSynchedPodController(
+			pod,
+			fakemultusclient.NewFakeClient(networkConfigs...),
+			tinyNetAttachDef())
+
+		k8sClient := podController.k8sClientSet.(*fake.Clientset)
+		patches := 0
+		k8sClient.PrependReactor("patch", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			patches++
+			return false, nil, nil
+		})
+
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:         podName,
+			PodNamespace:    namespace,
+			AttachmentNames: attachments,
+			Type:            add,
+			PodNetNS:        netnsPath,
 		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
 
-		Context("with an existing running pod", func() {
-			const (
-				cniVersion  = "0.3.0"
-				macAddr     = "02:03:04:05:06:07"
-				namespace   = "default"
-				networkName = "tiny-net"
-				podName     = "tiny-winy-pod"
-			)
-			var (
-				eventRecorder *record.FakeRecorder
-				k8sClient     k8sclient.Interface
-				pod           *corev1.Pod
-				networkToAdd  string
-				stopChannel   chan struct{}
-			)
+		Expect(patches).To(Equal(1))
+		updatedPod, err := k8sClient.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		for _, attachment := range attachments {
+			Expect(isInterfaceInNetworkStatus(updatedPod, attachment)).To(BeTrue())
+		}
+	})
+})
 
-			networkStatusNames := func(statuses []nad.NetworkStatus) []string {
-				var names []string
-				for _, status := range statuses {
-					names = append(names, status.Name)
-				}
-				return names
-			}
+var _ = Describe("The dry-run mode", func() {
+	It("reports the interfaces to add and remove without invoking the delegate", func() {
+		const maxEvents = 5
+		pod := podSpec(podName, namespace, networkName)
+		multusClient := fakemultusclient.NewFakeClient()
+		podController := newSynchedPodController(
+			pod,
+			multusClient,
+			tinyNetAttachDef())
+		podController.dryRun = true
+		eventRecorder := record.NewFakeRecorder(maxEvents)
+		podController.recorder = eventRecorder
 
-			BeforeEach(func() {
-				pod = podSpec(podName, namespace, networkName)
-				k8sClient = fake.NewSimpleClientset(pod)
-				networkToAdd = fmt.Sprintf("%s-2", networkName)
-				nadClient, err := newFakeNetAttachDefClient(
-					netAttachDef(networkName, namespace, dummyNetSpec(networkName, cniVersion)),
-					netAttachDef(networkToAdd, namespace, dummyNetSpec(networkToAdd, cniVersion)))
-				Expect(err).NotTo(HaveOccurred())
-				stopChannel = make(chan struct{})
-				const maxEvents = 5
-				eventRecorder = record.NewFakeRecorder(maxEvents)
-				Expect(
-					newDummyPodController(
-						k8sClient,
-						nadClient,
-						stopChannel,
-						eventRecorder,
-						fakecri.NewFakeRuntime(*pod),
-						fakemultusclient.NewFakeClient(
-							networkConfig(multuscni.CmdAdd, "net1", networkName, macAddr),
-							networkConfig(multuscni.CmdDel, "net0", "", "")),
-					)).NotTo(BeNil())
-				Expect(func() []nad.NetworkStatus {
-					updatedPod, err := k8sClient.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
-					if err != nil {
-						return nil
-					}
-					status, err := networkStatus(updatedPod.Annotations)
-					if err != nil {
-						return nil
-					}
-					return status
-				}()).Should(
-					And(
-						WithTransform(networkStatusNames, ContainElements(annotations.NamespacedName(namespace, networkName))),
-						Not(WithTransform(networkStatusNames, ContainElements(annotations.NamespacedName(namespace, networkToAdd))))),
-				)
+		for _, requestType := range []DynamicAttachmentRequestType{remove, add} {
+			podController.enqueue(&DynamicAttachmentRequest{
+				PodName:         podName,
+				PodNamespace:    namespace,
+				AttachmentNames: []*nad.NetworkSelectionElement{{Name: networkName, Namespace: namespace, InterfaceRequest: "net0"}},
+				Type:            requestType,
+				PodNetNS:        netnsPath,
 			})
+			Expect(podController.processNextWorkItem()).To(BeTrue())
+		}
 
-			AfterEach(func() {
-				close(stopChannel)
+		podKey := annotations.NamespacedName(namespace, podName)
+		Expect(eventRecorder.Events).To(Receive(Equal(fmt.Sprintf(
+			"Normal DryRunRemoveInterface pod [%s]: dry-run: would remove interface net0 of network: %s", podKey, networkName))))
+		Expect(eventRecorder.Events).To(Receive(Equal(fmt.Sprintf(
+			"Normal DryRunAddInterface pod [%s]: dry-run: would add interface net0 of network: %s", podKey, networkName))))
+		Expect(multusClient.Requests()).To(BeEmpty())
+		updatedPod, err := podController.k8sClientSet.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updatedPod.Annotations).To(Equal(pod.Annotations))
+	})
+})
+
+var _ = Describe("Removing an attachment whose network-attachment-definition no longer exists", func() {
+	const (
+		goneNetwork   = "gone-net"
+		goneInterface = "net1"
+	)
+
+	var (
+		eventRecorder *record.FakeRecorder
+		linkDeleter   *fakelink.Deleter
+		multusClient  *fakemultusclient.Client
+		podController *PodNetworksController
+	)
+
+	podKey := annotations.NamespacedName(namespace, podName)
+
+	BeforeEach(func() {
+		// the network-attachment-definition of the pod's second attachment was deleted
+		multusClient = fakemultusclient.NewFakeClient()
+		podController = newSynchedPodController(
+			podSpec(podName, namespace, networkName, goneNetwork),
+			multusClient,
+			tinyNetAttachDef())
+		eventRecorder = record.NewFakeRecorder(5)
+		podController.recorder = eventRecorder
+		linkDeleter = fakelink.NewFakeDeleter(nil)
+		WithLinkDeleter(linkDeleter)(podController)
+
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:         podName,
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{{Name: goneNetwork, Namespace: namespace, InterfaceRequest: goneInterface}},
+			Type:            remove,
+			PodNetNS:        netnsPath,
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+	})
+
+	It("deletes the interface by name, without invoking the delegate", func() {
+		Expect(linkDeleter.Deleted(netnsPath)).To(ConsistOf(goneInterface))
+		Expect(multusClient.Requests()).To(BeEmpty())
+		Expect(eventRecorder.Events).To(Receive(Equal(fmt.Sprintf(
+			"Normal RemovedInterface pod [%s]: removed interface %s from network: %s",
+			podKey,
+			goneInterface,
+			goneNetwork))))
+		Expect(podController.workqueue.NumRequeues(podKey)).To(BeZero())
+	})
+
+	It("drops the interface from the pod's network-status", func() {
+		pod, err := podController.k8sClientSet.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(isInterfaceInNetworkStatus(pod, &nad.NetworkSelectionElement{Name: goneNetwork, Namespace: namespace, InterfaceRequest: goneInterface})).To(BeFalse())
+		Expect(isInterfaceInNetworkStatus(pod, &nad.NetworkSelectionElement{Name: networkName, Namespace: namespace, InterfaceRequest: "net0"})).To(BeTrue())
+	})
+})
+
+var _ = Describe("Removing an interface whose network-status update fails", func() {
+	const ifaceName = "net0"
+
+	var (
+		eventRecorder *record.FakeRecorder
+		multusClient  *fakemultusclient.Client
+		podController *PodNetworksController
+	)
+
+	podKey := annotations.NamespacedName(namespace, podName)
+
+	BeforeEach(func() {
+		multusClient = fakemultusclient.NewFakeClient(networkConfig(multuscni.CmdDel, ifaceName, "", ""))
+		podController = newSynchedPodController(podSpec(podName, namespace, networkName), multusClient, tinyNetAttachDef())
+		eventRecorder = record.NewFakeRecorder(5)
+		podController.recorder = eventRecorder
+		failedStatusUpdates := 0
+		podController.k8sClientSet.(*fake.Clientset).PrependReactor(
+			"patch",
+			"pods",
+			func(_ k8stesting.Action) (bool, runtime.Object, error) {
+				if failedStatusUpdates > 0 {
+					return false, nil, nil
+				}
+				failedStatusUpdates++
+				return true, nil, fmt.Errorf("the API server is unavailable")
 			})
 
-			When("an attachment is added to the pod's network annotations", func() {
-				BeforeEach(func() {
-					var err error
-					_, err = k8sClient.CoreV1().Pods(namespace).UpdateStatus(
-						context.TODO(),
-						updatePodSpec(pod, networkName, networkToAdd),
-						metav1.UpdateOptions{})
-					Expect(err).NotTo(HaveOccurred())
-				})
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:         podName,
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{{Name: networkName, Namespace: namespace, InterfaceRequest: ifaceName}},
+			Type:            remove,
+			PodNetNS:        netnsPath,
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+	})
 
-				It("an `AddedInterface` event is seen in the event recorded ", func() {
-					expectedEventPayload := fmt.Sprintf(
-						"Normal AddedInterface pod [%s]: added interface %s to network: %s",
-						annotations.NamespacedName(namespace, podName),
-						"net1",
-						networkToAdd,
-					)
-					Eventually(<-eventRecorder.Events).Should(Equal(expectedEventPayload))
-				})
+	It("reports the failed status update, rather than the interface's removal", func() {
+		Expect(multusClient.Requests()).To(HaveLen(1))
+		Expect(eventRecorder.Events).To(Receive(Equal(fmt.Sprintf(
+			"Warning RemoveStatusUpdateFailed pod [%s]: removed interface %s from network: %s, but %s: "+
+				"failed to update pod's network-status annotations for %s: the API server is unavailable",
+			podKey,
+			ifaceName,
+			networkName,
+			errRemovedIfaceStatusNotUpdated,
+			podName))))
+		Expect(eventRecorder.Events).NotTo(Receive())
+		Expect(podController.workqueue.NumRequeues(podKey)).To(Equal(1))
+	})
+
+	It("reports the interface's removal once the retried status update succeeds", func() {
+		Expect(eventRecorder.Events).To(Receive(HavePrefix("Warning RemoveStatusUpdateFailed")))
+
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		Expect(eventRecorder.Events).To(Receive(Equal(fmt.Sprintf(
+			"Normal RemovedInterface pod [%s]: removed interface %s from network: %s", podKey, ifaceName, networkName))))
+		Expect(eventRecorder.Events).NotTo(Receive())
+		pod, err := podController.k8sClientSet.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(isInterfaceInNetworkStatus(pod, &nad.NetworkSelectionElement{Name: networkName, Namespace: namespace, InterfaceRequest: ifaceName})).To(BeFalse())
+	})
+})
+
+var _ = Describe("A namespaced controller", func() {
+	const otherNamespace = "other-tenant"
+
+	var (
+		eventRecorder *record.FakeRecorder
+		podController *PodNetworksController
+		stopChannel   chan struct{}
+	)
+
+	BeforeEach(func() {
+		k8sClient := fake.NewSimpleClientset(
+			podSpec(podName, namespace, networkName),
+			podSpec(podName, otherNamespace, networkName))
+		nadClient := fakenadclient.NewSimpleClientset()
+		const noResyncPeriod = 0
+		podInformerFactory := NewPodInformerFactory(k8sClient, noResyncPeriod, namespace)
+		netAttachDefInformerFactory := nadinformers.NewSharedInformerFactory(nadClient, noResyncPeriod)
+		eventRecorder = record.NewFakeRecorder(5)
+
+		var err error
+		podController, err = NewPodNetworksController(
+			podInformerFactory,
+			netAttachDefInformerFactory,
+			nil,
+			eventRecorder,
+			k8sClient,
+			nadClient,
+			fakecri.NewFakeRuntime(),
+			fakemultusclient.NewFakeClient())
+		Expect(err).NotTo(HaveOccurred())
+
+		stopChannel = make(chan struct{})
+		podInformerFactory.Start(stopChannel)
+		netAttachDefInformerFactory.Start(stopChannel)
+		Expect(cache.WaitForCacheSync(stopChannel, podController.arePodsSynched, podController.areNetAttachDefsSynched)).To(BeTrue())
+	})
+
+	AfterEach(func() {
+		close(stopChannel)
+	})
+
+	It("only watches the pods of its namespace", func() {
+		pods, err := podController.podsLister.List(labels.Everything())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pods).To(HaveLen(1))
+		Expect(pods[0].GetNamespace()).To(Equal(namespace))
+
+		_, err = podController.podsLister.Pods(otherNamespace).Get(podName)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+})
+
+var _ = Describe("Failed requests", func() {
+	var (
+		pod           *corev1.Pod
+		podController *PodNetworksController
+		eventRecorder *record.FakeRecorder
+	)
+
+	podKey := annotations.NamespacedName(namespace, podName)
+
+	BeforeEach(func() {
+		pod = podSpec(podName, namespace)
+		pod.Annotations[ingressBandwidthAnnot] = "lots"
+		// the delegate fails every invocation
+		podController = newSynchedPodController(
+			pod,
+			fakemultusclient.NewFakeClient(),
+			tinyNetAttachDef())
+		eventRecorder = record.NewFakeRecorder(5)
+		podController.recorder = eventRecorder
+	})
+
+	enqueueAdd := func(network *nad.NetworkSelectionElement) {
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:         podName,
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{network},
+			Type:            add,
+			PodNetNS:        netnsPath,
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+	}
+
+	It("are dropped right away when retrying them is pointless", func() {
+		enqueueAdd(&nad.NetworkSelectionElement{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"})
+
+		Expect(eventRecorder.Events).To(Receive(HavePrefix("Warning AddInterfaceFailed")))
+		Expect(eventRecorder.Events).To(Receive(HavePrefix(fmt.Sprintf(
+			"Warning RequestFailed pod [%s]: dropped the add request for networks %s without retrying it: failed to compute the delegate configuration",
+			podKey,
+			networkName))))
+		Expect(podController.pendingRequests.has(podKey)).To(BeFalse())
+		Expect(podController.workqueue.NumRequeues(podKey)).To(BeZero())
+	})
+
+	It("are retried when failing transiently", func() {
+		enqueueAdd(&nad.NetworkSelectionElement{
+			Name:             networkName,
+			Namespace:        namespace,
+			InterfaceRequest: "net1",
+			BandwidthRequest: &nad.BandwidthEntry{IngressRate: 1000},
+		})
+
+		Expect(eventRecorder.Events).To(Receive(HavePrefix("Warning AddInterfaceFailed")))
+		Expect(eventRecorder.Events).NotTo(Receive())
+		Expect(podController.pendingRequests.has(podKey)).To(BeTrue())
+		Expect(podController.workqueue.NumRequeues(podKey)).To(Equal(1))
+	})
+})
+
+var _ = Describe("Requests for a pod that no longer exists", func() {
+	It("are forgotten right away", func() {
+		multusClient := fakemultusclient.NewFakeClient()
+		// the pod lister does not feature the pod
+		podController := newUnstartedPodController(fakecri.NewFakeRuntime(), multusClient)
+
+		podKey := annotations.NamespacedName(namespace, podName)
+		for _, requestType := range []DynamicAttachmentRequestType{add, remove} {
+			podController.enqueue(&DynamicAttachmentRequest{
+				PodName:         podName,
+				PodNamespace:    namespace,
+				AttachmentNames: []*nad.NetworkSelectionElement{{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"}},
+				Type:            requestType,
+				PodNetNS:        netnsPath,
 			})
+			Expect(podController.processNextWorkItem()).To(BeTrue())
 
-			When("an attachment is removed from the pod's network annotations", func() {
-				BeforeEach(func() {
-					var err error
-					_, err = k8sClient.CoreV1().Pods(namespace).UpdateStatus(
-						context.TODO(),
-						updatePodSpec(pod),
-						metav1.UpdateOptions{})
-					Expect(err).NotTo(HaveOccurred())
-				})
+			Expect(podController.workqueue.NumRequeues(podKey)).To(BeZero())
+			Expect(podController.pendingRequests.has(podKey)).To(BeFalse())
+		}
+		Expect(multusClient.Requests()).To(BeEmpty())
+	})
+})
 
-				It("an `RemovedInterface` event is seen in the event recorded ", func() {
-					expectedEventPayload := fmt.Sprintf(
-						"Normal RemovedInterface pod [%s]: removed interface %s from network: %s",
-						annotations.NamespacedName(namespace, podName),
-						"net0",
-						networkName,
-					)
-					Eventually(<-eventRecorder.Events).Should(Equal(expectedEventPayload))
-				})
+var _ = Describe("Delegates replying without a result", func() {
+	const ifaceName = "net1"
+
+	addNetwork := func(response *multusapi.Response) (*fakemultusclient.Client, *record.FakeRecorder) {
+		multusClient := fakemultusclient.NewFakeClient(
+			fakemultusclient.NetworkConfig{Cmd: multuscni.CmdAdd, IfaceName: ifaceName, Response: response},
+			networkConfig(multuscni.CmdDel, ifaceName, "", ""))
+		podController := newSynchedPodController(podSpec(podName, namespace), multusClient, tinyNetAttachDef())
+		eventRecorder := record.NewFakeRecorder(5)
+		podController.recorder = eventRecorder
+
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:         podName,
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{{Name: networkName, Namespace: namespace, InterfaceRequest: ifaceName}},
+			Type:            add,
+			PodNetNS:        netnsPath,
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+		return multusClient, eventRecorder
+	}
+
+	expectRolledBackAddition := func(multusClient *fakemultusclient.Client, eventRecorder *record.FakeRecorder) {
+		Expect(eventRecorder.Events).To(Receive(Equal(fmt.Sprintf(
+			"Warning AddInterfaceFailed pod [%s]: failed adding interface %s to network: %s: failed to ADD delegate: %v",
+			annotations.NamespacedName(namespace, podName),
+			ifaceName,
+			networkName,
+			errNoDelegateResult))))
+		Expect(multusClient.Requests()).To(HaveLen(2))
+		Expect(multusClient.Requests()[1].Env).To(HaveKeyWithValue("CNI_COMMAND", multuscni.CmdDel))
+	}
+
+	It("fail adding the interface, which is rolled back, when the response has no result", func() {
+		expectRolledBackAddition(addNetwork(&multusapi.Response{}))
+	})
+
+	It("fail adding the interface, which is rolled back, when there is no response", func() {
+		expectRolledBackAddition(addNetwork(nil))
+	})
+})
+
+var _ = Describe("Removing an interface that is already gone", func() {
+	It("succeeds, dropping the interface from the network-status", func() {
+		removedAttachment := &nad.NetworkSelectionElement{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"}
+		pod := podSpec(podName, namespace)
+		pod.Annotations[nad.NetworkStatusAnnot] = `[{"name":"default/tiny-net","interface":"net1"}]`
+		multusClient := fakemultusclient.NewFakeClient(fakemultusclient.NetworkConfig{
+			Cmd:       multuscni.CmdDel,
+			IfaceName: "net1",
+			Err:       errors.New(`failed to delete interface "net1": Link not found`),
+		})
+		podController := newSynchedPodController(pod, multusClient, tinyNetAttachDef())
+		eventRecorder := record.NewFakeRecorder(5)
+		podController.recorder = eventRecorder
+
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:         podName,
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{removedAttachment},
+			Type:            remove,
+			PodNetNS:        netnsPath,
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		Expect(multusClient.Requests()).To(HaveLen(1))
+		Expect(eventRecorder.Events).To(Receive(HavePrefix("Normal RemovedInterface")))
+		Expect(podController.workqueue.NumRequeues(annotations.NamespacedName(namespace, podName))).To(BeZero())
+		updatedPod, err := podController.k8sClientSet.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(isInterfaceInNetworkStatus(updatedPod, removedAttachment)).To(BeFalse())
+	})
+})
+
+var _ = Describe("A network attached more than once", func() {
+	attachment := func(ifaceName string) *nad.NetworkSelectionElement {
+		return &nad.NetworkSelectionElement{Name: networkName, Namespace: namespace, InterfaceRequest: ifaceName}
+	}
+
+	addConfig := func(ifaceName string) fakemultusclient.NetworkConfig {
+		return fakemultusclient.NetworkConfig{
+			Cmd:       multuscni.CmdAdd,
+			IfaceName: ifaceName,
+			Response: &multusapi.Response{Result: &cni100.Result{
+				CNIVersion: "1.0.0",
+				Interfaces: []*cni100.Interface{{Name: ifaceName, Mac: macAddr, Sandbox: netnsPath}},
+			}},
+		}
+	}
+
+	It("has only the removed interface removed", func() {
+		multusClient := fakemultusclient.NewFakeClient(
+			addConfig("net1"),
+			addConfig("net2"),
+			networkConfig(multuscni.CmdDel, "net1", "", ""))
+		podController := newSynchedPodController(podSpec(podName, namespace), multusClient, tinyNetAttachDef())
+
+		for _, request := range []*DynamicAttachmentRequest{
+			{AttachmentNames: []*nad.NetworkSelectionElement{attachment("net1"), attachment("net2")}, Type: add},
+			{AttachmentNames: []*nad.NetworkSelectionElement{attachment("net1")}, Type: remove},
+		} {
+			request.PodName = podName
+			request.PodNamespace = namespace
+			request.PodNetNS = netnsPath
+			podController.enqueue(request)
+			Expect(podController.processNextWorkItem()).To(BeTrue())
+			// the informer observes the written network-status
+			updatedPod, err := podController.k8sClientSet.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(podController.podsInformer.GetStore().Update(updatedPod)).To(Succeed())
+		}
+
+		Expect(multusClient.Requests()).To(HaveLen(3))
+		Expect(multusClient.Requests()[2].Env).To(HaveKeyWithValue("CNI_COMMAND", multuscni.CmdDel))
+		Expect(multusClient.Requests()[2].Env).To(HaveKeyWithValue("CNI_IFNAME", "net1"))
+		pod, err := podController.k8sClientSet.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(isInterfaceInNetworkStatus(pod, attachment("net1"))).To(BeFalse())
+		Expect(isInterfaceInNetworkStatus(pod, attachment("net2"))).To(BeTrue())
+		Expect(podController.dynamicInterfaces(pod)).To(Equal([]string{"net2"}))
+	})
+})
+
+var _ = Describe("Removing multiple interfaces", func() {
+	It("removes them in the reverse order they were added", func() {
+		attachment := func(ifaceName string) *nad.NetworkSelectionElement {
+			return &nad.NetworkSelectionElement{Name: networkName, Namespace: namespace, InterfaceRequest: ifaceName}
+		}
+		addConfig := func(ifaceName string) fakemultusclient.NetworkConfig {
+			config := networkConfig(multuscni.CmdAdd, ifaceName, ifaceName, macAddr)
+			config.Response.Result.Interfaces[0].Sandbox = netnsPath
+			return config
+		}
+		pod := podSpec(podName, namespace)
+		multusClient := fakemultusclient.NewFakeClient(
+			addConfig("net3"),
+			addConfig("net1"),
+			networkConfig(multuscni.CmdDel, "net1", "", ""),
+			networkConfig(multuscni.CmdDel, "net3", "", ""),
+			networkConfig(multuscni.CmdDel, "net4", "", ""))
+		podController := newSynchedPodController(pod, multusClient, tinyNetAttachDef())
+
+		// the controller's cache follows the pod's updates
+		process := func(dynamicAttachmentRequest *DynamicAttachmentRequest) {
+			podController.enqueue(dynamicAttachmentRequest)
+			Expect(podController.processNextWorkItem()).To(BeTrue())
+			updatedPod, err := podController.k8sClientSet.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(podController.podsInformer.GetStore().Update(updatedPod)).To(Succeed())
+		}
+		// the network-status lists the interfaces by name, not in the order they were added
+		for _, ifaceName := range []string{"net3", "net1"} {
+			process(&DynamicAttachmentRequest{
+				PodName:         podName,
+				PodNamespace:    namespace,
+				AttachmentNames: []*nad.NetworkSelectionElement{attachment(ifaceName)},
+				Type:            add,
+				PodNetNS:        netnsPath,
 			})
+		}
+		process(&DynamicAttachmentRequest{
+			PodName:         podName,
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{attachment("net4"), attachment("net3"), attachment("net1")},
+			Type:            remove,
+			PodNetNS:        netnsPath,
+		})
+
+		var removedIfaces []string
+		for _, request := range multusClient.Requests() {
+			if request.Env["CNI_COMMAND"] == multuscni.CmdDel {
+				removedIfaces = append(removedIfaces, request.Env["CNI_IFNAME"])
+			}
+		}
+		// the interfaces the controller did not add are removed last
+		Expect(removedIfaces).To(Equal([]string{"net1", "net3", "net4"}))
+	})
+})
+
+var _ = Describe("Invalid attachments", func() {
+	It("are skipped, while the request's valid attachments are added", func() {
+		const maxEvents = 5
+		pod := podSpec(podName, namespace)
+		multusClient := fakemultusclient.NewFakeClient(
+			networkConfig(multuscni.CmdAdd, "net1", networkName, macAddr),
+			networkConfig(multuscni.CmdAdd, "net2", networkName, macAddr))
+		podController := newSynchedPodController(
+			pod,
+			multusClient,
+			tinyNetAttachDef())
+		eventRecorder := record.NewFakeRecorder(maxEvents)
+		podController.recorder = eventRecorder
+
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:      podName,
+			PodNamespace: namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{
+				{Name: networkName, Namespace: namespace, InterfaceRequest: "net1", MacRequest: "02:03:04"},
+				{Name: networkName, Namespace: namespace, InterfaceRequest: "net2", IPRequest: []string{"10.10.10.10/24"}},
+			},
+			Type:     add,
+			PodNetNS: netnsPath,
 		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		Expect(multusClient.Requests()).To(HaveLen(1))
+		Expect(multusClient.Requests()[0].Env).To(HaveKeyWithValue("CNI_IFNAME", "net2"))
+		Expect(eventRecorder.Events).To(Receive(Equal(fmt.Sprintf(
+			"Warning InvalidInterfaceRequest pod [%s]: skipped adding interface net1 to network: %s: %s",
+			annotations.NamespacedName(namespace, podName),
+			networkName,
+			`failed to validate MAC address "02:03:04": address 02:03:04: invalid MAC address`))))
+		Expect(podController.pendingRequests.has(annotations.NamespacedName(namespace, podName))).To(BeFalse())
+	})
+})
+
+var _ = Describe("The pod annotations patch", func() {
+	It("only features the updated annotations", func() {
+		const newStatus = `[{"name":"default/tiny-net","interface":"net1"}]`
+
+		patch, err := podAnnotationsPatch(
+			"",
+			map[string]string{nad.NetworkStatusAnnot: "[]", defaultBookkeepingKeys.DynamicInterfaces: `["net1"]`},
+			map[string]string{nad.NetworkStatusAnnot: newStatus, defaultBookkeepingKeys.DynamicInterfaces: `["net1"]`})
+		Expect(err).NotTo(HaveOccurred())
+
+		var patchBody map[string]interface{}
+		Expect(json.Unmarshal(patch, &patchBody)).To(Succeed())
+		Expect(patchBody).To(Equal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{
+					nad.NetworkStatusAnnot: newStatus,
+				},
+			},
+		}))
+	})
+
+	It("is conditioned on the pod's resource version", func() {
+		const (
+			newStatus       = `[{"name":"default/tiny-net","interface":"net1"}]`
+			resourceVersion = "1234"
+		)
+
+		patch, err := podAnnotationsPatch(
+			resourceVersion,
+			map[string]string{nad.NetworkStatusAnnot: "[]"},
+			map[string]string{nad.NetworkStatusAnnot: newStatus})
+		Expect(err).NotTo(HaveOccurred())
+
+		var patchBody map[string]interface{}
+		Expect(json.Unmarshal(patch, &patchBody)).To(Succeed())
+		Expect(patchBody).To(HaveKeyWithValue("metadata", HaveKeyWithValue("resourceVersion", resourceVersion)))
+	})
+
+	It("is empty when the annotations do not change", func() {
+		const status = `[{"name":"default/tiny-net","interface":"net1"}]`
+		Expect(podAnnotationsPatch(
+			"",
+			map[string]string{nad.NetworkStatusAnnot: status},
+			map[string]string{nad.NetworkStatusAnnot: status})).To(BeNil())
 	})
 })
 
@@ -203,7 +2439,8 @@ func newDummyPodController(
 	stopChannel chan struct{},
 	recorder record.EventRecorder,
 	containerRuntime cri.ContainerRuntime,
-	multusClient multuscni.Client) (*dummyPodController, error) {
+	multusClient multuscni.Client,
+	opts ...Option) (*dummyPodController, error) {
 	const noResyncPeriod = 0
 	netAttachDefInformerFactory := nadinformers.NewSharedInformerFactory(nadClient, noResyncPeriod)
 	podInformerFactory := v1coreinformerfactory.NewSharedInformerFactory(k8sClient, noResyncPeriod)
@@ -216,7 +2453,8 @@ func newDummyPodController(
 		k8sClient,
 		nadClient,
 		containerRuntime,
-		multusClient)
+		multusClient,
+		opts...)
 
 	alwaysReady := func() bool { return true }
 	podController.arePodsSynched = alwaysReady
@@ -239,6 +2477,145 @@ func newDummyPodController(
 	return controller, nil
 }
 
+// newUnstartedPodController returns a controller whose informers and workers
+// are not running; useful to unit test its event handlers.
+func newUnstartedPodController(
+	containerRuntime cri.ContainerRuntime,
+	multusClient multuscni.Client,
+	opts ...Option) *PodNetworksController {
+	const noResyncPeriod = 0
+	k8sClient := fake.NewSimpleClientset()
+	nadClient := fakenadclient.NewSimpleClientset()
+
+	podController, err := NewPodNetworksController(
+		v1coreinformerfactory.NewSharedInformerFactory(k8sClient, noResyncPeriod),
+		nadinformers.NewSharedInformerFactory(nadClient, noResyncPeriod),
+		nil,
+		nil,
+		k8sClient,
+		nadClient,
+		containerRuntime,
+		multusClient,
+		opts...)
+	Expect(err).NotTo(HaveOccurred())
+	return podController
+}
+
+// newSynchedPodController returns an unstarted controller whose caches - and
+// k8s client - feature the pod and the network-attachment-definitions, thus
+// allowing the tests to process the queued requests synchronously.
+func newSynchedPodController(
+	pod *corev1.Pod,
+	multusClient multuscni.Client,
+	networkAttachments ...nad.NetworkAttachmentDefinition) *PodNetworksController {
+	podController := newUnstartedPodController(fakecri.NewFakeRuntime(*pod), multusClient)
+	for i := range networkAttachments {
+		Expect(podController.netAttachDefInformer.GetStore().Add(&networkAttachments[i])).To(Succeed())
+	}
+	Expect(podController.podsInformer.GetStore().Add(pod)).To(Succeed())
+	_, err := podController.k8sClientSet.CoreV1().Pods(pod.GetNamespace()).Create(context.TODO(), pod, metav1.CreateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+	return podController
+}
+
+// blockingMultusClient blocks each delegate invocation until released.
+type blockingMultusClient struct {
+	*fakemultusclient.Client
+	invoked chan struct{}
+	release chan struct{}
+}
+
+func newBlockingMultusClient(currentStatus ...fakemultusclient.NetworkConfig) *blockingMultusClient {
+	return &blockingMultusClient{
+		Client:  fakemultusclient.NewFakeClient(currentStatus...),
+		invoked: make(chan struct{}, 1),
+		release: make(chan struct{}),
+	}
+}
+
+func (c *blockingMultusClient) InvokeDelegateWithContext(
+	ctx context.Context,
+	multusRequest *multusapi.Request,
+) (*multusapi.Response, error) {
+	c.invoked <- struct{}{}
+	<-c.release
+	return c.Client.InvokeDelegateWithContext(ctx, multusRequest)
+}
+
+// consultedRuntime counts how many times the network namespaces are resolved.
+type consultedRuntime struct {
+	*fakecri.Runtime
+	consulted int
+}
+
+func (r *consultedRuntime) NetNS(containerID string) (string, error) {
+	r.consulted++
+	return r.Runtime.NetNS(containerID)
+}
+
+// flakyRuntime fails resolving the network namespaces `failures` times,
+// e.g. as when the runtime does not know the container yet.
+type flakyRuntime struct {
+	consultedRuntime
+	failures int
+}
+
+func (r *flakyRuntime) NetNS(containerID string) (string, error) {
+	netns, err := r.consultedRuntime.NetNS(containerID)
+	if r.consulted <= r.failures {
+		return "", fmt.Errorf("container %s not found", containerID)
+	}
+	return netns, err
+}
+
+// concurrencyTrackingMultusClient blocks each delegate invocation until
+// released, recording the most invocations ever running at once.
+type concurrencyTrackingMultusClient struct {
+	*fakemultusclient.Client
+	invoked chan struct{}
+	release chan struct{}
+
+	lock       sync.Mutex
+	running    int
+	maxRunning int
+}
+
+func newConcurrencyTrackingMultusClient() *concurrencyTrackingMultusClient {
+	const maxInvocations = 100
+	return &concurrencyTrackingMultusClient{
+		Client:  fakemultusclient.NewFakeClient(),
+		invoked: make(chan struct{}, maxInvocations),
+		release: make(chan struct{}),
+	}
+}
+
+func (c *concurrencyTrackingMultusClient) InvokeDelegateWithContext(
+	ctx context.Context,
+	multusRequest *multusapi.Request,
+) (*multusapi.Response, error) {
+	c.lock.Lock()
+	c.running++
+	if c.running > c.maxRunning {
+		c.maxRunning = c.running
+	}
+	c.lock.Unlock()
+	defer func() {
+		c.lock.Lock()
+		c.running--
+		c.lock.Unlock()
+	}()
+
+	c.invoked <- struct{}{}
+	<-c.release
+	return c.Client.InvokeDelegateWithContext(ctx, multusRequest)
+}
+
+func (c *concurrencyTrackingMultusClient) maxConcurrent() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.maxRunning
+}
+
 func newFakeNetAttachDefClient(networkAttachments ...nad.NetworkAttachmentDefinition) (nadclient.Interface, error) {
 	netAttachDefClient := fakenadclient.NewSimpleClientset()
 	gvr := metav1.GroupVersionResource{
@@ -318,14 +2695,21 @@ func podSpec(name string, namespace string, networks ...string) *corev1.Pod {
 		},
 		Status: corev1.PodStatus{
 			ContainerStatuses: []corev1.ContainerStatus{
-				{
-					ContainerID: name,
-				},
+				runningContainer("containerd://" + name),
 			},
 		},
 	}
 }
 
+func runningContainer(containerID string) corev1.ContainerStatus {
+	return corev1.ContainerStatus{
+		ContainerID: containerID,
+		State: corev1.ContainerState{
+			Running: &corev1.ContainerStateRunning{},
+		},
+	}
+}
+
 func netAttachDef(netName string, namespace string, config string) nad.NetworkAttachmentDefinition {
 	return nad.NetworkAttachmentDefinition{
 		ObjectMeta: metav1.ObjectMeta{
@@ -338,6 +2722,12 @@ func netAttachDef(netName string, namespace string, config string) nad.NetworkAt
 	}
 }
 
+// tinyNetAttachDef returns the network-attachment-definition of the network
+// most tests attach.
+func tinyNetAttachDef() nad.NetworkAttachmentDefinition {
+	return netAttachDef(networkName, namespace, dummyNetSpec(networkName, cniVersion))
+}
+
 func updatePodSpec(pod *corev1.Pod, networkNames ...string) *corev1.Pod {
 	newPod := pod.DeepCopy()
 	newPod.Annotations[nad.NetworkAttachmentAnnot] = generateNetworkSelectionAnnotation(
@@ -0,0 +1,263 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/logging"
+)
+
+// ReconcileEndpoint reconciles the networks of a pod - under
+// ReconcileEndpoint<namespace>/<name> - on demand, e.g. once its network-status
+// was fixed by hand; see reconcilePod.
+const ReconcileEndpoint = "/reconcile/"
+
+// reconcilePods compares the network selection elements of every pod against
+// its network-status, enqueuing the requests required to close the gap. This
+// recovers from annotation updates missed while the controller was down, and
+// from failed requests.
+func (pnc *PodNetworksController) reconcilePods() {
+	pods, err := pnc.podsLister.List(pnc.podSelector)
+	if err != nil {
+		klog.ErrorS(err, "failed to list the pods to reconcile")
+		return
+	}
+
+	klog.V(logging.Debug).InfoS("reconciling the pods' networks", "pods", len(pods))
+	for _, pod := range pods {
+		pnc.reconcilePod(pod)
+	}
+}
+
+// serveReconcile reconciles the networks of the pod named in the request's
+// path, replying once the required requests - if any - are enqueued.
+func (pnc *PodNetworksController) serveReconcile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "expected a POST request", http.StatusMethodNotAllowed)
+		return
+	}
+	podKey := strings.TrimPrefix(r.URL.Path, ReconcileEndpoint)
+	namespace, name, isPodKey := strings.Cut(podKey, "/")
+	if !isPodKey || namespace == "" || name == "" || strings.Contains(name, "/") {
+		http.Error(w, "expected "+ReconcileEndpoint+"<namespace>/<name>", http.StatusBadRequest)
+		return
+	}
+	pod, err := pnc.podsLister.Pods(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		http.Error(w, "pod "+podKey+" not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !pnc.isPodSelected(pod) {
+		http.Error(w, "pod "+podKey+" is not handled by the controller", http.StatusNotFound)
+		return
+	}
+	klog.InfoS("reconciling the pod's networks on demand", "pod", name, "namespace", namespace)
+	pnc.reconcilePod(pod)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (pnc *PodNetworksController) reconcilePod(pod *corev1.Pod) {
+	podKey := annotations.NamespacedName(pod.GetNamespace(), pod.GetName())
+	logger := klog.LoggerWithValues(klog.Background(), "pod", pod.GetName(), "namespace", pod.GetNamespace())
+	if pod.Spec.HostNetwork {
+		logger.V(logging.Debug).Info("skipping reconciliation: the pod uses the host network")
+		return
+	}
+	if isMirrorPod(pod) {
+		logger.V(logging.Debug).Info("skipping reconciliation: the pod mirrors a static pod")
+		return
+	}
+	if pnc.pendingRequests.has(podKey) {
+		logger.V(logging.Debug).Info("skipping reconciliation: the pod has pending requests")
+		return
+	}
+
+	netnsPath, err := pnc.netnsPath(pod)
+	if err != nil {
+		// multus is still (or no longer) handling the pod's networks
+		logger.V(logging.Debug).Info("skipping reconciliation", "reason", err)
+		return
+	}
+
+	desiredNetworks, err := networkSelectionElements(pod.Annotations, pod.GetNamespace())
+	if err != nil {
+		logger.V(logging.Debug).Info("skipping reconciliation", "reason", err)
+		return
+	}
+	if duplicateIfaces := duplicateInterfaceNames(desiredNetworks); len(duplicateIfaces) > 0 {
+		logger.V(logging.Debug).Info("skipping reconciliation: duplicate interface names", "interfaces", duplicateIfaces)
+		return
+	}
+	currentNetworks, err := networkStatus(pod.Annotations)
+	if err != nil {
+		logger.V(logging.Debug).Info("skipping reconciliation", "reason", err)
+		return
+	}
+
+	if pnc.reattachToRecreatedSandbox(pod, netnsPath, desiredNetworks, currentNetworks) {
+		return
+	}
+
+	toAdd, toRemove := networkDrift(desiredNetworks, currentNetworks)
+	toAdd = validNetworks(toAdd)
+	toRemove = pnc.dynamicNetworks(pod, toRemove)
+	if isTerminating(pod) {
+		toAdd = nil
+	}
+	if !pnc.isNetworksUpdateAllowed(pod, toAdd, toRemove) {
+		return
+	}
+	if len(toRemove) > 0 {
+		logger.Info("reconcile: computed the attachments to remove", "attachments", len(toRemove))
+		pnc.enqueue(
+			&DynamicAttachmentRequest{
+				PodName:         pod.GetName(),
+				PodUID:          pod.GetUID(),
+				PodNamespace:    pod.GetNamespace(),
+				AttachmentNames: toRemove,
+				Type:            remove,
+				PodNetNS:        netnsPath,
+			})
+	}
+	if len(toAdd) > 0 {
+		logger.Info("reconcile: computed the attachments to add", "attachments", len(toAdd))
+		pnc.enqueue(
+			&DynamicAttachmentRequest{
+				PodName:         pod.GetName(),
+				PodUID:          pod.GetUID(),
+				PodNamespace:    pod.GetNamespace(),
+				AttachmentNames: toAdd,
+				Type:            add,
+				PodNetNS:        netnsPath,
+			})
+	}
+}
+
+// validateAttachment checks the network selection element, along with the
+// cni-args the controller consumes before invoking the delegate.
+func validateAttachment(network *nadv1.NetworkSelectionElement) error {
+	if err := annotations.ValidateNetworkSelectionElement(network); err != nil {
+		return err
+	}
+	if _, err := mtuRequest(network); err != nil {
+		return err
+	}
+	_, err := ipamOverride(network)
+	return err
+}
+
+// validNetworks filters out the invalid network selection elements, which were
+// reported when their attachment was first requested.
+func validNetworks(networks []*nadv1.NetworkSelectionElement) []*nadv1.NetworkSelectionElement {
+	var validNetworks []*nadv1.NetworkSelectionElement
+	for _, network := range networks {
+		if err := validateAttachment(network); err != nil {
+			klog.V(logging.Debug).InfoS("not reconciling invalid attachment", "nad", annotations.NamespacedName(network.Namespace, network.Name), "interface", network.InterfaceRequest, "reason", err)
+			continue
+		}
+		validNetworks = append(validNetworks, network)
+	}
+	return validNetworks
+}
+
+// dynamicNetworks filters out the networks whose interface the controller did
+// not add, e.g. multus' default networks: those are left alone.
+func (pnc *PodNetworksController) dynamicNetworks(
+	pod *corev1.Pod,
+	networks []*nadv1.NetworkSelectionElement,
+) []*nadv1.NetworkSelectionElement {
+	var dynamicNetworks []*nadv1.NetworkSelectionElement
+	for _, network := range networks {
+		if !pnc.isDynamicInterface(pod, network.InterfaceRequest) {
+			klog.V(logging.Debug).InfoS("not reconciling attachment the controller did not add", "nad", annotations.NamespacedName(network.Namespace, network.Name), "interface", network.InterfaceRequest)
+			continue
+		}
+		dynamicNetworks = append(dynamicNetworks, network)
+	}
+	return dynamicNetworks
+}
+
+// networkDrift computes which of the desired networks are missing from the
+// network-status, and which of the (non default) networks in the network-status
+// are no longer desired. Each network-status entry satisfies a single desired
+// network; the networks requesting an explicit interface name are matched first.
+func networkDrift(
+	desiredNetworks []*nadv1.NetworkSelectionElement,
+	currentNetworks []nadv1.NetworkStatus,
+) ([]*nadv1.NetworkSelectionElement, []*nadv1.NetworkSelectionElement) {
+	claimedNetworks := make([]bool, len(currentNetworks))
+	var toAdd, implicitlyNamedNetworks []*nadv1.NetworkSelectionElement
+	for _, desiredNetwork := range desiredNetworks {
+		if desiredNetwork.InterfaceRequest == "" {
+			implicitlyNamedNetworks = append(implicitlyNamedNetworks, desiredNetwork)
+			continue
+		}
+		if !claimNetworkStatus(desiredNetwork, currentNetworks, claimedNetworks) {
+			toAdd = append(toAdd, desiredNetwork)
+		}
+	}
+	for _, desiredNetwork := range implicitlyNamedNetworks {
+		if !claimNetworkStatus(desiredNetwork, currentNetworks, claimedNetworks) {
+			toAdd = append(toAdd, desiredNetwork)
+		}
+	}
+
+	var toRemove []*nadv1.NetworkSelectionElement
+	for i := range currentNetworks {
+		if currentNetworks[i].Default || claimedNetworks[i] {
+			continue
+		}
+		currentNetwork := networkStatusSelectionElement(currentNetworks[i])
+		if currentNetwork == nil {
+			klog.InfoS("cannot reconcile network: its name is not namespaced", "nad", currentNetworks[i].Name)
+			continue
+		}
+		toRemove = append(toRemove, currentNetwork)
+	}
+	return toAdd, toRemove
+}
+
+// claimNetworkStatus marks the first unclaimed network-status entry satisfying
+// the desired network as claimed, returning false if there is none.
+func claimNetworkStatus(
+	desiredNetwork *nadv1.NetworkSelectionElement,
+	currentNetworks []nadv1.NetworkStatus,
+	claimedNetworks []bool,
+) bool {
+	netName := annotations.NamespacedName(desiredNetwork.Namespace, desiredNetwork.Name)
+	for i := range currentNetworks {
+		if claimedNetworks[i] || currentNetworks[i].Default || currentNetworks[i].Name != netName {
+			continue
+		}
+		if desiredNetwork.InterfaceRequest == "" || desiredNetwork.InterfaceRequest == currentNetworks[i].Interface {
+			claimedNetworks[i] = true
+			return true
+		}
+	}
+	return false
+}
+
+// networkStatusSelectionElement returns the network selection element that
+// produced the network-status entry, or nil if it cannot be inferred.
+func networkStatusSelectionElement(status nadv1.NetworkStatus) *nadv1.NetworkSelectionElement {
+	nameParts := strings.Split(status.Name, "/")
+	if len(nameParts) != 2 {
+		return nil
+	}
+	return &nadv1.NetworkSelectionElement{
+		Namespace:        nameParts[0],
+		Name:             nameParts[1],
+		InterfaceRequest: status.Interface,
+	}
+}
@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+
+	nad "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+	fakecri "github.com/maiqueb/multus-dynamic-networks-controller/pkg/cri/fake"
+	fakemultusclient "github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni/fake"
+)
+
+var _ = Describe("The network drift", func() {
+	It("features the attachments missing from the network-status, and the undesired ones", func() {
+		desiredNetworks := []*nad.NetworkSelectionElement{
+			{Name: "net1", Namespace: namespace, InterfaceRequest: "net1"},
+			{Name: "net2", Namespace: namespace, InterfaceRequest: "net2"},
+		}
+		currentNetworks := []nad.NetworkStatus{
+			{Name: "cluster-default-net", Interface: "eth0", Default: true},
+			{Name: "default/net1", Interface: "net1"},
+			{Name: "default/net3", Interface: "net3"},
+		}
+
+		toAdd, toRemove := networkDrift(desiredNetworks, currentNetworks)
+		Expect(toAdd).To(ConsistOf(desiredNetworks[1]))
+		Expect(toRemove).To(ConsistOf(&nad.NetworkSelectionElement{Name: "net3", Namespace: namespace, InterfaceRequest: "net3"}))
+	})
+})
+
+var _ = Describe("Reconciling a pod", func() {
+	It("only removes the undesired attachments the controller added", func() {
+		pod := podSpec(podName, namespace)
+		pod.Annotations[nad.NetworkAttachmentAnnot] = `[{"name": "tiny-net", "interface": "net1"}]`
+		pod.Annotations[nad.NetworkStatusAnnot] = `[{"name":"cluster-default-net","interface":"eth0","default":true},` +
+			`{"name":"default/multus-default-net","interface":"net0"},` +
+			`{"name":"default/tiny-net","interface":"net1"},` +
+			`{"name":"default/stale-net","interface":"net2"}]`
+		pod.Annotations[defaultBookkeepingKeys.DynamicInterfaces] = `["net1","net2"]`
+		podController := newUnstartedPodController(fakecri.NewFakeRuntime(*pod), fakemultusclient.NewFakeClient())
+
+		podController.reconcilePod(pod)
+
+		Expect(podController.workqueue.Len()).To(Equal(1))
+		request := podController.pendingRequests.peek(annotations.NamespacedName(namespace, podName))
+		Expect(request).NotTo(BeNil())
+		Expect(request.Type).To(Equal(remove))
+		Expect(request.AttachmentNames).To(ConsistOf(
+			&nad.NetworkSelectionElement{Name: "stale-net", Namespace: namespace, InterfaceRequest: "net2"}))
+	})
+})
+
+var _ = Describe("Reconciling a pod on demand", func() {
+	var (
+		podController *PodNetworksController
+		server        *httptest.Server
+	)
+
+	post := func(endpoint string) int {
+		response, err := http.Post(server.URL+endpoint, "application/json", http.NoBody)
+		Expect(err).NotTo(HaveOccurred())
+		defer response.Body.Close()
+		return response.StatusCode
+	}
+
+	BeforeEach(func() {
+		pod := podSpec(podName, namespace, networkName)
+		// the network-status lacks the newly requested network
+		pod = updatePodSpec(pod, networkName, "new-net")
+		podController = newUnstartedPodController(fakecri.NewFakeRuntime(*pod), fakemultusclient.NewFakeClient())
+		Expect(podController.podsInformer.GetStore().Add(pod)).To(Succeed())
+		server = httptest.NewServer(podController.AdminHandler())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("enqueues the requests closing the gap between its networks and network-status", func() {
+		Expect(post(ReconcileEndpoint + annotations.NamespacedName(namespace, podName))).To(Equal(http.StatusAccepted))
+
+		Expect(podController.workqueue.Len()).To(Equal(1))
+		request := podController.pendingRequests.peek(annotations.NamespacedName(namespace, podName))
+		Expect(request).NotTo(BeNil())
+		Expect(request.Type).To(Equal(add))
+		Expect(request.AttachmentNames).To(ConsistOf(
+			&nad.NetworkSelectionElement{Name: "new-net", Namespace: namespace, InterfaceRequest: "net1"}))
+	})
+
+	It("leaves the mirror pods alone", func() {
+		pod := podSpec(podName, namespace, networkName)
+		pod = updatePodSpec(pod, networkName, "new-net")
+		pod.Annotations[corev1.MirrorPodAnnotationKey] = "0123456789abcdef"
+		Expect(podController.podsInformer.GetStore().Update(pod)).To(Succeed())
+
+		Expect(post(ReconcileEndpoint + annotations.NamespacedName(namespace, podName))).To(Equal(http.StatusAccepted))
+
+		Expect(podController.workqueue.Len()).To(BeZero())
+	})
+
+	It("rejects the unknown pods", func() {
+		Expect(post(ReconcileEndpoint + annotations.NamespacedName(namespace, "other-pod"))).To(Equal(http.StatusNotFound))
+
+		Expect(podController.workqueue.Len()).To(BeZero())
+	})
+
+	It("rejects the paths not naming a pod", func() {
+		Expect(post(ReconcileEndpoint + podName)).To(Equal(http.StatusBadRequest))
+
+		Expect(podController.workqueue.Len()).To(BeZero())
+	})
+})
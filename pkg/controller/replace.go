@@ -0,0 +1,265 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	multusapi "gopkg.in/k8snetworkplumbingwg/multus-cni.v3/pkg/server/api"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/audit"
+)
+
+// replacements pairs the attachments to remove with the attachments to add
+// requesting the same interface - e.g. when the addresses or the network
+// behind an interface change -, returning the paired attachments - in the
+// same order -, followed by the attachments left to remove and to add. Only
+// the valid attachments requesting their interface by name are paired.
+func replacements(
+	toRemove []*nadv1.NetworkSelectionElement,
+	toAdd []*nadv1.NetworkSelectionElement,
+) (replaced, replacing, remainingToRemove, remainingToAdd []*nadv1.NetworkSelectionElement) {
+	toRemoveIndex := map[string]*nadv1.NetworkSelectionElement{}
+	for _, network := range toRemove {
+		if network.InterfaceRequest != "" {
+			toRemoveIndex[network.InterfaceRequest] = network
+		}
+	}
+	pairedIfaces := map[string]bool{}
+	for _, network := range toAdd {
+		replacedNetwork, wasFound := toRemoveIndex[network.InterfaceRequest]
+		if network.InterfaceRequest == "" || !wasFound || pairedIfaces[network.InterfaceRequest] || validateAttachment(network) != nil {
+			remainingToAdd = append(remainingToAdd, network)
+			continue
+		}
+		pairedIfaces[network.InterfaceRequest] = true
+		replaced = append(replaced, replacedNetwork)
+		replacing = append(replacing, network)
+	}
+	for _, network := range toRemove {
+		if network.InterfaceRequest == "" || !pairedIfaces[network.InterfaceRequest] {
+			remainingToRemove = append(remainingToRemove, network)
+		}
+	}
+	return replaced, replacing, remainingToRemove, remainingToAdd
+}
+
+// replaceNetworks swaps the replaced attachments for the request's ones in a
+// single step: the replaced attachments are removed, then the new ones added,
+// the network-status being updated once. When adding any of the new
+// attachments fails, the added ones are removed and the replaced ones
+// restored; the pod thus never features both, nor - unless restoring them
+// fails too - neither.
+func (pnc *PodNetworksController) replaceNetworks(
+	ctx context.Context,
+	dynamicAttachmentRequest *DynamicAttachmentRequest,
+	pod *corev1.Pod,
+) error {
+	if err := pnc.validateReplacement(ctx, dynamicAttachmentRequest, pod); err != nil {
+		return err
+	}
+	if err := pnc.ensurePodFinalizer(ctx, pod); err != nil {
+		return err
+	}
+	removedNetworks, err := pnc.detachReplacedNetworks(ctx, dynamicAttachmentRequest, pod)
+	if err != nil {
+		return err
+	}
+	addedNetworks, responses, err := pnc.attachReplacingNetworks(ctx, dynamicAttachmentRequest, pod, removedNetworks)
+	if err != nil {
+		return err
+	}
+	if pnc.dryRun {
+		return nil
+	}
+
+	if err = pnc.updatePodNetworkStatus(
+		ctx,
+		pod,
+		replaceIfacesInStatus(removedNetworks, addedNetworks, responses),
+		addedNetworks,
+		dynamicAttachmentRequest.PodNetNS,
+	); err != nil {
+		for _, addedNetwork := range addedNetworks {
+			pnc.Eventf(pod, corev1.EventTypeWarning, "AddInterfaceFailed", addIfaceFailedEventFormat(pod, addedNetwork, err))
+		}
+		pnc.audit(dynamicAttachmentRequest, pod, audit.OperationAdd, err, addedNetworks...)
+		pnc.rollbackReplacement(ctx, dynamicAttachmentRequest, pod, removedNetworks, addedNetworks)
+		return err
+	}
+	pnc.recordInterfacesState(ctx, pod, addedNetworks, InterfaceAttached, "")
+	pnc.audit(dynamicAttachmentRequest, pod, audit.OperationRemove, nil, removedNetworks...)
+	pnc.audit(dynamicAttachmentRequest, pod, audit.OperationAdd, nil, addedNetworks...)
+	for _, removedNetwork := range removedNetworks {
+		pnc.Eventf(pod, corev1.EventTypeNormal, "RemovedInterface", removeIfaceEventFormat(pod, removedNetwork))
+	}
+	for i := range addedNetworks {
+		pnc.Eventf(pod, corev1.EventTypeNormal, "AddedInterface", addIfaceEventFormat(pod, addedNetworks[i], responses[i].Result))
+	}
+	return nil
+}
+
+// validateReplacement checks the request's attachments can be added before
+// removing the replaced ones. Retrying the request is pointless when they are
+// invalid, or reference a network of a namespace denying it.
+func (pnc *PodNetworksController) validateReplacement(
+	ctx context.Context,
+	dynamicAttachmentRequest *DynamicAttachmentRequest,
+	pod *corev1.Pod,
+) error {
+	for _, netToAdd := range dynamicAttachmentRequest.AttachmentNames {
+		if err := validateAttachment(netToAdd); err != nil {
+			pnc.Eventf(pod, corev1.EventTypeWarning, "InvalidInterfaceRequest", invalidIfaceEventFormat(pod, netToAdd, err))
+			pnc.audit(dynamicAttachmentRequest, pod, audit.OperationAdd, err, netToAdd)
+			return newTerminalError(err)
+		}
+		err := pnc.checkNetworkReference(ctx, pod, netToAdd)
+		if errors.Is(err, errCrossNamespaceReferenceDenied) {
+			pnc.Eventf(pod, corev1.EventTypeWarning, "CrossNamespaceReferenceDenied", crossNamespaceReferenceDeniedEventFormat(pod, netToAdd))
+			pnc.audit(dynamicAttachmentRequest, pod, audit.OperationAdd, err, netToAdd)
+			return newTerminalError(err)
+		}
+		if err != nil {
+			pnc.Eventf(pod, corev1.EventTypeWarning, "AddInterfaceFailed", addIfaceFailedEventFormat(pod, netToAdd, err))
+			pnc.audit(dynamicAttachmentRequest, pod, audit.OperationAdd, err, netToAdd)
+			return err
+		}
+	}
+	return nil
+}
+
+// detachReplacedNetworks removes the replaced attachments the pod features,
+// returning them; when removing any of them fails, the removed ones are
+// restored.
+func (pnc *PodNetworksController) detachReplacedNetworks(
+	ctx context.Context,
+	dynamicAttachmentRequest *DynamicAttachmentRequest,
+	pod *corev1.Pod,
+) ([]*nadv1.NetworkSelectionElement, error) {
+	replacedNetworks := pnc.attachedInterfaces(ctx, pod, dynamicAttachmentRequest.ReplacedAttachmentNames)
+	var removedNetworks []*nadv1.NetworkSelectionElement
+	for _, netToRemove := range replacedNetworks {
+		if err := pnc.detachNetwork(ctx, dynamicAttachmentRequest, pod, netToRemove); err != nil {
+			pnc.Eventf(pod, corev1.EventTypeWarning, "RemoveInterfaceFailed", removeIfaceFailedEventFormat(pod, netToRemove, err))
+			pnc.audit(dynamicAttachmentRequest, pod, audit.OperationRemove, err, netToRemove)
+			pnc.restoreNetworks(ctx, dynamicAttachmentRequest, pod, removedNetworks)
+			return nil, err
+		}
+		removedNetworks = append(removedNetworks, netToRemove)
+	}
+	return removedNetworks, nil
+}
+
+// attachReplacingNetworks adds the request's attachments, returning them along
+// with the delegates' responses; when adding any of them fails, the replacement
+// - i.e. the removal of `removedNetworks` - is rolled back.
+func (pnc *PodNetworksController) attachReplacingNetworks(
+	ctx context.Context,
+	dynamicAttachmentRequest *DynamicAttachmentRequest,
+	pod *corev1.Pod,
+	removedNetworks []*nadv1.NetworkSelectionElement,
+) ([]*nadv1.NetworkSelectionElement, []*multusapi.Response, error) {
+	addedNetworks := make([]*nadv1.NetworkSelectionElement, 0, len(dynamicAttachmentRequest.AttachmentNames))
+	responses := make([]*multusapi.Response, 0, len(dynamicAttachmentRequest.AttachmentNames))
+	for _, netToAdd := range dynamicAttachmentRequest.AttachmentNames {
+		response, err := pnc.addNetwork(ctx, dynamicAttachmentRequest, pod, netToAdd)
+		if err == nil {
+			addedNetworks = append(addedNetworks, netToAdd)
+			responses = append(responses, response)
+			err = pnc.applySysctls(ctx, dynamicAttachmentRequest, pod, netToAdd)
+		}
+		if err != nil {
+			klog.FromContext(ctx).Error(err, "failed to replace the attachments: restoring the replaced ones",
+				"nad", annotations.NamespacedName(netToAdd.Namespace, netToAdd.Name),
+				"interface", netToAdd.InterfaceRequest)
+			pnc.Eventf(pod, corev1.EventTypeWarning, "AddInterfaceFailed", addIfaceFailedEventFormat(pod, netToAdd, err))
+			pnc.audit(dynamicAttachmentRequest, pod, audit.OperationAdd, err, netToAdd)
+			pnc.rollbackReplacement(ctx, dynamicAttachmentRequest, pod, removedNetworks, addedNetworks)
+			return nil, nil, err
+		}
+	}
+	return addedNetworks, responses, nil
+}
+
+// rollbackReplacement removes the added attachments - in the reverse order -,
+// then restores the replaced ones.
+func (pnc *PodNetworksController) rollbackReplacement(
+	ctx context.Context,
+	dynamicAttachmentRequest *DynamicAttachmentRequest,
+	pod *corev1.Pod,
+	removedNetworks []*nadv1.NetworkSelectionElement,
+	addedNetworks []*nadv1.NetworkSelectionElement,
+) {
+	for i := len(addedNetworks) - 1; i >= 0; i-- {
+		if err := pnc.detachNetwork(ctx, dynamicAttachmentRequest, pod, addedNetworks[i]); err != nil {
+			klog.FromContext(ctx).Error(err, "failed to roll back the added attachment", "interface", addedNetworks[i].InterfaceRequest)
+		}
+	}
+	pnc.restoreNetworks(ctx, dynamicAttachmentRequest, pod, removedNetworks)
+}
+
+// restoreNetworks adds back the replaced attachments a failed replacement
+// removed, refreshing their network-status entries - e.g. the delegate may
+// have handed out other addresses; the entries of the ones failing to be added
+// back are dropped.
+func (pnc *PodNetworksController) restoreNetworks(
+	ctx context.Context,
+	dynamicAttachmentRequest *DynamicAttachmentRequest,
+	pod *corev1.Pod,
+	removedNetworks []*nadv1.NetworkSelectionElement,
+) {
+	if len(removedNetworks) == 0 || pnc.dryRun {
+		return
+	}
+
+	logger := klog.FromContext(ctx)
+	logger.Info("restoring the replaced attachments", "attachments", len(removedNetworks))
+	restoredNetworks := make([]*nadv1.NetworkSelectionElement, 0, len(removedNetworks))
+	responses := make([]*multusapi.Response, 0, len(removedNetworks))
+	for _, netToRestore := range removedNetworks {
+		response, err := pnc.addNetwork(ctx, dynamicAttachmentRequest, pod, netToRestore)
+		if err != nil {
+			logger.Error(err, "failed to restore the replaced attachment", "interface", netToRestore.InterfaceRequest)
+			pnc.Eventf(pod, corev1.EventTypeWarning, "AddInterfaceFailed", addIfaceFailedEventFormat(pod, netToRestore, err))
+			continue
+		}
+		restoredNetworks = append(restoredNetworks, netToRestore)
+		responses = append(responses, response)
+	}
+	if err := pnc.updatePodNetworkStatus(
+		ctx,
+		pod,
+		replaceIfacesInStatus(removedNetworks, restoredNetworks, responses),
+		restoredNetworks,
+		"",
+	); err != nil {
+		logger.Error(err, "failed to record the restored attachments")
+	}
+}
+
+// replaceIfacesInStatus drops the removed interfaces from the pod's
+// network-status, then appends the added ones - described by the delegate
+// responses.
+func replaceIfacesInStatus(
+	removedNetworks []*nadv1.NetworkSelectionElement,
+	addedNetworks []*nadv1.NetworkSelectionElement,
+	responses []*multusapi.Response,
+) networkStatusUpdate {
+	return func(pod *corev1.Pod) (string, error) {
+		// the pod must not be mutated; only its network-status is relevant
+		statusPod := &corev1.Pod{ObjectMeta: *pod.ObjectMeta.DeepCopy()}
+		for _, removedNetwork := range removedNetworks {
+			newIfaceStatus, err := removeIfaceFromStatus(removedNetwork)(statusPod)
+			if err != nil {
+				return "", fmt.Errorf("failed to compute the updated network status: %v", err)
+			}
+			setNetworkStatus(statusPod, newIfaceStatus)
+		}
+		return addIfacesToStatus(addedNetworks, responses)(statusPod)
+	}
+}
@@ -0,0 +1,253 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+
+	nad "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	multusapi "gopkg.in/k8snetworkplumbingwg/multus-cni.v3/pkg/server/api"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni"
+	fakemultusclient "github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni/fake"
+)
+
+var _ = Describe("Swapping the network behind an interface", func() {
+	const (
+		oldNetworkName   = "tiny-net"
+		newNetworkName   = "other-net"
+		swappedIfaceName = "net0"
+	)
+
+	var (
+		eventRecorder       *record.FakeRecorder
+		multusClient        *failingNetworkMultusClient
+		pod                 *corev1.Pod
+		podController       *PodNetworksController
+		networkStatusWrites int
+	)
+
+	BeforeEach(func() {
+		pod = podSpec(podName, namespace, oldNetworkName)
+		addConfig := networkConfig(multuscni.CmdAdd, swappedIfaceName, swappedIfaceName, macAddr)
+		addConfig.Response.Result.Interfaces[0].Sandbox = netnsPath
+		multusClient = &failingNetworkMultusClient{
+			Client: fakemultusclient.NewFakeClient(networkConfig(multuscni.CmdDel, swappedIfaceName, "", ""), addConfig),
+		}
+		podController = newSynchedPodController(
+			pod,
+			multusClient,
+			netAttachDef(oldNetworkName, namespace, dummyNetSpec(oldNetworkName, cniVersion)),
+			netAttachDef(newNetworkName, namespace, dummyNetSpec(newNetworkName, cniVersion)))
+		eventRecorder = record.NewFakeRecorder(10)
+		podController.recorder = eventRecorder
+		networkStatusWrites = 0
+		podController.k8sClientSet.(*fake.Clientset).PrependReactor(
+			"patch",
+			"pods",
+			func(action k8stesting.Action) (bool, runtime.Object, error) {
+				if strings.Contains(string(action.(k8stesting.PatchAction).GetPatch()), nad.NetworkStatusAnnot) {
+					networkStatusWrites++
+				}
+				return false, nil, nil
+			})
+	})
+
+	swap := func() {
+		podController.handlePodUpdate(pod, updatePodSpec(pod, newNetworkName))
+		for podController.workqueue.Len() > 0 {
+			Expect(podController.processNextWorkItem()).To(BeTrue())
+		}
+	}
+
+	commands := func() []string {
+		var commands []string
+		for _, request := range multusClient.Requests() {
+			Expect(request.Env["CNI_IFNAME"]).To(Equal(swappedIfaceName))
+			commands = append(commands, request.Env["CNI_COMMAND"])
+		}
+		return commands
+	}
+
+	currentPod := func() *corev1.Pod {
+		currentPod, err := podController.k8sClientSet.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		return currentPod
+	}
+
+	It("removes the old attachment before adding the new one, updating the network-status once", func() {
+		swap()
+
+		Expect(commands()).To(Equal([]string{multuscni.CmdDel, multuscni.CmdAdd}))
+		Expect(networkStatusWrites).To(Equal(1))
+		Expect(isInterfaceInNetworkStatus(
+			currentPod(),
+			&nad.NetworkSelectionElement{Name: oldNetworkName, Namespace: namespace, InterfaceRequest: swappedIfaceName})).To(BeFalse())
+		Expect(isInterfaceInNetworkStatus(
+			currentPod(),
+			&nad.NetworkSelectionElement{Name: newNetworkName, Namespace: namespace, InterfaceRequest: swappedIfaceName})).To(BeTrue())
+		Expect(eventRecorder.Events).To(Receive(HavePrefix("Normal RemovedInterface")))
+		Expect(eventRecorder.Events).To(Receive(HavePrefix("Normal AddedInterface")))
+	})
+
+	It("restores the old attachment when adding the new one fails", func() {
+		multusClient.failingNetwork = newNetworkName
+
+		podController.handlePodUpdate(pod, updatePodSpec(pod, newNetworkName))
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		Expect(commands()).To(Equal([]string{multuscni.CmdDel, multuscni.CmdAdd, multuscni.CmdAdd}))
+		Expect(multusClient.addedNetworks).To(Equal([]string{newNetworkName, oldNetworkName}))
+		Expect(networkStatusWrites).To(Equal(1))
+		Expect(isInterfaceInNetworkStatus(
+			currentPod(),
+			&nad.NetworkSelectionElement{Name: oldNetworkName, Namespace: namespace, InterfaceRequest: swappedIfaceName})).To(BeTrue())
+		Expect(isInterfaceInNetworkStatus(
+			currentPod(),
+			&nad.NetworkSelectionElement{Name: newNetworkName, Namespace: namespace, InterfaceRequest: swappedIfaceName})).To(BeFalse())
+		Expect(eventRecorder.Events).To(Receive(Equal(fmt.Sprintf(
+			"Warning AddInterfaceFailed pod [%s]: failed adding interface %s to network: %s: failed to ADD delegate: the network is unavailable",
+			annotations.NamespacedName(namespace, podName),
+			swappedIfaceName,
+			newNetworkName))))
+		Expect(eventRecorder.Events).NotTo(Receive())
+		Expect(podController.workqueue.NumRequeues(annotations.NamespacedName(namespace, podName))).To(Equal(1))
+	})
+
+	It("completes once the retried addition succeeds", func() {
+		multusClient.failingNetwork = newNetworkName
+		podController.handlePodUpdate(pod, updatePodSpec(pod, newNetworkName))
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		multusClient.failingNetwork = ""
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		Expect(isInterfaceInNetworkStatus(
+			currentPod(),
+			&nad.NetworkSelectionElement{Name: newNetworkName, Namespace: namespace, InterfaceRequest: swappedIfaceName})).To(BeTrue())
+		Expect(podController.pendingRequests.has(annotations.NamespacedName(namespace, podName))).To(BeFalse())
+	})
+
+	Context("with a network of another namespace", func() {
+		const otherNamespace = "network-owner"
+
+		swapToOtherNamespace := func() {
+			WithCrossNamespaceReferencesRestricted(true)(podController)
+			podController.enqueue(&DynamicAttachmentRequest{
+				PodName:      podName,
+				PodNamespace: namespace,
+				AttachmentNames: []*nad.NetworkSelectionElement{
+					{Name: newNetworkName, Namespace: otherNamespace, InterfaceRequest: swappedIfaceName},
+				},
+				ReplacedAttachmentNames: []*nad.NetworkSelectionElement{
+					{Name: oldNetworkName, Namespace: namespace, InterfaceRequest: swappedIfaceName},
+				},
+				Type:     replace,
+				PodNetNS: netnsPath,
+			})
+			Expect(podController.processNextWorkItem()).To(BeTrue())
+		}
+
+		It("drops the request when the namespace denies the reference", func() {
+			_, err := podController.k8sClientSet.CoreV1().Namespaces().Create(
+				context.TODO(),
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: otherNamespace}},
+				metav1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			swapToOtherNamespace()
+
+			Expect(multusClient.Requests()).To(BeEmpty())
+			Expect(eventRecorder.Events).To(Receive(HavePrefix("Warning CrossNamespaceReferenceDenied")))
+			Expect(podController.workqueue.NumRequeues(annotations.NamespacedName(namespace, podName))).To(BeZero())
+		})
+
+		It("retries the request when the namespace cannot be looked up", func() {
+			podController.k8sClientSet.(*fake.Clientset).PrependReactor(
+				"get",
+				"namespaces",
+				func(action k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, errors.New("the API server is unavailable")
+				})
+
+			swapToOtherNamespace()
+
+			Expect(multusClient.Requests()).To(BeEmpty())
+			Expect(eventRecorder.Events).To(Receive(HavePrefix("Warning AddInterfaceFailed")))
+			Expect(podController.workqueue.NumRequeues(annotations.NamespacedName(namespace, podName))).To(Equal(1))
+		})
+	})
+})
+
+var _ = Describe("Pairing the attachments to remove and to add", func() {
+	attachment := func(name string, ifaceName string) *nad.NetworkSelectionElement {
+		return &nad.NetworkSelectionElement{Name: name, Namespace: namespace, InterfaceRequest: ifaceName}
+	}
+
+	It("replaces the attachments requesting the same interface, by name", func() {
+		replaced, replacing, toRemove, toAdd := replacements(
+			[]*nad.NetworkSelectionElement{attachment("net-a", "net1"), attachment("net-b", "net2"), attachment("net-c", "")},
+			[]*nad.NetworkSelectionElement{attachment("net-d", "net2"), attachment("net-e", "net3"), attachment("net-f", "")})
+
+		Expect(replaced).To(Equal([]*nad.NetworkSelectionElement{attachment("net-b", "net2")}))
+		Expect(replacing).To(Equal([]*nad.NetworkSelectionElement{attachment("net-d", "net2")}))
+		Expect(toRemove).To(Equal([]*nad.NetworkSelectionElement{attachment("net-a", "net1"), attachment("net-c", "")}))
+		Expect(toAdd).To(Equal([]*nad.NetworkSelectionElement{attachment("net-e", "net3"), attachment("net-f", "")}))
+	})
+
+	It("does not replace an attachment with an invalid one", func() {
+		invalidAttachment := attachment("net-b", "net1")
+		invalidAttachment.MacRequest = "not-a-mac"
+
+		replaced, replacing, toRemove, toAdd := replacements(
+			[]*nad.NetworkSelectionElement{attachment("net-a", "net1")},
+			[]*nad.NetworkSelectionElement{invalidAttachment})
+
+		Expect(replaced).To(BeEmpty())
+		Expect(replacing).To(BeEmpty())
+		Expect(toRemove).To(ConsistOf(attachment("net-a", "net1")))
+		Expect(toAdd).To(ConsistOf(invalidAttachment))
+	})
+})
+
+// failingNetworkMultusClient fails adding the interfaces of `failingNetwork`,
+// recording the networks it adds interfaces to.
+type failingNetworkMultusClient struct {
+	*fakemultusclient.Client
+	failingNetwork string
+	addedNetworks  []string
+}
+
+func (c *failingNetworkMultusClient) InvokeDelegateWithContext(
+	ctx context.Context,
+	multusRequest *multusapi.Request,
+) (*multusapi.Response, error) {
+	response, err := c.Client.InvokeDelegateWithContext(ctx, multusRequest)
+	if multusRequest.Env["CNI_COMMAND"] != multuscni.CmdAdd {
+		return response, err
+	}
+	var netConfig struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(multusRequest.Config, &netConfig); err != nil {
+		return nil, err
+	}
+	c.addedNetworks = append(c.addedNetworks, netConfig.Name)
+	if netConfig.Name == c.failingNetwork {
+		return nil, fmt.Errorf("the network is unavailable")
+	}
+	return response, err
+}
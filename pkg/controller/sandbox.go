@@ -0,0 +1,40 @@
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+)
+
+// reattachToRecreatedSandbox re-adds the pod's dynamic interfaces when the
+// pod's sandbox is not the one they were added to - see
+// annotations.BookkeepingKeys.Sandbox -, returning true if it
+// did. They are first removed - thus letting the delegates release what they
+// allocated for the interfaces lost along the previous sandbox - then added
+// back, keeping their interface names.
+func (pnc *PodNetworksController) reattachToRecreatedSandbox(
+	pod *corev1.Pod,
+	netnsPath string,
+	desiredNetworks []*nadv1.NetworkSelectionElement,
+	currentNetworks []nadv1.NetworkStatus,
+) bool {
+	recordedSandbox, wasFound := pod.Annotations[pnc.bookkeepingKeys.Sandbox]
+	if !wasFound || recordedSandbox == netnsPath {
+		return false
+	}
+	attachments := pnc.dynamicAttachments(pod, currentNetworks)
+	if len(attachments) == 0 {
+		return false
+	}
+
+	klog.InfoS(
+		"the pod's sandbox was re-created: re-adding its dynamic interfaces",
+		"pod", pod.GetName(),
+		"namespace", pod.GetNamespace(),
+		"previousNetNS", recordedSandbox,
+		"netns", netnsPath,
+		"attachments", len(attachments))
+	pnc.enqueueReattachments(pod, netnsPath, attachments, desiredNetworks)
+	return true
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+
+	nad "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+	fakecri "github.com/maiqueb/multus-dynamic-networks-controller/pkg/cri/fake"
+	fakemultusclient "github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni/fake"
+)
+
+var _ = Describe("Reconciling a pod whose sandbox was re-created", func() {
+	const previousNetNS = "/var/run/netns/previous-sandbox"
+
+	var (
+		pod           *corev1.Pod
+		podController *PodNetworksController
+		currentNetNS  string
+	)
+
+	BeforeEach(func() {
+		pod = podSpec(podName, namespace)
+		pod.Annotations[nad.NetworkAttachmentAnnot] = `[{"name": "tiny-net", "interface": "net1", "ips": ["10.10.10.10/24"]}]`
+		pod.Annotations[nad.NetworkStatusAnnot] = `[{"name":"cluster-default-net","interface":"eth0","default":true},{"name":"default/tiny-net","interface":"net1"}]`
+		pod.Annotations[defaultBookkeepingKeys.DynamicInterfaces] = `["net1"]`
+		podController = newUnstartedPodController(fakecri.NewFakeRuntime(*pod), fakemultusclient.NewFakeClient())
+
+		var err error
+		currentNetNS, err = podController.netnsPath(pod)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	pendingRequests := func() []*DynamicAttachmentRequest {
+		podKey := annotations.NamespacedName(namespace, podName)
+		var requests []*DynamicAttachmentRequest
+		for request := podController.pendingRequests.peek(podKey); request != nil; request = podController.pendingRequests.peek(podKey) {
+			requests = append(requests, request)
+			podController.pendingRequests.pop(podKey)
+		}
+		return requests
+	}
+
+	It("removes the dynamic interfaces, then adds them back into the new sandbox", func() {
+		pod.Annotations[defaultBookkeepingKeys.Sandbox] = previousNetNS
+		podController.reconcilePod(pod)
+
+		requests := pendingRequests()
+		Expect(requests).To(HaveLen(2))
+		Expect(requests[0].Type).To(Equal(remove))
+		Expect(requests[0].PodNetNS).To(Equal(currentNetNS))
+		Expect(requests[0].AttachmentNames).To(ConsistOf(
+			&nad.NetworkSelectionElement{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"}))
+		Expect(requests[1].Type).To(Equal(add))
+		Expect(requests[1].PodNetNS).To(Equal(currentNetNS))
+		Expect(requests[1].AttachmentNames).To(ConsistOf(
+			&nad.NetworkSelectionElement{Name: networkName, Namespace: namespace, InterfaceRequest: "net1", IPRequest: []string{"10.10.10.10/24"}}))
+	})
+
+	It("does nothing when the sandbox is the one the interfaces were added to", func() {
+		pod.Annotations[defaultBookkeepingKeys.Sandbox] = currentNetNS
+		podController.reconcilePod(pod)
+
+		Expect(pendingRequests()).To(BeEmpty())
+	})
+
+	It("does nothing when the sandbox the interfaces were added to is unknown", func() {
+		podController.reconcilePod(pod)
+
+		Expect(pendingRequests()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+)
+
+// AttachEndpoint attaches the networks of a SelectorAttachment to the pods its
+// selector matches; the networks are appended to each pod's networks
+// annotation, which remains the source of truth of the pod's networks.
+const AttachEndpoint = "/attach"
+
+// SelectorAttachment requests attaching the networks - in the networks
+// annotation's format - to the pods of the namespace matching the label
+// selector; the pods of all namespaces are matched when the namespace is
+// empty. The selector cannot be empty.
+type SelectorAttachment struct {
+	Namespace string                          `json:"namespace,omitempty"`
+	Selector  string                          `json:"selector"`
+	Networks  []nadv1.NetworkSelectionElement `json:"networks"`
+}
+
+// SelectorAttachmentResult lists - sorted - the pods the networks are being
+// attached to.
+type SelectorAttachmentResult struct {
+	Pods []string `json:"pods"`
+}
+
+// AdminHandler returns the handler serving the administrative endpoints.
+func (pnc *PodNetworksController) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(AttachEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "expected a POST request", http.StatusMethodNotAllowed)
+			return
+		}
+		var selectorAttachment SelectorAttachment
+		if err := json.NewDecoder(r.Body).Decode(&selectorAttachment); err != nil {
+			http.Error(w, fmt.Sprintf("failed to parse the selector attachment: %v", err), http.StatusBadRequest)
+			return
+		}
+		podKeys, err := pnc.attachBySelector(&selectorAttachment)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		if err := json.NewEncoder(w).Encode(SelectorAttachmentResult{Pods: podKeys}); err != nil {
+			klog.ErrorS(err, "failed to reply the selector attachment result")
+		}
+	})
+	mux.HandleFunc(DrainAttachmentsEndpoint, pnc.serveDrainAttachments)
+	mux.HandleFunc(PauseEndpoint, pnc.servePause(pnc.Pause))
+	mux.HandleFunc(ResumeEndpoint, pnc.servePause(pnc.Resume))
+	mux.HandleFunc(ReconcileEndpoint, pnc.serveReconcile)
+	return mux
+}
+
+// attachBySelector expands the selector attachment into an attach request per
+// matching pod, returning - sorted - the keys of the pods. The pods using the
+// host network, mirroring static pods, or being deleted, are left out.
+func (pnc *PodNetworksController) attachBySelector(selectorAttachment *SelectorAttachment) ([]string, error) {
+	if selectorAttachment.Selector == "" {
+		return nil, fmt.Errorf("the selector cannot be empty")
+	}
+	selector, err := labels.Parse(selectorAttachment.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the selector: %v", err)
+	}
+	if len(selectorAttachment.Networks) == 0 {
+		return nil, fmt.Errorf("no networks to attach")
+	}
+	for _, network := range selectorAttachment.Networks {
+		if network.Name == "" {
+			return nil, fmt.Errorf("the networks must be named")
+		}
+	}
+
+	var pods []*corev1.Pod
+	if selectorAttachment.Namespace == "" {
+		pods, err = pnc.podsLister.List(selector)
+	} else {
+		pods, err = pnc.podsLister.Pods(selectorAttachment.Namespace).List(selector)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the pods: %v", err)
+	}
+
+	podKeys := []string{}
+	for _, pod := range pods {
+		if !pnc.isPodSelected(pod) || pod.Spec.HostNetwork || isMirrorPod(pod) || isTerminating(pod) {
+			continue
+		}
+		var attachments []*nadv1.NetworkSelectionElement
+		for i := range selectorAttachment.Networks {
+			attachment := selectorAttachment.Networks[i]
+			if attachment.Namespace == "" {
+				attachment.Namespace = pod.GetNamespace()
+			}
+			attachments = append(attachments, &attachment)
+		}
+		pnc.enqueue(&DynamicAttachmentRequest{
+			PodName:         pod.GetName(),
+			PodUID:          pod.GetUID(),
+			PodNamespace:    pod.GetNamespace(),
+			AttachmentNames: attachments,
+			Type:            attach,
+		})
+		podKeys = append(podKeys, annotations.NamespacedName(pod.GetNamespace(), pod.GetName()))
+	}
+	sort.Strings(podKeys)
+	return podKeys, nil
+}
+
+// attachNetworks appends the request's attachments to the pod's networks
+// annotation; the ones it already features are not appended again.
+func (pnc *PodNetworksController) attachNetworks(
+	ctx context.Context,
+	dynamicAttachmentRequest *DynamicAttachmentRequest,
+	pod *corev1.Pod,
+) error {
+	if isTerminating(pod) {
+		klog.FromContext(ctx).Info("discarding the attach request: the pod is terminating")
+		return nil
+	}
+	return pnc.updateNetworksAnnotation(ctx, pod, func(pod *corev1.Pod) ([]nadv1.NetworkSelectionElement, error) {
+		currentNetworks, err := networkSelectionElements(pod.Annotations, pod.GetNamespace())
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the pod's networks: %v", err)
+		}
+		networks := make([]nadv1.NetworkSelectionElement, 0, len(currentNetworks)+len(dynamicAttachmentRequest.AttachmentNames))
+		for _, network := range currentNetworks {
+			networks = append(networks, *network)
+		}
+		currentNetworksIndex := indexNetworkSelectionElements(currentNetworks)
+		for _, attachment := range dynamicAttachmentRequest.AttachmentNames {
+			if _, isAttached := currentNetworksIndex[networkSelectionElementIndexKey(*attachment)]; isAttached {
+				continue
+			}
+			networks = append(networks, *attachment)
+		}
+		return networks, nil
+	})
+}
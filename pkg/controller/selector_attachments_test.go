@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	nad "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+	fakecri "github.com/maiqueb/multus-dynamic-networks-controller/pkg/cri/fake"
+	fakemultusclient "github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni/fake"
+)
+
+var _ = Describe("The selector attachments", func() {
+	const workloadLabel = "app"
+
+	var (
+		podController *PodNetworksController
+		server        *httptest.Server
+	)
+
+	attachment := nad.NetworkSelectionElement{Name: networkName, InterfaceRequest: "net1"}
+
+	workloadPod := func(name string, workload string) *corev1.Pod {
+		pod := podSpec(name, namespace, networkName)
+		pod.Labels = map[string]string{workloadLabel: workload}
+		return pod
+	}
+
+	podNetworks := func(name string) []*nad.NetworkSelectionElement {
+		pod, err := podController.k8sClientSet.CoreV1().Pods(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		networks, err := networkSelectionElements(pod.Annotations, namespace)
+		Expect(err).NotTo(HaveOccurred())
+		return networks
+	}
+
+	post := func(selectorAttachment SelectorAttachment) *http.Response {
+		body, err := json.Marshal(selectorAttachment)
+		Expect(err).NotTo(HaveOccurred())
+		response, err := http.Post(server.URL+AttachEndpoint, "application/json", bytes.NewReader(body))
+		Expect(err).NotTo(HaveOccurred())
+		return response
+	}
+
+	BeforeEach(func() {
+		podController = newUnstartedPodController(fakecri.NewFakeRuntime(), fakemultusclient.NewFakeClient())
+		for _, pod := range []*corev1.Pod{
+			workloadPod("server-0", "server"),
+			workloadPod("server-1", "server"),
+			workloadPod("client", "client"),
+		} {
+			Expect(podController.podsInformer.GetStore().Add(pod)).To(Succeed())
+			_, err := podController.k8sClientSet.CoreV1().Pods(namespace).Create(context.TODO(), pod, metav1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		}
+		server = httptest.NewServer(podController.AdminHandler())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("attach the network to each pod the selector matches", func() {
+		response := post(SelectorAttachment{
+			Namespace: namespace,
+			Selector:  workloadLabel + "=server",
+			Networks:  []nad.NetworkSelectionElement{attachment},
+		})
+		defer response.Body.Close()
+		Expect(response.StatusCode).To(Equal(http.StatusAccepted))
+		var result SelectorAttachmentResult
+		Expect(json.NewDecoder(response.Body).Decode(&result)).To(Succeed())
+		Expect(result.Pods).To(Equal([]string{
+			annotations.NamespacedName(namespace, "server-0"),
+			annotations.NamespacedName(namespace, "server-1"),
+		}))
+
+		Expect(podController.workqueue.Len()).To(Equal(2))
+		for podController.workqueue.Len() > 0 {
+			Expect(podController.processNextWorkItem()).To(BeTrue())
+		}
+
+		expectedAttachment := &nad.NetworkSelectionElement{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"}
+		Expect(podNetworks("server-0")).To(ContainElement(expectedAttachment))
+		Expect(podNetworks("server-1")).To(ContainElement(expectedAttachment))
+		Expect(podNetworks("client")).NotTo(ContainElement(expectedAttachment))
+	})
+
+	It("do not attach the networks the pods already feature again", func() {
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:         "client",
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{{Name: networkName, Namespace: namespace, InterfaceRequest: "net0"}},
+			Type:            attach,
+		})
+
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		Expect(podNetworks("client")).To(HaveLen(1))
+	})
+
+	It("reject the requests without a selector", func() {
+		response := post(SelectorAttachment{Namespace: namespace, Networks: []nad.NetworkSelectionElement{attachment}})
+		defer response.Body.Close()
+
+		Expect(response.StatusCode).To(Equal(http.StatusBadRequest))
+		Expect(podController.workqueue.Len()).To(BeZero())
+	})
+
+	It("reject the requests without networks", func() {
+		response := post(SelectorAttachment{Namespace: namespace, Selector: workloadLabel + "=server"})
+		defer response.Body.Close()
+
+		Expect(response.StatusCode).To(Equal(http.StatusBadRequest))
+		Expect(podController.workqueue.Len()).To(BeZero())
+	})
+})
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"sync"
+
+	"k8s.io/klog/v2"
+)
+
+// standby tells if the controller merely stands by - e.g. while another
+// replica holds the leader election lease. Standing by, it ignores the pods'
+// events, rather than queueing requests it would replay - stale - once it
+// takes over.
+type standby struct {
+	lock         sync.RWMutex
+	isStandingBy bool
+}
+
+func (s *standby) standingBy() bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.isStandingBy
+}
+
+// takeOver stops standing by, returning true when the controller was.
+func (s *standby) takeOver() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	wasStandingBy := s.isStandingBy
+	s.isStandingBy = false
+	return wasStandingBy
+}
+
+// catchUp issues the requests the pods' events ignored while standing by
+// called for: the terminating pods are finalized, and every other pod
+// reconciled - its PodNetworkAttachment, if any, being applied. The
+// attachments of the pods deleted in the meantime are only removed when the
+// pod finalizer is enabled.
+func (pnc *PodNetworksController) catchUp() {
+	pods, err := pnc.podsLister.List(pnc.podSelector)
+	if err != nil {
+		klog.ErrorS(err, "failed to list the pods to catch up on")
+		return
+	}
+
+	klog.InfoS("catching up on the pods' networks", "pods", len(pods))
+	for _, pod := range pods {
+		if isTerminating(pod) && hasPodFinalizer(pod) {
+			pnc.enqueue(
+				&DynamicAttachmentRequest{
+					PodName:      pod.GetName(),
+					PodUID:       pod.GetUID(),
+					PodNamespace: pod.GetNamespace(),
+					Type:         finalize,
+				})
+			continue
+		}
+		if attachment, err := pnc.podNetworkAttachment(pod); err != nil || attachment != nil {
+			pnc.enqueue(
+				&DynamicAttachmentRequest{
+					PodName:      pod.GetName(),
+					PodUID:       pod.GetUID(),
+					PodNamespace: pod.GetNamespace(),
+					Type:         apply,
+				})
+		}
+		pnc.reconcilePod(pod)
+	}
+}
@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+	fakecri "github.com/maiqueb/multus-dynamic-networks-controller/pkg/cri/fake"
+	fakemultusclient "github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni/fake"
+)
+
+var _ = Describe("A controller standing by", func() {
+	var podController *PodNetworksController
+
+	podKey := annotations.NamespacedName(namespace, podName)
+
+	BeforeEach(func() {
+		pod := podSpec(podName, namespace)
+		podController = newUnstartedPodController(
+			fakecri.NewFakeRuntime(*pod),
+			fakemultusclient.NewFakeClient(),
+			WithStandby(true))
+	})
+
+	It("ignores the pods' networks updates", func() {
+		pod := podSpec(podName, namespace)
+		podController.handlePodUpdate(pod, updatePodSpec(pod, networkName))
+
+		Expect(podController.pendingRequests.has(podKey)).To(BeFalse())
+		Expect(podController.workqueue.Len()).To(BeZero())
+	})
+
+	It("catches up on the networks updates it ignored once taking over", func() {
+		pod := podSpec(podName, namespace)
+		updatedPod := updatePodSpec(pod, networkName)
+		Expect(podController.podsInformer.GetStore().Add(updatedPod)).To(Succeed())
+		podController.handlePodUpdate(pod, updatedPod)
+
+		Expect(podController.standby.takeOver()).To(BeTrue())
+		podController.catchUp()
+
+		Expect(podController.pendingRequests.peek(podKey)).NotTo(BeNil())
+		Expect(podController.pendingRequests.peek(podKey).Type).To(Equal(add))
+		Expect(podController.workqueue.Len()).To(Equal(1))
+	})
+
+	It("finalizes the pods terminated while standing by once taking over", func() {
+		pod := podSpec(podName, namespace)
+		pod.Finalizers = []string{podFinalizer}
+		pod.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+		Expect(podController.podsInformer.GetStore().Add(pod)).To(Succeed())
+
+		Expect(podController.standby.takeOver()).To(BeTrue())
+		podController.catchUp()
+
+		Expect(podController.pendingRequests.peek(podKey)).NotTo(BeNil())
+		Expect(podController.pendingRequests.peek(podKey).Type).To(Equal(finalize))
+	})
+})
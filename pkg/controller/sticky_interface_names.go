@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+)
+
+// stickyInterfaceNames returns the interface names previously picked for the
+// pod's implicitly named attachments, indexed by network; see
+// annotations.BookkeepingKeys.InterfaceNames.
+func (pnc *PodNetworksController) stickyInterfaceNames(pod *corev1.Pod) map[string]string {
+	stickyNames := map[string]string{}
+	recordedNames, wasFound := pod.Annotations[pnc.bookkeepingKeys.InterfaceNames]
+	if !wasFound {
+		return stickyNames
+	}
+	if err := json.Unmarshal([]byte(recordedNames), &stickyNames); err != nil {
+		klog.ErrorS(err, "ignoring the malformed sticky interface names", "pod", klog.KObj(pod))
+		return map[string]string{}
+	}
+	return stickyNames
+}
+
+// recordStickyInterfaceNames adds the interface names picked for the
+// implicitly named attachments to the pod's sticky interface names.
+func (pnc *PodNetworksController) recordStickyInterfaceNames(
+	ctx context.Context,
+	pod *corev1.Pod,
+	pickedNames map[string]string,
+) error {
+	stickyNames := pnc.stickyInterfaceNames(pod)
+	isUpToDate := true
+	for network, ifaceName := range pickedNames {
+		if stickyNames[network] != ifaceName {
+			stickyNames[network] = ifaceName
+			isUpToDate = false
+		}
+	}
+	if isUpToDate {
+		return nil
+	}
+
+	recordedNames, err := json.Marshal(stickyNames)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the sticky interface names: %v", err)
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{pnc.bookkeepingKeys.InterfaceNames: string(recordedNames)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to compute the sticky interface names patch: %v", err)
+	}
+	if _, err := pnc.k8sClientSet.CoreV1().Pods(pod.GetNamespace()).Patch(
+		ctx,
+		pod.GetName(),
+		types.MergePatchType,
+		patch,
+		metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to record the sticky interface names of pod %s: %v", pod.GetName(), err)
+	}
+	pod.Annotations[pnc.bookkeepingKeys.InterfaceNames] = string(recordedNames)
+	return nil
+}
+
+func stickyInterfaceNameKey(network *nadv1.NetworkSelectionElement) string {
+	return annotations.NamespacedName(network.Namespace, network.Name)
+}
@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+)
+
+// sysctlsCNIArg is the cni-args key holding the sysctls - indexed by their
+// name - to apply in the pod's network namespace once the interface is added;
+// e.g. `"cni-args": {"sysctls": {"net.ipv6.conf.net1.disable_ipv6": "1"}}`.
+const sysctlsCNIArg = "sysctls"
+
+// applySysctls applies the sysctls requested by the attachment's cni-args in
+// the pod's network namespace.
+func (pnc *PodNetworksController) applySysctls(
+	ctx context.Context,
+	dynamicAttachmentRequest *DynamicAttachmentRequest,
+	pod *corev1.Pod,
+	network *nadv1.NetworkSelectionElement,
+) error {
+	sysctls, err := requestedSysctls(network)
+	if err != nil {
+		return newTerminalError(err)
+	}
+	if len(sysctls) == 0 || pnc.dryRun {
+		return nil
+	}
+
+	netnsPath, _, err := pnc.attachmentSandbox(dynamicAttachmentRequest, pod, network)
+	if err != nil {
+		return err
+	}
+	klog.FromContext(ctx).Info("applying sysctls", "interface", network.InterfaceRequest, "sysctls", sysctls)
+	if err := pnc.sysctlSetter.Set(netnsPath, sysctls); err != nil {
+		return fmt.Errorf("failed to apply the sysctls of interface %s: %v", network.InterfaceRequest, err)
+	}
+	return nil
+}
+
+// requestedSysctls reads the sysctls from the attachment's cni-args; only the
+// sysctls of the attached interface - under `net.ipv4.conf.<iface>` or
+// `net.ipv6.conf.<iface>` - are allowed.
+func requestedSysctls(network *nadv1.NetworkSelectionElement) (map[string]string, error) {
+	if network.CNIArgs == nil {
+		return nil, nil
+	}
+	rawSysctls, wasFound := (*network.CNIArgs)[sysctlsCNIArg]
+	if !wasFound {
+		return nil, nil
+	}
+	sysctlsArg, isMap := rawSysctls.(map[string]interface{})
+	if !isMap {
+		return nil, fmt.Errorf("the %q cni-arg must map the sysctl names to their values", sysctlsCNIArg)
+	}
+
+	sysctls := make(map[string]string, len(sysctlsArg))
+	for name, value := range sysctlsArg {
+		if err := validateInterfaceSysctl(name, network.InterfaceRequest); err != nil {
+			return nil, err
+		}
+		switch value := value.(type) {
+		case string:
+			sysctls[name] = value
+		case float64:
+			sysctls[name] = fmt.Sprint(value)
+		default:
+			return nil, fmt.Errorf("the value of sysctl %s must be a string or a number", name)
+		}
+	}
+	return sysctls, nil
+}
+
+// validateInterfaceSysctl ensures the sysctl - once resolved to its path under
+// /proc/sys - is a setting of the given interface.
+func validateInterfaceSysctl(name string, ifaceName string) error {
+	if strings.Contains(name, "..") {
+		return fmt.Errorf("sysctl %s must not traverse its path", name)
+	}
+	sysctlPath := filepath.Clean(filepath.Join("/proc/sys", sysctlRelativePath(name)))
+	if !strings.HasPrefix(sysctlPath, "/proc/sys/net/") {
+		return fmt.Errorf("sysctl %s is not a network sysctl", name)
+	}
+	for _, ipFamily := range []string{"ipv4", "ipv6"} {
+		ifaceSysctls := filepath.Join("/proc/sys/net", ipFamily, "conf", ifaceName)
+		if filepath.Dir(sysctlPath) == ifaceSysctls {
+			return nil
+		}
+	}
+	return fmt.Errorf("sysctl %s is not a setting of interface %s", name, ifaceName)
+}
+
+// sysctlRelativePath resolves the sysctl name the way the sysctl library does:
+// when the name uses dots as separators, its slashes stand for dots - e.g.
+// `net.ipv4.conf.eth0/100.arp_notify` is `net/ipv4/conf/eth0.100/arp_notify`.
+func sysctlRelativePath(name string) string {
+	separator := strings.IndexAny(name, "./")
+	if separator == -1 || name[separator] == '/' {
+		return name
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.':
+			return '/'
+		case '/':
+			return '.'
+		}
+		return r
+	}, name)
+}
@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/client-go/tools/record"
+
+	nad "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni"
+	fakemultusclient "github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni/fake"
+	fakesysctl "github.com/maiqueb/multus-dynamic-networks-controller/pkg/sysctl/fake"
+)
+
+var _ = Describe("The sysctls of hotplugged interfaces", func() {
+	var (
+		multusClient  *fakemultusclient.Client
+		podController *PodNetworksController
+		eventRecorder *record.FakeRecorder
+	)
+
+	sysctlsRequest := func() *DynamicAttachmentRequest {
+		return &DynamicAttachmentRequest{
+			PodName:      podName,
+			PodNamespace: namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{
+				{
+					Name:             networkName,
+					Namespace:        namespace,
+					InterfaceRequest: "net1",
+					CNIArgs: &map[string]interface{}{
+						sysctlsCNIArg: map[string]interface{}{
+							"net.ipv6.conf.net1.disable_ipv6": "1",
+							"net.ipv4.conf.net1.arp_notify":   float64(1),
+						},
+					},
+				},
+			},
+			Type:     add,
+			PodNetNS: netnsPath,
+		}
+	}
+
+	BeforeEach(func() {
+		const maxEvents = 5
+		multusClient = fakemultusclient.NewFakeClient(
+			networkConfig(multuscni.CmdAdd, "net1", networkName, macAddr),
+			networkConfig(multuscni.CmdDel, "net1", "", ""))
+		podController = newSynchedPodController(
+			podSpec(podName, namespace),
+			multusClient,
+			tinyNetAttachDef())
+		eventRecorder = record.NewFakeRecorder(maxEvents)
+		podController.recorder = eventRecorder
+	})
+
+	It("are applied in the pod's network namespace once the interface is added", func() {
+		sysctlSetter := fakesysctl.NewFakeSetter(nil)
+		WithSysctlSetter(sysctlSetter)(podController)
+
+		Expect(podController.handleDynamicInterfaceRequest(context.Background(), sysctlsRequest())).To(Succeed())
+		Expect(sysctlSetter.Sysctls(netnsPath)).To(Equal(map[string]string{
+			"net.ipv6.conf.net1.disable_ipv6": "1",
+			"net.ipv4.conf.net1.arp_notify":   "1",
+		}))
+		Expect(eventRecorder.Events).To(Receive(HavePrefix("Normal AddedInterface")))
+	})
+
+	It("roll the added interface back when they cannot be applied", func() {
+		WithSysctlSetter(fakesysctl.NewFakeSetter(errors.New("read-only file system")))(podController)
+
+		Expect(podController.handleDynamicInterfaceRequest(context.Background(), sysctlsRequest())).To(
+			MatchError(ContainSubstring("read-only file system")))
+		var commands []string
+		for _, request := range multusClient.Requests() {
+			commands = append(commands, request.Env["CNI_COMMAND"])
+		}
+		Expect(commands).To(Equal([]string{multuscni.CmdAdd, multuscni.CmdDel}))
+		Expect(eventRecorder.Events).To(Receive(HavePrefix("Warning AddInterfaceFailed")))
+	})
+
+	It("must be network sysctls", func() {
+		_, err := requestedSysctls(&nad.NetworkSelectionElement{
+			CNIArgs: &map[string]interface{}{sysctlsCNIArg: map[string]interface{}{"kernel.panic": "1"}},
+		})
+		Expect(err).To(MatchError("sysctl kernel.panic is not a network sysctl"))
+	})
+
+	It("must not traverse out of the interface's sysctls", func() {
+		_, err := requestedSysctls(&nad.NetworkSelectionElement{
+			InterfaceRequest: "net1",
+			CNIArgs: &map[string]interface{}{
+				sysctlsCNIArg: map[string]interface{}{"net/ipv4/conf/net1/../../../../kernel/panic": "1"},
+			},
+		})
+		Expect(err).To(MatchError("sysctl net/ipv4/conf/net1/../../../../kernel/panic must not traverse its path"))
+	})
+
+	It("must be settings of the attached interface", func() {
+		_, err := requestedSysctls(&nad.NetworkSelectionElement{
+			InterfaceRequest: "net1",
+			CNIArgs: &map[string]interface{}{
+				sysctlsCNIArg: map[string]interface{}{"net.ipv4.conf.eth0.arp_notify": "1"},
+			},
+		})
+		Expect(err).To(MatchError("sysctl net.ipv4.conf.eth0.arp_notify is not a setting of interface net1"))
+	})
+
+	It("accept the settings of interfaces whose name features dots", func() {
+		Expect(requestedSysctls(&nad.NetworkSelectionElement{
+			InterfaceRequest: "eth0.100",
+			CNIArgs: &map[string]interface{}{
+				sysctlsCNIArg: map[string]interface{}{
+					"net.ipv4.conf.eth0/100.arp_notify":   "1",
+					"net/ipv6/conf/eth0.100/disable_ipv6": "1",
+				},
+			},
+		})).To(HaveLen(2))
+	})
+})
@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+)
+
+// targetContainerCNIArg is the cni-args key naming the container whose network
+// namespace the attachment targets - e.g. `"cni-args": {"container": "sidecar"}`;
+// it is only relevant for the pods whose containers do not share the sandbox's
+// network namespace. The sandbox's network namespace is targeted when unset.
+const targetContainerCNIArg = "container"
+
+// attachmentSandbox returns the network namespace - and the ID of the container
+// owning it - the attachment is plugged into: the one of the container named
+// in the attachment's cni-args, or the request's - i.e. the pod's sandbox - by
+// default.
+func (pnc *PodNetworksController) attachmentSandbox(
+	dynamicAttachmentRequest *DynamicAttachmentRequest,
+	pod *corev1.Pod,
+	network *nadv1.NetworkSelectionElement,
+) (string, string, error) {
+	containerName, err := targetContainer(network)
+	if err != nil {
+		return "", "", newTerminalError(err)
+	}
+	if containerName == "" {
+		containerID, err := podContainerID(pod)
+		if err != nil {
+			return "", "", err
+		}
+		return dynamicAttachmentRequest.PodNetNS, containerID, nil
+	}
+
+	containerID, err := runningContainerID(pod, containerName)
+	if err != nil {
+		return "", "", err
+	}
+	netnsPath, err := pnc.containerRuntime.NetNS(containerID)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get netns for container %s [%s]: %w", containerName, containerID, err)
+	}
+	return netnsPath, containerID, nil
+}
+
+// targetContainer returns the name of the container the attachment targets, or
+// an empty string when it targets the pod's sandbox.
+func targetContainer(network *nadv1.NetworkSelectionElement) (string, error) {
+	if network.CNIArgs == nil {
+		return "", nil
+	}
+	rawContainerName, wasFound := (*network.CNIArgs)[targetContainerCNIArg]
+	if !wasFound {
+		return "", nil
+	}
+	containerName, isString := rawContainerName.(string)
+	if !isString || containerName == "" {
+		return "", fmt.Errorf("the %q cni-arg must be a container name", targetContainerCNIArg)
+	}
+	return containerName, nil
+}
+
+// runningContainerID returns the ID of the pod's named container, which must be
+// running.
+func runningContainerID(pod *corev1.Pod, containerName string) (string, error) {
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if containerStatus.Name != containerName {
+			continue
+		}
+		if containerStatus.State.Running == nil || containerStatus.ContainerID == "" {
+			return "", fmt.Errorf(
+				"container %s of pod %s is not running",
+				containerName,
+				annotations.NamespacedName(pod.GetNamespace(), pod.GetName()))
+		}
+		return parseContainerID(containerStatus.ContainerID)
+	}
+	return "", fmt.Errorf(
+		"pod %s does not feature container %s",
+		annotations.NamespacedName(pod.GetNamespace(), pod.GetName()),
+		containerName)
+}
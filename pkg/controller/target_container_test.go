@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/client-go/tools/record"
+
+	nad "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	fakecri "github.com/maiqueb/multus-dynamic-networks-controller/pkg/cri/fake"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni"
+	fakemultusclient "github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni/fake"
+)
+
+var _ = Describe("Container targeted attachments", func() {
+	const (
+		sidecarID        = "sidecar-id"
+		sidecarNetnsPath = "/var/run/netns/sidecar"
+	)
+
+	var (
+		multusClient  *fakemultusclient.Client
+		podController *PodNetworksController
+	)
+
+	containerTargetedRequest := func(containerName string) *DynamicAttachmentRequest {
+		return &DynamicAttachmentRequest{
+			PodName:      podName,
+			PodNamespace: namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{{
+				Name:             networkName,
+				Namespace:        namespace,
+				InterfaceRequest: "net1",
+				CNIArgs:          &map[string]interface{}{targetContainerCNIArg: containerName},
+			}},
+			Type:     add,
+			PodNetNS: netnsPath,
+		}
+	}
+
+	BeforeEach(func() {
+		pod := podSpec(podName, namespace)
+		sidecar := runningContainer("containerd://" + sidecarID)
+		sidecar.Name = "sidecar"
+		pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, sidecar)
+
+		multusClient = fakemultusclient.NewFakeClient(networkConfig(multuscni.CmdAdd, "net1", networkName, macAddr))
+		podController = newSynchedPodController(
+			pod,
+			multusClient,
+			tinyNetAttachDef())
+		podController.containerRuntime = fakecri.NewFakeRuntime(*pod).WithContainer(sidecarID, sidecarNetnsPath)
+		podController.recorder = record.NewFakeRecorder(5)
+	})
+
+	It("are plugged into the network namespace of the targeted container", func() {
+		Expect(podController.handleDynamicInterfaceRequest(context.Background(), containerTargetedRequest("sidecar"))).To(Succeed())
+
+		Expect(multusClient.Requests()).To(HaveLen(1))
+		Expect(multusClient.Requests()[0].Env).To(HaveKeyWithValue("CNI_NETNS", sidecarNetnsPath))
+		Expect(multusClient.Requests()[0].Env).To(HaveKeyWithValue("CNI_CONTAINERID", sidecarID))
+	})
+
+	It("fail when the pod does not feature the targeted container", func() {
+		Expect(podController.handleDynamicInterfaceRequest(context.Background(), containerTargetedRequest("ghost"))).To(
+			MatchError(ContainSubstring("pod default/tiny-winy-pod does not feature container ghost")))
+		Expect(multusClient.Requests()).To(BeEmpty())
+	})
+})
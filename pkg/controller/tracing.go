@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/maiqueb/multus-dynamic-networks-controller/pkg/controller"
+
+// spanAttributes identify the request, and the pod it refers to, in its spans.
+func (dar *DynamicAttachmentRequest) spanAttributes() []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("request.id", dar.ID),
+		attribute.String("request.type", string(dar.Type)),
+		attribute.String("pod", dar.PodName),
+		attribute.String("namespace", dar.PodNamespace),
+	}
+}
+
+// endSpan ends the span, recording the error - if any - the traced operation
+// failed with.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// operationResult describes the outcome of a traced operation.
+func operationResult(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
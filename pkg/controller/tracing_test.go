@@ -0,0 +1,58 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	nad "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/annotations"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni"
+	fakemultusclient "github.com/maiqueb/multus-dynamic-networks-controller/pkg/multuscni/fake"
+)
+
+var _ = Describe("Tracing", func() {
+	It("records the spans of a successful add, down to the delegate invocation", func() {
+		spanRecorder := tracetest.NewSpanRecorder()
+		pod := podSpec(podName, namespace)
+		podController := newSynchedPodController(
+			pod,
+			fakemultusclient.NewFakeClient(networkConfig(multuscni.CmdAdd, "net1", "net1", macAddr)),
+			tinyNetAttachDef())
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))(podController)
+
+		podController.enqueue(&DynamicAttachmentRequest{
+			PodName:         podName,
+			PodNamespace:    namespace,
+			AttachmentNames: []*nad.NetworkSelectionElement{{Name: networkName, Namespace: namespace, InterfaceRequest: "net1"}},
+			Type:            add,
+			PodNetNS:        netnsPath,
+		})
+		Expect(podController.processNextWorkItem()).To(BeTrue())
+
+		spans := map[string]sdktrace.ReadOnlySpan{}
+		for _, span := range spanRecorder.Ended() {
+			spans[span.Name()] = span
+		}
+		Expect(spans).To(HaveLen(3))
+		Expect(spans).To(HaveKey("ProcessDynamicAttachmentRequest"))
+		Expect(spans["ProcessDynamicAttachmentRequest"].Attributes()).To(ContainElements(
+			attribute.String("pod", podName),
+			attribute.String("namespace", namespace),
+			attribute.String("request.type", string(add))))
+		Expect(spans).To(HaveKey("HandleDynamicAttachmentRequest"))
+		Expect(spans["HandleDynamicAttachmentRequest"].Parent().SpanID()).To(
+			Equal(spans["ProcessDynamicAttachmentRequest"].SpanContext().SpanID()))
+		Expect(spans).To(HaveKey("InvokeDelegate"))
+		Expect(spans["InvokeDelegate"].Parent().SpanID()).To(
+			Equal(spans["HandleDynamicAttachmentRequest"].SpanContext().SpanID()))
+		Expect(spans["InvokeDelegate"].Attributes()).To(ContainElements(
+			attribute.String("command", multuscni.CmdAdd),
+			attribute.String("nad", annotations.NamespacedName(namespace, networkName)),
+			attribute.String("interface", "net1"),
+			attribute.String("result", "success")))
+	})
+})
@@ -8,4 +8,5 @@ import (
 
 type Client interface {
 	LoadContainer(ctx context.Context, id string) (containerd.Container, error)
+	Version(ctx context.Context) (containerd.Version, error)
 }
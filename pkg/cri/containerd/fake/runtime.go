@@ -13,7 +13,8 @@ import (
 )
 
 type Client struct {
-	cache map[string]containerd.Container
+	cache   map[string]containerd.Container
+	version string
 }
 
 type ClientOpt func(client *Client)
@@ -32,6 +33,19 @@ func WithCachedContainer(containerID string, container containerd.Container) Cli
 	}
 }
 
+func WithVersion(version string) ClientOpt {
+	return func(client *Client) {
+		client.version = version
+	}
+}
+
+func (c Client) Version(context.Context) (containerd.Version, error) {
+	if c.version == "" {
+		return containerd.Version{}, fmt.Errorf("version not available")
+	}
+	return containerd.Version{Version: c.version}, nil
+}
+
 func (c Client) LoadContainer(_ context.Context, id string) (containerd.Container, error) {
 	container, wasFound := c.cache[id]
 	if wasFound {
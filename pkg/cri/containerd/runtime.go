@@ -12,7 +12,10 @@ import (
 	"github.com/opencontainers/runtime-spec/specs-go"
 )
 
-const k8sNamespace = "k8s.io"
+const (
+	k8sNamespace = "k8s.io"
+	runtimeName  = "containerd"
+)
 
 // Runtime represents a connection to the containerd runtime
 type Runtime struct {
@@ -63,6 +66,15 @@ func (cd *Runtime) NetNS(containerID string) (string, error) {
 	return "", fmt.Errorf("could not find netns for container ID: %s", containerID)
 }
 
+// Version returns the name and version of the containerd runtime
+func (cd *Runtime) Version() (string, string, error) {
+	version, err := cd.containerRuntime.Version(cd.namespacedContext)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to query the containerd version: %w", err)
+	}
+	return runtimeName, version.Version, nil
+}
+
 func (cd *Runtime) containerSpec(containerID string) (*oci.Spec, error) {
 	container, err := cd.containerRuntime.LoadContainer(cd.namespacedContext, containerID)
 	if err != nil {
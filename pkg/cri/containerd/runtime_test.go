@@ -89,6 +89,21 @@ var _ = Describe("Containerd runtime", func() {
 			Expect(err).To(MatchError(expectedErrorString))
 		})
 	})
+
+	When("the runtime reports its version", func() {
+		const version = "v1.6.8"
+
+		BeforeEach(func() {
+			runtime = newContainerdRuntime(newDummyContainerdRuntime(fake.WithVersion(version)))
+		})
+
+		It("the runtime name and version are read when queried", func() {
+			name, reportedVersion, err := runtime.Version()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(name).To(Equal("containerd"))
+			Expect(reportedVersion).To(Equal(version))
+		})
+	})
 })
 
 func newDummyContainerdRuntime(opts ...fake.ClientOpt) Client {
@@ -0,0 +1,100 @@
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/grpc"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+type Client struct {
+	containers map[string]string
+	sandboxes  map[string]*specs.Spec
+	version    string
+}
+
+type ClientOpt func(client *Client)
+
+func NewClient(opts ...ClientOpt) *Client {
+	client := &Client{containers: map[string]string{}, sandboxes: map[string]*specs.Spec{}}
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client
+}
+
+// WithSandboxedContainer features the container, running in the pod sandbox
+// whose spec is given.
+func WithSandboxedContainer(containerID string, sandboxID string, sandboxSpec *specs.Spec) ClientOpt {
+	return func(client *Client) {
+		client.containers[containerID] = sandboxID
+		client.sandboxes[sandboxID] = sandboxSpec
+	}
+}
+
+func WithVersion(version string) ClientOpt {
+	return func(client *Client) {
+		client.version = version
+	}
+}
+
+func NewSandboxSpec(netnsPath string) *specs.Spec {
+	return &specs.Spec{
+		Linux: &specs.Linux{
+			Namespaces: []specs.LinuxNamespace{{Type: specs.NetworkNamespace, Path: netnsPath}},
+		},
+	}
+}
+
+func NewSandboxSpecWithoutNetworkNamespace() *specs.Spec {
+	return &specs.Spec{Linux: &specs.Linux{}}
+}
+
+func NewNonLinuxSandboxSpec() *specs.Spec {
+	return &specs.Spec{}
+}
+
+func (c Client) ListContainers(
+	_ context.Context,
+	in *runtimeapi.ListContainersRequest,
+	_ ...grpc.CallOption,
+) (*runtimeapi.ListContainersResponse, error) {
+	sandboxID, wasFound := c.containers[in.GetFilter().GetId()]
+	if !wasFound {
+		return &runtimeapi.ListContainersResponse{}, nil
+	}
+	return &runtimeapi.ListContainersResponse{
+		Containers: []*runtimeapi.Container{{Id: in.GetFilter().GetId(), PodSandboxId: sandboxID}},
+	}, nil
+}
+
+func (c Client) PodSandboxStatus(
+	_ context.Context,
+	in *runtimeapi.PodSandboxStatusRequest,
+	_ ...grpc.CallOption,
+) (*runtimeapi.PodSandboxStatusResponse, error) {
+	sandboxSpec, wasFound := c.sandboxes[in.GetPodSandboxId()]
+	if !wasFound {
+		return nil, fmt.Errorf("pod sandbox not found: %s", in.GetPodSandboxId())
+	}
+	info, err := json.Marshal(map[string]interface{}{"runtimeSpec": sandboxSpec})
+	if err != nil {
+		return nil, err
+	}
+	return &runtimeapi.PodSandboxStatusResponse{
+		Status: &runtimeapi.PodSandboxStatus{Id: in.GetPodSandboxId()},
+		Info:   map[string]string{"info": string(info)},
+	}, nil
+}
+
+func (c Client) Version(context.Context, *runtimeapi.VersionRequest, ...grpc.CallOption) (*runtimeapi.VersionResponse, error) {
+	if c.version == "" {
+		return nil, fmt.Errorf("version not available")
+	}
+	return &runtimeapi.VersionResponse{RuntimeName: "cri-o", RuntimeVersion: c.version}, nil
+}
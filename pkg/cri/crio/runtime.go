@@ -0,0 +1,117 @@
+package crio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// sandboxInfoKey indexes the verbose information of the pod sandbox status
+const sandboxInfoKey = "info"
+
+// Runtime represents a connection to the CRI-O runtime, over the CRI runtime
+// service.
+type Runtime struct {
+	client  Client
+	timeout time.Duration
+}
+
+// sandboxInfo is the verbose information CRI-O reports in the pod sandbox
+// status; the spec of the sandbox features the path of its network namespace.
+type sandboxInfo struct {
+	RuntimeSpec *specs.Spec `json:"runtimeSpec"`
+}
+
+// NewCrioRuntime connects to the CRI-O runtime over the specified `socketPath`
+func NewCrioRuntime(socketPath string, timeout time.Duration) (*Runtime, error) {
+	conn, err := grpc.Dial("unix://"+socketPath, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CRI-O client: %w", err)
+	}
+
+	return newCrioRuntime(runtimeapi.NewRuntimeServiceClient(conn), timeout), nil
+}
+
+func newCrioRuntime(client Client, timeout time.Duration) *Runtime {
+	return &Runtime{
+		client:  client,
+		timeout: timeout,
+	}
+}
+
+// NetNS returns the netns path of a given container - i.e. the one of its pod
+// sandbox
+func (cr *Runtime) NetNS(containerID string) (string, error) {
+	if containerID == "" {
+		return "", fmt.Errorf("ID cannot be empty")
+	}
+
+	sandboxID, err := cr.sandboxID(containerID)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := cr.context()
+	defer cancel()
+	status, err := cr.client.PodSandboxStatus(ctx, &runtimeapi.PodSandboxStatusRequest{PodSandboxId: sandboxID, Verbose: true})
+	if err != nil {
+		return "", fmt.Errorf("failed to query the status of pod sandbox %s: %w", sandboxID, err)
+	}
+	var info sandboxInfo
+	if err := json.Unmarshal([]byte(status.GetInfo()[sandboxInfoKey]), &info); err != nil {
+		return "", fmt.Errorf("failed to read the status of pod sandbox %s: %w", sandboxID, err)
+	}
+
+	if info.RuntimeSpec == nil || info.RuntimeSpec.Linux == nil {
+		return "", fmt.Errorf("container does not feature platform-specific configuration for Linux based containers")
+	}
+
+	for _, ns := range info.RuntimeSpec.Linux.Namespaces {
+		if ns.Type == specs.NetworkNamespace && ns.Path != "" {
+			return ns.Path, nil
+		}
+	}
+	return "", fmt.Errorf("could not find netns for container ID: %s", containerID)
+}
+
+// Version returns the name and version of the CRI-O runtime
+func (cr *Runtime) Version() (string, string, error) {
+	ctx, cancel := cr.context()
+	defer cancel()
+	version, err := cr.client.Version(ctx, &runtimeapi.VersionRequest{})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to query the CRI-O version: %w", err)
+	}
+	return version.GetRuntimeName(), version.GetRuntimeVersion(), nil
+}
+
+func (cr *Runtime) sandboxID(containerID string) (string, error) {
+	ctx, cancel := cr.context()
+	defer cancel()
+	containers, err := cr.client.ListContainers(ctx, &runtimeapi.ListContainersRequest{
+		Filter: &runtimeapi.ContainerFilter{Id: containerID},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to query container %s: %w", containerID, err)
+	}
+	if len(containers.GetContainers()) == 0 {
+		return "", fmt.Errorf("container not found: %s", containerID)
+	}
+	return containers.GetContainers()[0].GetPodSandboxId(), nil
+}
+
+// context bounds each query of the runtime by the timeout - if any.
+func (cr *Runtime) context() (context.Context, context.CancelFunc) {
+	if cr.timeout == 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), cr.timeout)
+}
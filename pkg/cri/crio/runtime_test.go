@@ -0,0 +1,106 @@
+package crio
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/cri/crio/fake"
+)
+
+func TestController(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CRI-O runtime suite")
+}
+
+var _ = Describe("CRI-O runtime", func() {
+	const withoutTimeout = 0
+
+	var runtime *Runtime
+
+	When("a live container is provisioned in the runtime", func() {
+		const (
+			containerID = "1234"
+			sandboxID   = "5678"
+			netnsPath   = "/var/run/netns/over-there"
+		)
+
+		BeforeEach(func() {
+			runtime = newCrioRuntime(
+				fake.NewClient(fake.WithSandboxedContainer(containerID, sandboxID, fake.NewSandboxSpec(netnsPath))),
+				withoutTimeout)
+		})
+
+		It("the network namespace of its pod sandbox is read when queried", func() {
+			Expect(runtime.NetNS(containerID)).To(Equal(netnsPath))
+		})
+
+		It("cannot query when given an empty container ID", func() {
+			const emptyID = ""
+			_, err := runtime.NetNS(emptyID)
+			Expect(err).To(MatchError("ID cannot be empty"))
+		})
+	})
+
+	When("the runtime *does not* feature any containers", func() {
+		BeforeEach(func() {
+			runtime = newCrioRuntime(fake.NewClient(), withoutTimeout)
+		})
+
+		It("cannot extract the network namespace of a container", func() {
+			const wrongContainerID = "no-go"
+
+			_, err := runtime.NetNS(wrongContainerID)
+			Expect(err).To(MatchError(fmt.Sprintf("container not found: %s", wrongContainerID)))
+		})
+	})
+
+	When("the runtime features a non-linux pod sandbox", func() {
+		const containerID = "1234"
+
+		BeforeEach(func() {
+			runtime = newCrioRuntime(
+				fake.NewClient(fake.WithSandboxedContainer(containerID, "5678", fake.NewNonLinuxSandboxSpec())),
+				withoutTimeout)
+		})
+
+		It("the runtime cannot access the net namespace", func() {
+			_, err := runtime.NetNS(containerID)
+			Expect(err).To(
+				MatchError(
+					"container does not feature platform-specific configuration for Linux based containers"))
+		})
+	})
+
+	When("the runtime features a pod sandbox without network namespace", func() {
+		const containerID = "1234"
+
+		BeforeEach(func() {
+			runtime = newCrioRuntime(
+				fake.NewClient(fake.WithSandboxedContainer(containerID, "5678", fake.NewSandboxSpecWithoutNetworkNamespace())),
+				withoutTimeout)
+		})
+
+		It("the runtime cannot access the net namespace", func() {
+			_, err := runtime.NetNS(containerID)
+			Expect(err).To(MatchError(fmt.Sprintf("could not find netns for container ID: %s", containerID)))
+		})
+	})
+
+	When("the runtime reports its version", func() {
+		const version = "1.24.2"
+
+		BeforeEach(func() {
+			runtime = newCrioRuntime(fake.NewClient(fake.WithVersion(version)), withoutTimeout)
+		})
+
+		It("the runtime name and version are read when queried", func() {
+			name, reportedVersion, err := runtime.Version()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(name).To(Equal("cri-o"))
+			Expect(reportedVersion).To(Equal(version))
+		})
+	})
+})
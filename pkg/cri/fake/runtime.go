@@ -8,6 +8,11 @@ import (
 	v1 "k8s.io/api/core/v1"
 )
 
+const (
+	RuntimeName    = "fake"
+	RuntimeVersion = "v0.0.1"
+)
+
 type Runtime struct {
 	cache map[string]string
 }
@@ -22,6 +27,16 @@ func NewFakeRuntime(pods ...v1.Pod) *Runtime {
 	return &Runtime{cache: runtimeCache}
 }
 
+// WithContainer makes the runtime resolve the network namespace of the container.
+func (r *Runtime) WithContainer(containerID string, netnsPath string) *Runtime {
+	r.cache[containerID] = netnsPath
+	return r
+}
+
+func (r *Runtime) Version() (string, string, error) {
+	return RuntimeName, RuntimeVersion, nil
+}
+
 func (r *Runtime) NetNS(containerID string) (string, error) {
 	if netnsName, wasFound := r.cache[containerID]; wasFound {
 		return netnsName, nil
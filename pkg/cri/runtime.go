@@ -0,0 +1,89 @@
+package cri
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/cri/containerd"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/cri/crio"
+)
+
+// NewRuntime connects to the container runtime listening on `socketPath`. The
+// runtime type is detected from the socket path when not specified.
+func NewRuntime(socketPath string, runtimeType RuntimeType, timeout time.Duration) (ContainerRuntime, error) {
+	if socketPath == "" {
+		return nil, fmt.Errorf("the CRI socket path cannot be empty")
+	}
+	if runtimeType == "" {
+		detectedRuntimeType, err := detectRuntimeType(socketPath)
+		if err != nil {
+			return nil, err
+		}
+		runtimeType = detectedRuntimeType
+	}
+
+	switch runtimeType {
+	case Containerd:
+		return containerd.NewContainerdRuntime(socketPath, timeout)
+	case Crio:
+		return crio.NewCrioRuntime(socketPath, timeout)
+	default:
+		return nil, fmt.Errorf("unknown runtime type: %s", runtimeType)
+	}
+}
+
+// NewRuntimeFromSockets connects to the first container runtime answering on
+// the sockets, tried in order - e.g. `/run/containerd/containerd.sock`, then
+// `/var/run/crio/crio.sock` -, returning the socket it answered on; thus a
+// single configuration serves the nodes running different runtimes. The
+// runtime type is detected from each socket path, and the missing sockets are
+// skipped.
+func NewRuntimeFromSockets(socketPaths []string, timeout time.Duration) (ContainerRuntime, string, error) {
+	return firstAnsweringRuntime(socketPaths, func(socketPath string) (ContainerRuntime, error) {
+		if _, err := os.Stat(socketPath); err != nil {
+			return nil, err
+		}
+		return NewRuntime(socketPath, "", timeout)
+	})
+}
+
+// firstAnsweringRuntime returns the first runtime - created by `newRuntime` -
+// whose version can be queried, along with its socket path.
+func firstAnsweringRuntime(
+	socketPaths []string,
+	newRuntime func(socketPath string) (ContainerRuntime, error),
+) (ContainerRuntime, string, error) {
+	if len(socketPaths) == 0 {
+		return nil, "", fmt.Errorf("the CRI socket paths cannot be empty")
+	}
+	var failures []string
+	for _, socketPath := range socketPaths {
+		runtime, err := newRuntime(socketPath)
+		if err == nil {
+			_, _, err = runtime.Version()
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", socketPath, err))
+			continue
+		}
+		return runtime, socketPath, nil
+	}
+	return nil, "", fmt.Errorf("no container runtime answered on any socket: %s", strings.Join(failures, "; "))
+}
+
+// detectRuntimeType infers the runtime type from the path of its socket - e.g.
+// `/run/containerd/containerd.sock` or `/var/run/crio/crio.sock`.
+func detectRuntimeType(socketPath string) (RuntimeType, error) {
+	socketName := strings.TrimSuffix(filepath.Base(socketPath), filepath.Ext(socketPath))
+	switch socketName {
+	case string(Containerd):
+		return Containerd, nil
+	case string(Crio):
+		return Crio, nil
+	default:
+		return "", fmt.Errorf("cannot detect the runtime type listening on %s: specify it explicitly", socketPath)
+	}
+}
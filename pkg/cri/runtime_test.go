@@ -0,0 +1,120 @@
+package cri
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/cri/crio"
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/cri/fake"
+)
+
+func TestRuntime(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "container runtime suite")
+}
+
+var _ = Describe("The container runtime factory", func() {
+	const withoutTimeout = 0
+
+	It("detects containerd from its socket path", func() {
+		Expect(detectRuntimeType("/run/containerd/containerd.sock")).To(Equal(Containerd))
+		Expect(detectRuntimeType("/run/k3s/containerd/containerd.sock")).To(Equal(Containerd))
+	})
+
+	It("detects CRI-O from its socket path", func() {
+		Expect(detectRuntimeType("/var/run/crio/crio.sock")).To(Equal(Crio))
+	})
+
+	It("connects to CRI-O over the CRI", func() {
+		Expect(NewRuntime("/var/run/crio/crio.sock", "", withoutTimeout)).To(BeAssignableToTypeOf(&crio.Runtime{}))
+	})
+
+	It("fails to detect the runtime from unknown socket paths", func() {
+		_, err := NewRuntime("/var/run/runtime.sock", "", withoutTimeout)
+		Expect(err).To(MatchError(ContainSubstring("cannot detect the runtime type")))
+	})
+
+	It("fails without a socket path", func() {
+		_, err := NewRuntime("", Containerd, withoutTimeout)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("fails for unknown runtime types", func() {
+		_, err := NewRuntime("/run/containerd/containerd.sock", "docker", withoutTimeout)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("The container runtime fallback", func() {
+	const withoutTimeout = 0
+
+	var attemptedSockets []string
+
+	// newRuntime fakes the runtimes answering on the `answeringSockets`, and
+	// the ones failing to answer on any other socket
+	newRuntime := func(answeringSockets ...string) func(string) (ContainerRuntime, error) {
+		attemptedSockets = nil
+		return func(socketPath string) (ContainerRuntime, error) {
+			attemptedSockets = append(attemptedSockets, socketPath)
+			for _, answeringSocket := range answeringSockets {
+				if socketPath == answeringSocket {
+					return fake.NewFakeRuntime(), nil
+				}
+			}
+			return &unresponsiveRuntime{}, nil
+		}
+	}
+
+	It("uses the first socket a runtime answers on", func() {
+		runtime, socketPath, err := firstAnsweringRuntime(
+			[]string{"/run/containerd/containerd.sock", "/var/run/crio/crio.sock"},
+			newRuntime("/run/containerd/containerd.sock", "/var/run/crio/crio.sock"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(runtime).NotTo(BeNil())
+		Expect(socketPath).To(Equal("/run/containerd/containerd.sock"))
+		Expect(attemptedSockets).To(Equal([]string{"/run/containerd/containerd.sock"}))
+	})
+
+	It("falls back to the following sockets, in order, when the runtime does not answer", func() {
+		_, socketPath, err := firstAnsweringRuntime(
+			[]string{"/run/containerd/containerd.sock", "/run/k3s/containerd/containerd.sock", "/var/run/crio/crio.sock"},
+			newRuntime("/var/run/crio/crio.sock"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(socketPath).To(Equal("/var/run/crio/crio.sock"))
+		Expect(attemptedSockets).To(Equal(
+			[]string{"/run/containerd/containerd.sock", "/run/k3s/containerd/containerd.sock", "/var/run/crio/crio.sock"}))
+	})
+
+	It("fails when no runtime answers on any socket, reporting why for each", func() {
+		_, _, err := firstAnsweringRuntime(
+			[]string{"/run/containerd/containerd.sock", "/var/run/crio/crio.sock"},
+			newRuntime())
+		Expect(err).To(MatchError(
+			"no container runtime answered on any socket: " +
+				"/run/containerd/containerd.sock: the runtime is unresponsive; " +
+				"/var/run/crio/crio.sock: the runtime is unresponsive"))
+	})
+
+	It("skips the missing sockets", func() {
+		_, _, err := NewRuntimeFromSockets([]string{"/this/socket/does/not/exist/containerd.sock"}, withoutTimeout)
+		Expect(err).To(MatchError(ContainSubstring("no such file or directory")))
+	})
+
+	It("fails without socket paths", func() {
+		_, _, err := NewRuntimeFromSockets(nil, withoutTimeout)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+type unresponsiveRuntime struct{}
+
+func (*unresponsiveRuntime) NetNS(_ string) (string, error) {
+	return "", fmt.Errorf("the runtime is unresponsive")
+}
+
+func (*unresponsiveRuntime) Version() (string, string, error) {
+	return "", "", fmt.Errorf("the runtime is unresponsive")
+}
@@ -14,4 +14,6 @@ const (
 type ContainerRuntime interface {
 	// NetNS returns the network namespace of the given containerID.
 	NetNS(containerID string) (string, error)
+	// Version returns the name and version of the container runtime.
+	Version() (name string, version string, err error)
 }
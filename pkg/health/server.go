@@ -0,0 +1,39 @@
+package health
+
+import (
+	"net/http"
+
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// LivenessEndpoint replies OK as long as the process is able to serve it
+	LivenessEndpoint = "/healthz"
+	// ReadinessEndpoint replies OK once all the readiness checks pass
+	ReadinessEndpoint = "/readyz"
+)
+
+// NewHandler returns the handler serving the liveness and readiness endpoints;
+// the readiness endpoint reports the process as ready once all the provided
+// caches are synchronized.
+func NewHandler(readinessChecks ...cache.InformerSynced) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(LivenessEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		reply(w, http.StatusOK, "ok")
+	})
+	mux.HandleFunc(ReadinessEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		for _, isReady := range readinessChecks {
+			if !isReady() {
+				reply(w, http.StatusServiceUnavailable, "caches not synchronized")
+				return
+			}
+		}
+		reply(w, http.StatusOK, "ok")
+	})
+	return mux
+}
+
+func reply(w http.ResponseWriter, statusCode int, message string) {
+	w.WriteHeader(statusCode)
+	_, _ = w.Write([]byte(message))
+}
@@ -0,0 +1,63 @@
+package health
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestHealth(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Health endpoints suite")
+}
+
+var _ = Describe("The health endpoints", func() {
+	var (
+		areCachesSynched int32
+		server           *httptest.Server
+	)
+
+	BeforeEach(func() {
+		atomic.StoreInt32(&areCachesSynched, 0)
+		server = httptest.NewServer(NewHandler(func() bool { return atomic.LoadInt32(&areCachesSynched) == 1 }))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	statusCode := func(endpoint string) int {
+		response, err := server.Client().Get(server.URL + endpoint)
+		Expect(err).NotTo(HaveOccurred())
+		defer response.Body.Close()
+		return response.StatusCode
+	}
+
+	When("the caches are not synchronized", func() {
+		It("reports the process as alive", func() {
+			Expect(statusCode(LivenessEndpoint)).To(Equal(http.StatusOK))
+		})
+
+		It("reports the process as not ready", func() {
+			Expect(statusCode(ReadinessEndpoint)).To(Equal(http.StatusServiceUnavailable))
+		})
+	})
+
+	When("the caches are synchronized", func() {
+		BeforeEach(func() {
+			atomic.StoreInt32(&areCachesSynched, 1)
+		})
+
+		It("reports the process as alive", func() {
+			Expect(statusCode(LivenessEndpoint)).To(Equal(http.StatusOK))
+		})
+
+		It("reports the process as ready", func() {
+			Expect(statusCode(ReadinessEndpoint)).To(Equal(http.StatusOK))
+		})
+	})
+})
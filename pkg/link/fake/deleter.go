@@ -0,0 +1,35 @@
+package fake
+
+import (
+	"sync"
+)
+
+// Deleter records the links deleted from each network namespace, failing with
+// the configured error - if any.
+type Deleter struct {
+	err     error
+	lock    sync.Mutex
+	deleted map[string][]string
+}
+
+func NewFakeDeleter(err error) *Deleter {
+	return &Deleter{err: err, deleted: map[string][]string{}}
+}
+
+func (d *Deleter) Delete(netnsPath string, ifaceName string) error {
+	if d.err != nil {
+		return d.err
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.deleted[netnsPath] = append(d.deleted[netnsPath], ifaceName)
+	return nil
+}
+
+// Deleted returns the links deleted from the network namespace, in order.
+func (d *Deleter) Deleted(netnsPath string) []string {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return append([]string{}, d.deleted[netnsPath]...)
+}
@@ -0,0 +1,40 @@
+package link
+
+import (
+	"fmt"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/vishvananda/netlink"
+)
+
+// Deleter deletes links from network namespaces
+type Deleter interface {
+	// Delete deletes the link named `ifaceName` from the network namespace
+	// found at `netnsPath`; deleting a link that does not exist is not an error.
+	Delete(netnsPath string, ifaceName string) error
+}
+
+// NetNSDeleter deletes the links by entering the network namespace.
+type NetNSDeleter struct{}
+
+// NewNetNSDeleter returns a Deleter entering the network namespaces
+func NewNetNSDeleter() *NetNSDeleter {
+	return &NetNSDeleter{}
+}
+
+// Delete deletes the link from the network namespace
+func (NetNSDeleter) Delete(netnsPath string, ifaceName string) error {
+	return ns.WithNetNSPath(netnsPath, func(ns.NetNS) error {
+		iface, err := netlink.LinkByName(ifaceName)
+		if _, isNotFound := err.(netlink.LinkNotFoundError); isNotFound {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to lookup link %s: %w", ifaceName, err)
+		}
+		if err := netlink.LinkDel(iface); err != nil {
+			return fmt.Errorf("failed to delete link %s: %w", ifaceName, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "dynamic_networks_controller"
+
+var (
+	// DroppedRequests counts the dynamic attachment requests given up on after exhausting their retries
+	DroppedRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "dropped_requests_total",
+			Help:      "Number of dynamic attachment requests dropped after exhausting their retries",
+		},
+		[]string{"type"},
+	)
+
+	// NetNSResolutionErrors counts the failures to resolve the pods' network namespace through the container runtime
+	NetNSResolutionErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "netns_resolution_errors_total",
+			Help:      "Number of failures to resolve the network namespace of a pod through the container runtime",
+		},
+		[]string{"runtime"},
+	)
+
+	// CRIInfo reports the name and version of the container runtime the controller connected to
+	CRIInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "cri_info",
+			Help:      "The container runtime the controller connected to; always 1, labeled by the runtime's name and version",
+		},
+		[]string{"runtime", "version"},
+	)
+
+	// E2ELatency measures the time from a networks annotation update to its
+	// interfaces being recorded in the pod's network-status
+	E2ELatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "dynamic_networks_e2e_latency_seconds",
+			Help:    "Time from a networks annotation update to the network-status reflecting it, including the time queued and the retries",
+			Buckets: prometheus.ExponentialBuckets(0.05, 2, 12),
+		},
+		[]string{"operation"},
+	)
+
+	// RejectedNetworksUpdates counts the networks annotation updates the
+	// controller refused to process, labeled by the reason
+	RejectedNetworksUpdates = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rejected_networks_updates_total",
+			Help:      "Number of networks annotation updates rejected without being processed",
+		},
+		[]string{"reason"},
+	)
+
+	// IsLeader indicates if the replica holds the leader election lease
+	IsLeader = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "is_leader",
+			Help:      "Whether the replica holds the leader election lease, thus processing the dynamic attachment requests",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(DroppedRequests, NetNSResolutionErrors, CRIInfo, E2ELatency, RejectedNetworksUpdates, IsLeader)
+}
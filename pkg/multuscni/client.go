@@ -8,6 +8,10 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"os"
+
+	cni100 "github.com/containernetworking/cni/pkg/types/100"
+	"github.com/containernetworking/cni/pkg/types/create"
 
 	"k8s.io/klog/v2"
 
@@ -26,47 +30,138 @@ func MultusDelegateURL() string {
 
 type Client interface {
 	InvokeDelegate(req *multusapi.Request) (*multusapi.Response, error)
+	// InvokeDelegateWithContext invokes the delegate, giving up when the context is done.
+	InvokeDelegateWithContext(ctx context.Context, req *multusapi.Request) (*multusapi.Response, error)
+}
+
+// Pinger checks the multus server can be reached.
+type Pinger interface {
+	Ping(ctx context.Context) error
 }
 
 type HTTPClient struct {
 	httpClient *http.Client
 	serverURL  string
+	socketPath string
 }
 
+// NewClient returns a client of the multus server listening on the unix
+// socket.
 func NewClient(socketPath string) *HTTPClient {
 	return &HTTPClient{
 		httpClient: &http.Client{
 			Transport: &http.Transport{
 				DialContext: func(ctx context.Context, network string, addr string) (net.Conn, error) {
-					return net.Dial("unix", socketPath)
+					var dialer net.Dialer
+					return dialer.DialContext(ctx, "unix", socketPath)
 				},
 			},
 		},
-		serverURL: MultusDelegateURL(),
+		serverURL:  MultusDelegateURL(),
+		socketPath: socketPath,
 	}
 }
 
+// Ping checks the multus server listens on the client's socket, and replies to
+// HTTP requests; whatever the reply's status, since the delegate endpoint
+// only serves CNI requests.
+func (c *HTTPClient) Ping(ctx context.Context) error {
+	if c.socketPath != "" {
+		socketInfo, err := os.Stat(c.socketPath)
+		if err != nil {
+			return fmt.Errorf("the multus socket is missing: %v", err)
+		}
+		if socketInfo.Mode()&os.ModeSocket == 0 {
+			return fmt.Errorf("the multus socket %s is not a socket", c.socketPath)
+		}
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, c.serverURL, http.NoBody)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("the multus server does not respond on %s: %v", c.socketPath, err)
+	}
+	if err := resp.Body.Close(); err != nil {
+		klog.Errorf("failed closing the connection to the multus-server: %v", err)
+	}
+	return nil
+}
+
 func (c *HTTPClient) InvokeDelegate(req *multusapi.Request) (*multusapi.Response, error) {
-	httpResp, err := c.DoCNI(req)
+	return c.InvokeDelegateWithContext(context.Background(), req)
+}
+
+func (c *HTTPClient) InvokeDelegateWithContext(ctx context.Context, req *multusapi.Request) (*multusapi.Response, error) {
+	httpResp, err := c.DoCNIWithContext(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-	response := &multusapi.Response{}
-	if len(httpResp) != 0 {
-		if err = json.Unmarshal(httpResp, response); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal response '%s': %v", string(httpResp), err)
-		}
+	if len(httpResp) == 0 {
+		return &multusapi.Response{}, nil
+	}
+	response, err := parseResponse(httpResp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response '%s': %v", string(httpResp), err)
 	}
 	return response, nil
 }
 
+// parseResponse parses the multus server's response, converting the CNI result
+// - whichever spec version the delegate replied with, e.g. 0.3.1 - to the
+// current version; thus the result's interfaces and IPs are found where the
+// consumers expect them. The results not featuring their version are deemed
+// current.
+func parseResponse(body []byte) (*multusapi.Response, error) {
+	var rawResponse struct {
+		Result json.RawMessage
+	}
+	if err := json.Unmarshal(body, &rawResponse); err != nil {
+		return nil, err
+	}
+	if len(rawResponse.Result) == 0 || string(rawResponse.Result) == "null" {
+		return &multusapi.Response{}, nil
+	}
+
+	var versionedResult struct {
+		CNIVersion string `json:"cniVersion"`
+	}
+	if err := json.Unmarshal(rawResponse.Result, &versionedResult); err != nil {
+		return nil, err
+	}
+	version := versionedResult.CNIVersion
+	if version == "" {
+		result := &cni100.Result{}
+		if err := json.Unmarshal(rawResponse.Result, result); err != nil {
+			return nil, err
+		}
+		result.CNIVersion = cni100.ImplementedSpecVersion
+		return &multusapi.Response{Result: result}, nil
+	}
+	result, err := create.Create(version, rawResponse.Result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the CNI result: %v", err)
+	}
+	currentResult, err := cni100.NewResultFromResult(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert the CNI %s result to %s: %v", version, cni100.ImplementedSpecVersion, err)
+	}
+	return &multusapi.Response{Result: currentResult}, nil
+}
+
 func (c *HTTPClient) DoCNI(req *multusapi.Request) ([]byte, error) {
+	return c.DoCNIWithContext(context.Background(), req)
+}
+
+func (c *HTTPClient) DoCNIWithContext(ctx context.Context, req *multusapi.Request) ([]byte, error) {
 	data, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal CNI request %v: %v", req, err)
 	}
 
-	request, err := httpRequest(c.serverURL, data)
+	request, err := httpRequest(ctx, c.serverURL, data)
 	if err != nil {
 		return nil, err
 	}
@@ -92,8 +187,8 @@ func (c *HTTPClient) DoCNI(req *multusapi.Request) ([]byte, error) {
 	return body, nil
 }
 
-func httpRequest(serverURL string, payload []byte) (*http.Request, error) {
-	httpReq, err := http.NewRequestWithContext(context.Background(), http.MethodPost, serverURL, bytes.NewBuffer(payload))
+func httpRequest(ctx context.Context, serverURL string, payload []byte) (*http.Request, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, serverURL, bytes.NewBuffer(payload))
 	if err != nil {
 		return nil, err
 	}
@@ -1,11 +1,15 @@
 package multuscni
 
 import (
+	"context"
 	"encoding/json"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -22,7 +26,7 @@ func TestController(t *testing.T) {
 
 var _ = Describe("multuscni REST client", func() {
 	const (
-		cniVersion  = "0.4.0"
+		cniVersion  = cni100.ImplementedSpecVersion
 		networkName = "net1"
 		podIP       = "192.168.14.14/24"
 		podMAC      = "02:03:04:05:06:07"
@@ -49,6 +53,23 @@ var _ = Describe("multuscni REST client", func() {
 		Expect(err).To(MatchError(ContainSubstring("failed to unmarshal response '{asd:123}':")))
 	})
 
+	It("errors when the server does not reply within the context deadline", func() {
+		const (
+			serverDelay = time.Second
+			timeout     = 50 * time.Millisecond
+		)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(serverDelay)
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		defer server.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		_, err := newDummyClient(server.Client(), server.URL).InvokeDelegateWithContext(ctx, multusRequest())
+		Expect(err).To(MatchError(ContainSubstring(context.DeadlineExceeded.Error())))
+	})
+
 	DescribeTable("return the expected response", func(response *multusapi.Response) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusOK)
@@ -79,6 +100,97 @@ var _ = Describe("multuscni REST client", func() {
 			},
 		}),
 	)
+
+	DescribeTable("converts the CNI result to the current spec version", func(result string) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"Result":` + result + `}`))
+		}))
+
+		defer server.Close()
+		response, err := newDummyClient(server.Client(), server.URL).InvokeDelegate(multusRequest())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(response.Result.CNIVersion).To(Equal(cni100.ImplementedSpecVersion))
+		Expect(response.Result.Interfaces).To(HaveLen(1))
+		Expect(response.Result.Interfaces[0].Name).To(Equal(networkName))
+		Expect(response.Result.Interfaces[0].Mac).To(Equal(podMAC))
+		Expect(response.Result.IPs).To(HaveLen(1))
+		Expect(response.Result.IPs[0].Address.String()).To(Equal(podIP))
+	},
+		Entry("when the delegate replies with a 0.3.1 result", `{
+			"cniVersion": "0.3.1",
+			"interfaces": [{"name": "net1", "mac": "02:03:04:05:06:07", "sandbox": "/var/run/netns/pod"}],
+			"ips": [{"version": "4", "address": "192.168.14.14/24", "interface": 0}]
+		}`),
+		Entry("when the delegate replies with a 1.0.0 result", `{
+			"cniVersion": "1.0.0",
+			"interfaces": [{"name": "net1", "mac": "02:03:04:05:06:07", "sandbox": "/var/run/netns/pod"}],
+			"ips": [{"address": "192.168.14.14/24", "interface": 0}]
+		}`),
+		Entry("when the result does not feature its version", `{
+			"interfaces": [{"name": "net1", "mac": "02:03:04:05:06:07", "sandbox": "/var/run/netns/pod"}],
+			"ips": [{"address": "192.168.14.14/24", "interface": 0}]
+		}`),
+	)
+})
+
+var _ = Describe("multuscni client of the unix socket", func() {
+	var socketPath string
+
+	BeforeEach(func() {
+		socketDir, err := os.MkdirTemp("", "multus")
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(os.RemoveAll, socketDir)
+		socketPath = filepath.Join(socketDir, "multus.sock")
+	})
+
+	serve := func(handler http.HandlerFunc) {
+		listener, err := net.Listen("unix", socketPath)
+		Expect(err).NotTo(HaveOccurred())
+		server := &http.Server{Handler: handler, ReadHeaderTimeout: time.Second}
+		go func() { _ = server.Serve(listener) }()
+		DeferCleanup(server.Close)
+	}
+
+	It("invokes the delegate through the socket", func() {
+		serve(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Path).To(Equal(multusapi.MultusDelegateAPIEndpoint))
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"result":{"cniVersion":"0.4.0"}}`))
+		})
+
+		response, err := NewClient(socketPath).InvokeDelegate(multusRequest())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(response.Result).NotTo(BeNil())
+		Expect(response.Result.CNIVersion).To(Equal(cni100.ImplementedSpecVersion))
+	})
+
+	It("reaches the server listening on the socket, whatever it replies", func() {
+		serve(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		})
+
+		Expect(NewClient(socketPath).Ping(context.Background())).To(Succeed())
+	})
+
+	It("fails to reach a missing socket", func() {
+		Expect(NewClient(socketPath).Ping(context.Background())).To(MatchError(ContainSubstring("the multus socket is missing")))
+	})
+
+	It("fails to reach a socket nobody listens on", func() {
+		listener, err := net.Listen("unix", socketPath)
+		Expect(err).NotTo(HaveOccurred())
+		listener.(*net.UnixListener).SetUnlinkOnClose(false)
+		Expect(listener.Close()).To(Succeed())
+
+		Expect(NewClient(socketPath).Ping(context.Background())).To(MatchError(ContainSubstring("the multus server does not respond")))
+	})
+
+	It("fails to reach a path which is not a socket", func() {
+		Expect(os.WriteFile(socketPath, nil, 0600)).To(Succeed())
+
+		Expect(NewClient(socketPath).Ping(context.Background())).To(MatchError(ContainSubstring("is not a socket")))
+	})
 })
 
 func multusRequest() *multusapi.Request {
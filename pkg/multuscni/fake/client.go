@@ -1,35 +1,79 @@
 package fake
 
 import (
+	"context"
 	"fmt"
+	"sync"
 
 	multusapi "gopkg.in/k8snetworkplumbingwg/multus-cni.v3/pkg/server/api"
 )
 
+// NetworkConfig is the delegate's reply to the command on the interface: the
+// response, or - when set - the error.
 type NetworkConfig struct {
 	Cmd       string
 	IfaceName string
 	Response  *multusapi.Response
+	Err       error
 }
 
 type Client struct {
-	requestData map[string]*multusapi.Response
+	requestData map[string]NetworkConfig
+	lock        sync.Mutex
+	requests    []*multusapi.Request
+	// unreachableErr is the error of every ping, and delegate invocation, of
+	// an unreachable multus server
+	unreachableErr error
 }
 
 func NewFakeClient(currentStatus ...NetworkConfig) *Client {
-	mockedClient := &Client{requestData: map[string]*multusapi.Response{}}
+	mockedClient := &Client{requestData: map[string]NetworkConfig{}}
 	for i := range currentStatus {
-		mockedClient.requestData[keyFromCommandAndInterfaceName(currentStatus[i].Cmd, currentStatus[i].IfaceName)] = currentStatus[i].Response
+		mockedClient.requestData[keyFromCommandAndInterfaceName(currentStatus[i].Cmd, currentStatus[i].IfaceName)] = currentStatus[i]
 	}
 	return mockedClient
 }
 
+// NewUnreachableFakeClient returns a client of a multus server which cannot be
+// reached - e.g. its socket is missing.
+func NewUnreachableFakeClient() *Client {
+	mockedClient := NewFakeClient()
+	mockedClient.unreachableErr = fmt.Errorf("the multus server does not respond: dial unix: connect: no such file or directory")
+	return mockedClient
+}
+
+func (fc *Client) Ping(_ context.Context) error {
+	return fc.unreachableErr
+}
+
 func (fc *Client) InvokeDelegate(multusRequest *multusapi.Request) (*multusapi.Response, error) {
+	return fc.InvokeDelegateWithContext(context.Background(), multusRequest)
+}
+
+func (fc *Client) InvokeDelegateWithContext(_ context.Context, multusRequest *multusapi.Request) (*multusapi.Response, error) {
+	fc.lock.Lock()
+	fc.requests = append(fc.requests, multusRequest)
+	fc.lock.Unlock()
+
+	if fc.unreachableErr != nil {
+		return nil, fc.unreachableErr
+	}
 	serverReply, wasFound := fc.requestData[key(multusRequest)]
 	if !wasFound {
 		return nil, fmt.Errorf("not found")
 	}
-	return serverReply, nil
+	if serverReply.Err != nil {
+		return nil, serverReply.Err
+	}
+	return serverReply.Response, nil
+}
+
+// Requests returns the delegate requests the client received, in order.
+func (fc *Client) Requests() []*multusapi.Request {
+	fc.lock.Lock()
+	defer fc.lock.Unlock()
+
+	return append([]*multusapi.Request{}, fc.requests...)
 }
 
 func key(req *multusapi.Request) string {
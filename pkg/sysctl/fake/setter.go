@@ -0,0 +1,40 @@
+package fake
+
+import (
+	"sync"
+)
+
+// Setter records the sysctls applied in each network namespace, failing with
+// the configured error - if any.
+type Setter struct {
+	err     error
+	lock    sync.Mutex
+	sysctls map[string]map[string]string
+}
+
+func NewFakeSetter(err error) *Setter {
+	return &Setter{err: err, sysctls: map[string]map[string]string{}}
+}
+
+func (s *Setter) Set(netnsPath string, sysctls map[string]string) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.sysctls[netnsPath] == nil {
+		s.sysctls[netnsPath] = map[string]string{}
+	}
+	for name, value := range sysctls {
+		s.sysctls[netnsPath][name] = value
+	}
+	return nil
+}
+
+// Sysctls returns the sysctls applied in the network namespace.
+func (s *Setter) Sysctls(netnsPath string) map[string]string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.sysctls[netnsPath]
+}
@@ -0,0 +1,36 @@
+package sysctl
+
+import (
+	"fmt"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/containernetworking/plugins/pkg/utils/sysctl"
+)
+
+// Setter applies sysctls inside network namespaces
+type Setter interface {
+	// Set applies the sysctls - indexed by their name - inside the network
+	// namespace found at `netnsPath`.
+	Set(netnsPath string, sysctls map[string]string) error
+}
+
+// NetNSSetter applies the sysctls by entering the network namespace, since
+// the network sysctls in /proc/sys are scoped to the caller's network namespace.
+type NetNSSetter struct{}
+
+// NewNetNSSetter returns a Setter entering the network namespaces
+func NewNetNSSetter() *NetNSSetter {
+	return &NetNSSetter{}
+}
+
+// Set applies the sysctls inside the network namespace
+func (NetNSSetter) Set(netnsPath string, sysctls map[string]string) error {
+	return ns.WithNetNSPath(netnsPath, func(ns.NetNS) error {
+		for name, value := range sysctls {
+			if _, err := sysctl.Sysctl(name, value); err != nil {
+				return fmt.Errorf("failed to set sysctl %s to %s: %w", name, value, err)
+			}
+		}
+		return nil
+	})
+}
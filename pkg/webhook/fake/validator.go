@@ -0,0 +1,35 @@
+package fake
+
+import (
+	"context"
+	"sync"
+
+	"github.com/maiqueb/multus-dynamic-networks-controller/pkg/webhook"
+)
+
+// Validator replies to every review with the configured verdict - or error -,
+// recording the reviews it got.
+type Validator struct {
+	verdict *webhook.Verdict
+	err     error
+	lock    sync.Mutex
+	reviews []*webhook.Review
+}
+
+func NewFakeValidator(verdict *webhook.Verdict, err error) *Validator {
+	return &Validator{verdict: verdict, err: err}
+}
+
+func (v *Validator) Validate(_ context.Context, review *webhook.Review) (*webhook.Verdict, error) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	v.reviews = append(v.reviews, review)
+	return v.verdict, v.err
+}
+
+// Reviews returns the reviews the validator got, in order.
+func (v *Validator) Reviews() []*webhook.Review {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	return append([]*webhook.Review{}, v.reviews...)
+}
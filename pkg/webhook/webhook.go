@@ -0,0 +1,92 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+)
+
+// DefaultTimeout bounds how long the webhook is given to reply
+const DefaultTimeout = 10 * time.Second
+
+// Review describes the attachments a networks annotation update adds to, and
+// removes from, a pod.
+type Review struct {
+	PodName      string                           `json:"podName"`
+	PodNamespace string                           `json:"podNamespace"`
+	Add          []*nadv1.NetworkSelectionElement `json:"add,omitempty"`
+	Remove       []*nadv1.NetworkSelectionElement `json:"remove,omitempty"`
+}
+
+// Verdict is the webhook's reply to a review.
+type Verdict struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// Validator decides if the networks of a pod can be updated
+type Validator interface {
+	// Validate returns the verdict on the reviewed networks update, or an
+	// error when no verdict could be reached.
+	Validate(ctx context.Context, review *Review) (*Verdict, error)
+}
+
+// HTTPValidator posts the reviews to a webhook, as JSON; the webhook must
+// reply with a 200 OK status, and a JSON encoded verdict.
+type HTTPValidator struct {
+	httpClient *http.Client
+	url        string
+}
+
+// NewHTTPValidator returns a Validator posting the reviews to `url`, giving up
+// on the webhook after `timeout`.
+func NewHTTPValidator(url string, timeout time.Duration) *HTTPValidator {
+	return &HTTPValidator{
+		httpClient: &http.Client{Timeout: timeout},
+		url:        url,
+	}
+}
+
+func (v *HTTPValidator) Validate(ctx context.Context, review *Review) (*Verdict, error) {
+	data, err := json.Marshal(review)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the review: %v", err)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, v.url, bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := v.httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send the review: %v", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			klog.Errorf("failed closing the connection to the webhook: %v", err)
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the verdict: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected webhook response status %v: '%s'", resp.StatusCode, string(body))
+	}
+
+	verdict := &Verdict{}
+	if err := json.Unmarshal(body, verdict); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the verdict '%s': %v", string(body), err)
+	}
+	return verdict, nil
+}
@@ -0,0 +1,61 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+)
+
+func TestWebhook(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Networks update webhook suite")
+}
+
+var _ = Describe("The networks update webhook client", func() {
+	review := &Review{
+		PodName:      "tpod",
+		PodNamespace: "ns1",
+		Add:          []*nadv1.NetworkSelectionElement{{Name: "tenantnetwork", Namespace: "ns1", InterfaceRequest: "net1"}},
+	}
+
+	stubWebhook := func(status int, reply string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			receivedReview := &Review{}
+			Expect(json.NewDecoder(r.Body).Decode(receivedReview)).To(Succeed())
+			Expect(receivedReview).To(Equal(review))
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(reply))
+		}))
+	}
+
+	It("returns the allowing verdict", func() {
+		server := stubWebhook(http.StatusOK, `{"allowed": true}`)
+		defer server.Close()
+
+		Expect(NewHTTPValidator(server.URL, DefaultTimeout).Validate(context.Background(), review)).To(
+			Equal(&Verdict{Allowed: true}))
+	})
+
+	It("returns the denying verdict, along with its reason", func() {
+		server := stubWebhook(http.StatusOK, `{"allowed": false, "reason": "tenantnetwork is off limits"}`)
+		defer server.Close()
+
+		Expect(NewHTTPValidator(server.URL, DefaultTimeout).Validate(context.Background(), review)).To(
+			Equal(&Verdict{Allowed: false, Reason: "tenantnetwork is off limits"}))
+	})
+
+	It("errors when the webhook replies with anything other than 200 OK status", func() {
+		server := stubWebhook(http.StatusInternalServerError, `kablewit`)
+		defer server.Close()
+
+		_, err := NewHTTPValidator(server.URL, DefaultTimeout).Validate(context.Background(), review)
+		Expect(err).To(MatchError("unexpected webhook response status 500: 'kablewit'"))
+	})
+})